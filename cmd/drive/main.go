@@ -26,6 +26,7 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/odeke-em/command"
 	"github.com/odeke-em/drive/config"
@@ -36,6 +37,26 @@ import (
 
 var context *config.Context
 
+// configDir is the global override for where the
+// credentials and state of a drive context are kept.
+var configDir *string
+
+// remoteRoot is the global override for the remote
+// folder that the drive context maps to.
+var remoteRoot *string
+
+// impersonate is the global email of the user that
+// service account credentials are to act as.
+var impersonate *string
+
+// maxQPS is the global limit of the number of
+// requests per second sent to the API.
+var maxQPS *float64
+
+// checkOnly when set makes commands only validate and report their
+// configuration instead of running, see newCommands.
+var checkOnly *bool
+
 type errorer func() error
 
 func bindCommandWithAliases(key, description string, cmd command.Cmd, requiredFlags []string) {
@@ -83,6 +104,13 @@ func main() {
 	}
 	runtime.GOMAXPROCS(int(maxProcs))
 
+	configDir = flag.String(drive.CLIOptionConfigDir, os.Getenv(drive.DriveConfigDirEnvKey), drive.DescConfigDir)
+	checkOnly = flag.Bool(drive.CLIOptionCheck, false, drive.DescCheck)
+	remoteRoot = flag.String(drive.CLIOptionRemoteRoot, "", drive.DescRemoteRoot)
+	impersonate = flag.String(drive.CLIOptionImpersonate, "", drive.DescImpersonate)
+	maxQPS = flag.Float64(drive.CLIOptionMaxQPS, 0, drive.DescMaxQPS)
+	flag.Float64Var(maxQPS, drive.CLIOptionMaxAPIQPS, 0, "alias for -"+drive.CLIOptionMaxQPS)
+
 	bindCommandWithAliases(drive.AboutKey, drive.DescAbout, &aboutCmd{}, []string{})
 	bindCommandWithAliases(drive.CopyKey, drive.DescCopy, &copyCmd{}, []string{})
 	bindCommandWithAliases(drive.DiffKey, drive.DescDiff, &diffCmd{}, []string{})
@@ -121,6 +149,19 @@ func main() {
 	bindCommandWithAliases(drive.ClashesKey, drive.DescFixClashes, &clashesCmd{}, []string{})
 	bindCommandWithAliases(drive.IdKey, drive.DescId, &idCmd{}, []string{})
 	bindCommandWithAliases(drive.ReportIssueKey, drive.DescReportIssue, &issueCmd{}, []string{})
+	bindCommandWithAliases(drive.ExportLinksKey, drive.DescExportLinks, &exportLinksCmd{}, []string{})
+	bindCommandWithAliases(drive.VerifyKey, drive.DescVerify, &verifyCmd{}, []string{})
+	bindCommandWithAliases(drive.WatchKey, drive.DescWatch, &watchCmd{}, []string{})
+	bindCommandWithAliases(drive.DedupeKey, drive.DescDedupe, &dedupeCmd{}, []string{})
+	bindCommandWithAliases(drive.ConfigKey, drive.DescConfig, &configCmd{}, []string{})
+	bindCommandWithAliases(drive.TrashOlderThanKey, drive.DescTrashOlderThan, &trashOlderThanCmd{}, []string{})
+	bindCommandWithAliases(drive.DoctorKey, drive.DescDoctor, &doctorCmd{}, []string{})
+	bindCommandWithAliases(drive.ResetSessionsKey, drive.DescResetSessions, &resetSessionsCmd{}, []string{})
+	bindCommandWithAliases(drive.FindKey, drive.DescFind, &findCmd{}, []string{})
+	bindCommandWithAliases(drive.ChangesKey, drive.DescChanges, &changesCmd{}, []string{})
+	bindCommandWithAliases(drive.UndoKey, drive.DescUndo, &undoCmd{}, []string{})
+	bindCommandWithAliases(drive.DuplicatesKey, drive.DescDuplicates, &duplicatesCmd{}, []string{})
+	bindCommandWithAliases(drive.CommentsKey, drive.DescComments, &commentsCmd{}, []string{})
 
 	command.DefineHelp(&helpCmd{})
 	command.ParseAndRun()
@@ -148,7 +189,7 @@ func (cmd *featuresCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *featuresCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	context, path := discoverContext(args)
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path: path,
 	}).About(drive.AboutFeatures))
 }
@@ -166,21 +207,59 @@ func (cmd *versionCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 type initCmd struct {
 	ServiceAccountJSONFile *string `json:"-"`
+	RemoteRoot             *string `json:"-"`
+	Remote                 *string `json:"-"`
+	AuthPort               *int    `json:"-"`
+	AuthManual             *bool   `json:"-"`
+	AuthActivity           *bool   `json:"-"`
+	BindAddr               *string `json:"-"`
 }
 
 func (cmd *initCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.ServiceAccountJSONFile = fs.String(drive.ServiceAccountJSONFileKey, "", "points the Google Service Account JSON file")
+	cmd.RemoteRoot = fs.String(drive.CLIOptionRemoteRoot, "", drive.DescRemoteRoot)
+	cmd.Remote = fs.String(drive.CLIOptionInitRemote, "", drive.DescInitRemote)
+	cmd.AuthPort = fs.Int(drive.CLIOptionAuthPort, 0, drive.DescAuthPort)
+	cmd.AuthManual = fs.Bool(drive.CLIOptionAuthManual, false, drive.DescAuthManual)
+	cmd.AuthActivity = fs.Bool(drive.ActivityKey, false, drive.DescAuthActivity)
+	cmd.BindAddr = fs.String(drive.CLIOptionBindAddr, drive.DefaultAuthBindAddr, drive.DescAuthBindAddr)
 	return fs
 }
 
 func (cmd *initCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
-	comm := drive.New(initContext(args), nil)
+	remote := strings.TrimSpace(*cmd.Remote)
+	if remote != "" && strings.TrimSpace(*cmd.RemoteRoot) != "" {
+		exitWithError(fmt.Errorf("cannot use both `%s` and `%s`", drive.CLIOptionInitRemote, drive.CLIOptionRemoteRoot))
+	}
+
+	ctx := initContext(args)
+	comm := drive.New(ctx, &drive.Options{
+		AuthPort:     *cmd.AuthPort,
+		AuthManual:   *cmd.AuthManual,
+		AuthActivity: *cmd.AuthActivity,
+		Impersonate:  *impersonate,
+
+		AuthBindAddr: strings.TrimSpace(*cmd.BindAddr),
+	})
+	// Impersonation can only be verified once the service
+	// account is known, so only the check is done up front.
+	exitIfCheckOnly(comm)
+	// There are no credentials to look the remote root up with yet,
+	// so it is only persisted here and resolved by later commands.
+	if root := strings.TrimSpace(*cmd.RemoteRoot); root != "" {
+		ctx.RemoteRoot = root
+	}
 	gcsJSONFile := *cmd.ServiceAccountJSONFile
 	if gcsJSONFile == "" {
 		exitWithError(comm.Init())
 	} else {
 		exitWithError(comm.InitWithServiceAccount(gcsJSONFile))
 	}
+
+	if remote != "" {
+		// Now that there are credentials, the folder can be looked up
+		exitWithError(drive.New(ctx, nil).AdoptRemoteRoot(remote))
+	}
 }
 
 type deInitCmd struct {
@@ -199,7 +278,7 @@ func (cmd *deInitCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Path:     path,
 	}
 
-	exitWithError(drive.New(context, opts).DeInit())
+	exitWithError(newCommands(context, opts).DeInit())
 }
 
 type quotaCmd struct{}
@@ -211,7 +290,7 @@ func (cmd *quotaCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *quotaCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	context, path := discoverContext(args)
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path: path,
 	}).About(drive.AboutQuota))
 }
@@ -262,7 +341,7 @@ func (ocmd *openCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		openType |= drive.FileManagerOpen
 	}
 
-	exitWithError(drive.New(context, &opts).Open(openType))
+	exitWithError(newCommands(context, &opts).Open(openType))
 }
 
 type editDescriptionCmd struct {
@@ -296,7 +375,7 @@ func (cmd *editDescriptionCmd) Run(args []string, definedFlags map[string]*flag.
 		Sources: sources,
 	}
 
-	exitWithError(drive.New(context, &opts).EditDescription(*cmd.ById))
+	exitWithError(newCommands(context, &opts).EditDescription(*cmd.ById))
 }
 
 type urlCmd struct {
@@ -316,7 +395,532 @@ func (cmd *urlCmd) Run(args []string, definedArgs map[string]*flag.Flag) {
 		Sources: sources,
 	}
 
-	exitWithError(drive.New(context, &opts).Url(*cmd.ById))
+	exitWithError(newCommands(context, &opts).Url(*cmd.ById))
+}
+
+type exportLinksCmd struct {
+	ById   *bool   `json:"by-id"`
+	Format *string `json:"format"`
+}
+
+func (cmd *exportLinksCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById = fs.Bool(drive.CLIOptionId, false, "resolve export links by id instead of path")
+	cmd.Format = fs.String(drive.ExportFormatKey, "", drive.DescExportFormat)
+	return fs
+}
+
+func (cmd *exportLinksCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	meta := map[string][]string{
+		drive.ExportFormatKey: drive.NonEmptyTrimmedStrings(*cmd.Format),
+	}
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+		Meta:    &meta,
+	}
+
+	exitWithError(newCommands(context, &opts).ExportLinks(*cmd.ById))
+}
+
+type verifyCmd struct {
+	Hidden  *bool `json:"hidden"`
+	Deep    *bool `json:"deep"`
+	Quiet   *bool `json:"quiet"`
+	Verbose *bool `json:"verbose"`
+}
+
+func (cmd *verifyCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "discover hidden paths")
+	cmd.Deep = fs.Bool(drive.CLIOptionVerifyDeep, false, drive.DescVerifyDeep)
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, "also report files whose checksums match")
+	return fs
+}
+
+func (vcmd *verifyCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := verifyCmd{}
+	df := defaultsFiller{
+		command: drive.VerifyKey,
+		from:    *vcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	opts := drive.Options{
+		Path:       path,
+		Sources:    sources,
+		Hidden:     *cmd.Hidden,
+		VerifyDeep: *cmd.Deep,
+		Quiet:      *cmd.Quiet,
+		Verbose:    *cmd.Verbose,
+	}
+
+	exitWithError(newCommands(context, &opts).Verify())
+}
+
+type watchCmd struct {
+	Hidden                       *bool   `json:"hidden"`
+	Debounce                     *string `json:"debounce"`
+	PollInterval                 *string `json:"poll"`
+	IgnoreConflict               *bool   `json:"ignore-conflict"`
+	ExponentialBackoffRetryCount *int    `json:"retry-count"`
+	Quiet                        *bool   `json:"quiet"`
+	Verbose                      *bool   `json:"verbose"`
+}
+
+func (cmd *watchCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows watching and pushing of hidden paths")
+	cmd.Debounce = fs.String(drive.CLIOptionDebounce, drive.DefaultWatchDebounce.String(), drive.DescDebounce)
+	cmd.PollInterval = fs.String(drive.CLIOptionPollInterval, "", drive.DescPollInterval)
+	cmd.IgnoreConflict = fs.Bool(drive.CLIOptionIgnoreConflict, false, drive.DescIgnoreConflict)
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	return fs
+}
+
+func (wcmd *watchCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := watchCmd{}
+	df := defaultsFiller{
+		command: drive.WatchKey,
+		from:    *wcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	debounce, err := time.ParseDuration(*cmd.Debounce)
+	if err != nil {
+		exitWithError(fmt.Errorf("-%s: %v", drive.CLIOptionDebounce, err))
+	}
+
+	var pollInterval time.Duration
+	if *cmd.PollInterval != "" {
+		if pollInterval, err = time.ParseDuration(*cmd.PollInterval); err != nil || pollInterval <= 0 {
+			exitWithError(fmt.Errorf("-%s: %q must be a positive duration", drive.CLIOptionPollInterval, *cmd.PollInterval))
+		}
+	}
+
+	opts := drive.Options{
+		Path:                         path,
+		Sources:                      sources,
+		Hidden:                       *cmd.Hidden,
+		Debounce:                     debounce,
+		PollInterval:                 pollInterval,
+		IgnoreConflict:               *cmd.IgnoreConflict,
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+		Quiet:                        *cmd.Quiet,
+		Verbose:                      *cmd.Verbose,
+		Recursive:                    true,
+		Depth:                        drive.InfiniteDepth,
+		IgnoreChecksum:               true,
+		NoPrompt:                     true,
+	}
+
+	if pollInterval > 0 {
+		exitWithError(newCommands(context, &opts).PollPull())
+		return
+	}
+
+	exitWithError(newCommands(context, &opts).Watch())
+}
+
+type dedupeCmd struct {
+	Hidden           *bool `json:"hidden"`
+	Depth            *int  `json:"depth"`
+	Recursive        *bool `json:"recursive"`
+	DryRun           *bool `json:"dry-run"`
+	RepointShortcuts *bool `json:"repoint-shortcuts"`
+	NoPrompt         *bool `json:"no-prompt"`
+	Quiet            *bool `json:"quiet"`
+}
+
+func (cmd *dedupeCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "also deduplicate hidden paths")
+	cmd.Depth = fs.Int(drive.DepthKey, 1, "maximum recursion depth")
+	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursively deduplicate subfolders")
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRun)
+	cmd.RepointShortcuts = fs.Bool(drive.CLIOptionRepointShortcuts, false, drive.DescRepointShortcuts)
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before trashing duplicates")
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	return fs
+}
+
+func (dcmd *dedupeCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := dedupeCmd{}
+	df := defaultsFiller{
+		command: drive.DedupeKey,
+		from:    *dcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	depth := *cmd.Depth
+	if *cmd.Recursive {
+		depth = drive.InfiniteDepth
+	}
+
+	opts := drive.Options{
+		Path:             path,
+		Sources:          sources,
+		Hidden:           *cmd.Hidden,
+		Depth:            depth,
+		Recursive:        *cmd.Recursive,
+		DryRun:           *cmd.DryRun,
+		RepointShortcuts: *cmd.RepointShortcuts,
+		NoPrompt:         *cmd.NoPrompt,
+		Quiet:            *cmd.Quiet,
+	}
+
+	exitWithError(newCommands(context, &opts).Dedupe())
+}
+
+type resetSessionsCmd struct{}
+
+func (cmd *resetSessionsCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	return fs
+}
+
+func (cmd *resetSessionsCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+
+	exitWithError(newCommands(context, &drive.Options{
+		Path: path,
+	}).ResetSessions())
+}
+
+type findCmd struct {
+	NameContains  *repeatedStringsFlag `json:"-"`
+	Owners        *repeatedStringsFlag `json:"-"`
+	ModifiedAfter *string              `json:"modified-after"`
+	Mime          *string              `json:"mime"`
+	Starred       *bool                `json:"starred"`
+	Trashed       *bool                `json:"trashed"`
+	Query         *string              `json:"query"`
+	Hidden        *bool                `json:"hidden"`
+	Format        *string              `json:"format"`
+	Quiet         *bool                `json:"quiet"`
+	Verbose       *bool                `json:"verbose"`
+}
+
+func (cmd *findCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.NameContains = &repeatedStringsFlag{}
+	fs.Var(cmd.NameContains, drive.CLIOptionNameContains, drive.DescFindNameContains)
+	cmd.Owners = &repeatedStringsFlag{}
+	fs.Var(cmd.Owners, drive.CLIOptionOwner, drive.DescFindOwner)
+
+	cmd.ModifiedAfter = fs.String(drive.CLIOptionModifiedAfter, "", drive.DescFindModifiedAfter)
+	cmd.Mime = fs.String(drive.CLIOptionMime, "", drive.DescFindMime)
+	cmd.Starred = fs.Bool(drive.CLIOptionStarred, false, drive.DescFindStarred)
+	cmd.Trashed = fs.Bool(drive.CLIOptionTrashed, false, drive.DescFindTrashed)
+	cmd.Query = fs.String(drive.CLIOptionQuery, "", drive.DescFindQuery)
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "also find hidden files")
+	cmd.Format = fs.String(drive.CLIOptionListFormat, "", drive.DescListFormat)
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, "print the query that the predicates compile into")
+	fs.BoolVar(cmd.Verbose, "v", false, "alias for -"+drive.CLIOptionVerboseKey)
+	return fs
+}
+
+func (fcmd *findCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	_, context, path := preprocessArgsByToggle(args, true)
+
+	cmd := findCmd{}
+	df := defaultsFiller{
+		command: drive.FindKey,
+		from:    *fcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	fq := &drive.FindQuery{
+		MimeTypes: drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Mime, ",")...),
+		Starred:   *cmd.Starred,
+		Trashed:   *cmd.Trashed,
+		Raw:       *cmd.Query,
+	}
+
+	// Repeated flags aren't read from .driverc so they are
+	// retrieved from the flags as they were parsed.
+	if fcmd.NameContains != nil {
+		fq.NameContains = drive.NonEmptyStrings(*fcmd.NameContains...)
+	}
+	if fcmd.Owners != nil {
+		fq.Owners = drive.NonEmptyTrimmedStrings(*fcmd.Owners...)
+	}
+
+	if modifiedAfter := strings.TrimSpace(*cmd.ModifiedAfter); modifiedAfter != "" {
+		after, err := drive.ParseSince(modifiedAfter, time.Now())
+		if err != nil {
+			exitWithError(err)
+		}
+		fq.ModifiedAfter = after
+	}
+
+	opts := drive.Options{
+		Path:       path,
+		FindQuery:  fq,
+		Hidden:     *cmd.Hidden,
+		ListFormat: *cmd.Format,
+		Quiet:      *cmd.Quiet,
+		Verbose:    *cmd.Verbose,
+	}
+
+	exitWithError(newCommands(context, &opts).Find())
+}
+
+type changesCmd struct {
+	SinceChangeId *int64  `json:"since-change-id"`
+	Follow        *bool   `json:"follow"`
+	PollInterval  *string `json:"poll"`
+}
+
+func (cmd *changesCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.SinceChangeId = fs.Int64(drive.CLIOptionSinceChangeId, 0, drive.DescSinceChangeId)
+	cmd.Follow = fs.Bool(drive.CLIOptionFollow, false, drive.DescFollow)
+	cmd.PollInterval = fs.String(drive.CLIOptionPollInterval, drive.DefaultChangesPollInterval.String(), drive.DescChangesPollInterval)
+	return fs
+}
+
+func (ccmd *changesCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	context, path := discoverContext(args)
+
+	cmd := changesCmd{}
+	df := defaultsFiller{
+		command: drive.ChangesKey,
+		from:    *ccmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	pollInterval, err := time.ParseDuration(*cmd.PollInterval)
+	if err != nil || pollInterval <= 0 {
+		exitWithError(fmt.Errorf("-%s: %q must be a positive duration", drive.CLIOptionPollInterval, *cmd.PollInterval))
+	}
+
+	opts := drive.Options{
+		Path:          path,
+		SinceChangeId: *cmd.SinceChangeId,
+		Follow:        *cmd.Follow,
+		PollInterval:  pollInterval,
+	}
+
+	exitWithError(newCommands(context, &opts).ChangeFeed())
+}
+
+type duplicatesCmd struct {
+	Hidden    *bool `json:"hidden"`
+	Depth     *int  `json:"depth"`
+	ByContent *bool `json:"by-content"`
+	ByName    *bool `json:"by-name"`
+	Quiet     *bool `json:"quiet"`
+}
+
+func (cmd *duplicatesCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "also list hidden paths")
+	cmd.Depth = fs.Int(drive.DepthKey, drive.InfiniteDepth, "maximum recursion depth")
+	cmd.ByContent = fs.Bool(drive.CLIOptionByContent, false, drive.DescDuplicatesByContent)
+	cmd.ByName = fs.Bool(drive.CLIOptionByName, false, drive.DescDuplicatesByName)
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	return fs
+}
+
+func (dcmd *duplicatesCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := duplicatesCmd{}
+	df := defaultsFiller{
+		command: drive.DuplicatesKey,
+		from:    *dcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	opts := drive.Options{
+		Path:                path,
+		Sources:             sources,
+		Hidden:              *cmd.Hidden,
+		Depth:               *cmd.Depth,
+		DuplicatesByContent: *cmd.ByContent,
+		DuplicatesByName:    *cmd.ByName,
+		Quiet:               *cmd.Quiet,
+	}
+
+	exitWithError(newCommands(context, &opts).Duplicates())
+}
+
+type trashOlderThanCmd struct {
+	Hidden      *bool   `json:"hidden"`
+	Cutoff      *string `json:"cutoff"`
+	MinFileSize *string `json:"min-size"`
+	DryRun      *bool   `json:"dry-run"`
+	NoPrompt    *bool   `json:"no-prompt"`
+	Quiet       *bool   `json:"quiet"`
+}
+
+func (cmd *trashOlderThanCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "also trash hidden paths")
+	cmd.Cutoff = fs.String(drive.CLIOptionCutoff, "", drive.DescOlderThanCutoff)
+	cmd.MinFileSize = fs.String(drive.CLIOptionMinFileSize, "", drive.DescMinFileSize)
+	cmd.DryRun = fs.Bool(drive.CLIOptionDryRun, false, drive.DescDryRun)
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before trashing the files")
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	return fs
+}
+
+func (tcmd *trashOlderThanCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	sources, context, path := preprocessArgs(args)
+
+	cmd := trashOlderThanCmd{}
+	df := defaultsFiller{
+		command: drive.TrashOlderThanKey,
+		from:    *tcmd, to: &cmd,
+		rcSourcePath: context.AbsPathOf(path),
+		definedFlags: definedFlags,
+	}
+
+	if err := fillWithDefaults(df); err != nil {
+		exitWithError(err)
+	}
+
+	if strings.TrimSpace(*cmd.Cutoff) == "" {
+		exitWithError(fmt.Errorf("-%s: expecting a cutoff e.g 90d", drive.CLIOptionCutoff))
+	}
+
+	olderThan, err := drive.ParseSince(*cmd.Cutoff, time.Now())
+	if err != nil {
+		exitWithError(err)
+	}
+
+	minFileSize, _, err := parseFileSizeRange(*cmd.MinFileSize, "")
+	if err != nil {
+		exitWithError(err)
+	}
+
+	opts := drive.Options{
+		Path:        path,
+		Sources:     sources,
+		Hidden:      *cmd.Hidden,
+		OlderThan:   olderThan,
+		MinFileSize: minFileSize,
+		DryRun:      *cmd.DryRun,
+		NoPrompt:    *cmd.NoPrompt,
+		Quiet:       *cmd.Quiet,
+	}
+
+	exitWithError(newCommands(context, &opts).TrashOlderThan())
+}
+
+type configCmd struct{}
+
+func (cmd *configCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	return fs
+}
+
+func (cmd *configCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	// The arguments are the setting and its values, not paths
+	_, context, path := preprocessArgsByToggle(args, true)
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: args,
+	}
+
+	exitWithError(newLocalCommands(context, &opts).Config())
+}
+
+type doctorCmd struct{}
+
+func (cmd *doctorCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	return fs
+}
+
+func (cmd *doctorCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	_, context, path := preprocessArgs(args)
+
+	// Not through newCommands, since the remote root
+	// lookup would fail before anything was diagnosed.
+	exitWithError(newLocalCommands(context, &drive.Options{Path: path}).Doctor())
+}
+
+type commentsCmd struct {
+	ById           *bool   `json:"by-id"`
+	Message        *string `json:"message"`
+	IncludeDeleted *bool   `json:"deleted"`
+}
+
+func (cmd *commentsCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.ById, cmd.Message, cmd.IncludeDeleted = new(bool), new(string), new(bool)
+	return cmd.bindFlags(fs)
+}
+
+// bindFlags defines the flags on fs with the values they already hold as defaults.
+func (cmd *commentsCmd) bindFlags(fs *flag.FlagSet) *flag.FlagSet {
+	fs.BoolVar(cmd.ById, drive.CLIOptionId, *cmd.ById, "refer to the files by id instead of path")
+	fs.StringVar(cmd.Message, drive.CLIOptionCommentMessage, *cmd.Message, drive.DescCommentMessage)
+	fs.BoolVar(cmd.IncludeDeleted, drive.CLIOptionIncludeDeletedComments, *cmd.IncludeDeleted, drive.DescIncludeDeletedComments)
+	return fs
+}
+
+func (cmd *commentsCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	subcommand := drive.CommentsListKey
+	if len(args) >= 1 && (args[0] == drive.CommentsListKey || args[0] == drive.CommentsAddKey) {
+		subcommand, args = args[0], args[1:]
+
+		// Parsing stopped at the subcommand, so the flags after it are parsed here
+		fs := cmd.bindFlags(flag.NewFlagSet(drive.CommentsKey+" "+subcommand, flag.ExitOnError))
+		exitWithError(fs.Parse(args))
+		args = fs.Args()
+	}
+
+	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
+
+	opts := drive.Options{
+		Path:    path,
+		Sources: sources,
+
+		CommentMessage:         *cmd.Message,
+		IncludeDeletedComments: *cmd.IncludeDeleted,
+	}
+
+	g := newCommands(context, &opts)
+	if subcommand == drive.CommentsAddKey {
+		exitWithError(g.AddComment(*cmd.ById))
+	} else {
+		exitWithError(g.Comments(*cmd.ById))
+	}
 }
 
 type listCmd struct {
@@ -342,6 +946,14 @@ type listCmd struct {
 	ExactOwner   *string `json:"exact-owner"`
 	NotOwner     *string `json:"not-owner"`
 	Sort         *string `json:"sort"`
+	OrderBy      *string `json:"order-by"`
+	Reverse      *bool   `json:"reverse"`
+	Format       *string `json:"format"`
+	Parents      *bool   `json:"parents-as-labels"`
+	JSON         *bool   `json:"json"`
+	ChunkedList  *bool   `json:"chunked-list"`
+
+	ConcurrentList *int `json:"concurrent-list"`
 }
 
 func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -349,6 +961,8 @@ func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "list all paths even hidden ones")
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "list only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "list all directories")
+	fs.BoolVar(cmd.Files, drive.CLIOptionOnlyFiles, false, "alias for -"+drive.CLIOptionFiles)
+	fs.BoolVar(cmd.Directories, drive.CLIOptionOnlyFolders, false, "alias for -"+drive.CLIOptionDirectories)
 	cmd.LongFmt = fs.Bool(drive.CLIOptionLongFmt, false, "long listing of contents")
 	cmd.PageSize = fs.Int64(drive.PageSizeKey, 100, "number of results per pagination")
 	cmd.Shared = fs.Bool("shared", false, "show files that are shared with me")
@@ -367,6 +981,13 @@ func (cmd *listCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.ExactOwner = fs.String(drive.CLIOptionExactOwner, "", drive.DescExactOwner)
 	cmd.NotOwner = fs.String(drive.CLIOptionNotOwner, "", drive.DescNotOwner)
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "list by id instead of path")
+	cmd.OrderBy = fs.String(drive.CLIOptionOrderBy, "", drive.DescOrderBy)
+	cmd.Reverse = fs.Bool(drive.CLIOptionReverse, false, drive.DescReverse)
+	cmd.Format = fs.String(drive.CLIOptionListFormat, "", drive.DescListFormat)
+	cmd.Parents = fs.Bool(drive.CLIOptionParentsAsLabels, false, drive.DescParentsAsLabels)
+	cmd.JSON = fs.Bool(drive.CLIOptionListJSON, false, drive.DescListJSON)
+	cmd.ChunkedList = fs.Bool(drive.CLIOptionChunkedList, false, drive.DescChunkedList)
+	cmd.ConcurrentList = fs.Int(drive.CLIOptionConcurrentList, 0, drive.DescConcurrentList)
 
 	return fs
 }
@@ -404,6 +1025,9 @@ func (lCmd *listCmd) _run(args []string, definedFlags map[string]*flag.Flag, dis
 	if *cmd.InTrash {
 		typeMask |= drive.InTrash
 	}
+	if *cmd.Parents {
+		typeMask |= drive.ParentsAsLabels
+	}
 
 	if diskUsageSubset {
 		typeMask |= drive.DiskUsageOnly
@@ -429,26 +1053,34 @@ func (lCmd *listCmd) _run(args []string, definedFlags map[string]*flag.Flag, dis
 	}
 
 	opts := &drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Depth:     depth,
-		Hidden:    *cmd.Hidden,
-		InTrash:   *cmd.InTrash,
-		PageSize:  *cmd.PageSize,
-		NoPrompt:  *cmd.NoPrompt,
-		Recursive: *cmd.Recursive,
-		TypeMask:  typeMask,
-		Quiet:     *cmd.Quiet,
-		Meta:      &meta,
-		Match:     *cmd.Matches,
+		Path:       path,
+		Sources:    sources,
+		Depth:      depth,
+		Hidden:     *cmd.Hidden,
+		InTrash:    *cmd.InTrash,
+		PageSize:   *cmd.PageSize,
+		NoPrompt:   *cmd.NoPrompt,
+		Recursive:  *cmd.Recursive,
+		TypeMask:   typeMask,
+		Quiet:      *cmd.Quiet,
+		Meta:       &meta,
+		Match:      *cmd.Matches,
+		OrderBy:    drive.NonEmptyTrimmedStrings(strings.Split(*cmd.OrderBy, ",")...),
+		Reverse:    *cmd.Reverse,
+		ListFormat: *cmd.Format,
+
+		ListJSON:    *cmd.JSON,
+		ChunkedList: *cmd.ChunkedList,
+
+		ConcurrentList: *cmd.ConcurrentList,
 	}
 
 	if *cmd.Shared {
-		return drive.New(context, opts).ListShared()
+		return newCommands(context, opts).ListShared()
 	} else if *cmd.Matches {
-		return drive.New(context, opts).ListMatches()
+		return newCommands(context, opts).ListMatches()
 	} else {
-		return drive.New(context, opts).List(*cmd.ById)
+		return newCommands(context, opts).List(*cmd.ById)
 	}
 
 	return nil
@@ -515,9 +1147,9 @@ func (mcmd *md5SumCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	if *cmd.ById {
-		exitWithError(drive.New(context, &opts).StatById())
+		exitWithError(newCommands(context, &opts).StatById())
 	} else {
-		exitWithError(drive.New(context, &opts).Stat())
+		exitWithError(newCommands(context, &opts).Stat())
 	}
 }
 
@@ -528,6 +1160,7 @@ type statCmd struct {
 	Recursive *bool `json:"recursive"`
 	Quiet     *bool `json:"quiet"`
 	Md5sum    *bool `json:"md5sum"`
+	Activity  *bool `json:"activity"`
 }
 
 func (cmd *statCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -537,6 +1170,7 @@ func (cmd *statCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "stat by id instead of path")
 	cmd.Md5sum = fs.Bool(drive.Md5sumKey, false, "produce output compatible with md5sum(1)")
+	cmd.Activity = fs.Bool(drive.ActivityKey, false, drive.DescActivity)
 	return fs
 }
 
@@ -569,12 +1203,13 @@ func (scmd *statCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Recursive: *cmd.Recursive,
 		Quiet:     *cmd.Quiet,
 		Md5sum:    *cmd.Md5sum,
+		Activity:  *cmd.Activity,
 	}
 
 	if *cmd.ById {
-		exitWithError(drive.New(context, &opts).StatById())
+		exitWithError(newCommands(context, &opts).StatById())
 	} else {
-		exitWithError(drive.New(context, &opts).Stat())
+		exitWithError(newCommands(context, &opts).Stat())
 	}
 }
 
@@ -649,7 +1284,7 @@ func (icmd *indexCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Match:             *cmd.Matches,
 	}
 
-	dr := drive.New(context, options)
+	dr := newCommands(context, options)
 
 	fetchFn := dr.Fetch
 	if byId {
@@ -708,8 +1343,64 @@ type pullCmd struct {
 
 	ExponentialBackoffRetryCount *int  `json:"retry-count"`
 	ExportsDumpToSameDirectory   *bool `json:"same-exports-dir"`
+	ExportsStripExtension        *bool `json:"strip-extension"`
+	ExportsKeepOriginalName      *bool `json:"keep-original-name"`
 
 	AllowURLLinkedFiles *bool `json:"desktop-links"`
+
+	CheckpointInterval *int    `json:"checkpoint"`
+	Resume             *bool   `json:"resume"`
+	ResumableStateTTL  *string `json:"resumable-state-ttl"`
+	IgnoreCase         *bool   `json:"ignore-case"`
+	PruneDepth         *int    `json:"prune-depth"`
+	FollowShortcuts    *bool   `json:"follow-shortcuts"`
+	RenameOnCollision  *bool   `json:"rename-on-collision"`
+
+	TempDir *string `json:"temp-dir"`
+	Atomic  *bool   `json:"atomic"`
+
+	RetryOnChecksumMismatch *int `json:"retry-on-checksum-mismatch"`
+
+	MinFileSize *string `json:"min-size"`
+	MaxFileSize *string `json:"max-size"`
+
+	ApplyRemoteDeletes *bool `json:"apply-remote-deletes"`
+	PromptAll          *bool `json:"prompt-all"`
+	Mirror             *bool `json:"mirror"`
+	Decompress         *bool `json:"decompress"`
+	PreserveMode       *bool `json:"preserve-mode"`
+	MetadataOnly       *bool `json:"metadata-only"`
+	ExcludeGoogleDocs  *bool `json:"exclude-google-docs"`
+	FlattenSingleChild *bool `json:"flatten-single-child"`
+	PullQueue          *bool `json:"queue"`
+	ContinueOnError    *bool `json:"continue-on-error"`
+	SummaryOnly        *bool `json:"summary-only"`
+
+	RenameMap         *string `json:"rename-map"`
+	SlashReplacement  *string `json:"slash-replacement"`
+	BandwidthSchedule *string `json:"bwlimit-schedule"`
+	LocalChecksumAlgo *string `json:"local-checksum-algo"`
+	Plan              *string `json:"plan"`
+	ApplyPlan         *string `json:"apply-plan"`
+	ProgressJSON      *string `json:"progress-json"`
+
+	UnicodeNormalization *string `json:"unicode-normalization"`
+	WaitForLock          *bool   `json:"wait"`
+	RemoteHashOnly       *bool   `json:"remote-hash-only"`
+	ExportAll            *bool   `json:"export-all"`
+	ExportAllFormats     *bool   `json:"export-all-formats"`
+	PullAs               *string `json:"as"`
+	MapRootTo            *string `json:"map-root-to"`
+	NoRecursive          *bool   `json:"no-recursive"`
+	ConcurrentList       *int    `json:"concurrent-list"`
+	LinkShortcuts        *bool   `json:"link-shortcuts"`
+	OwnedOnly            *bool   `json:"owned-only"`
+	ExcludeShared        *bool   `json:"exclude-shared"`
+	PreserveEmptyDirs    *bool   `json:"preserve-empty-on-pull"`
+	NoEmptyDirs          *bool   `json:"no-empty-dirs"`
+	RetriesPerFile       *int    `json:"retries-per-file"`
+	Archive              *string `json:"archive"`
+	ToArchive            *string `json:"to-archive"`
 }
 
 func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -718,6 +1409,8 @@ func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Export = fs.String(
 		drive.ExportsKey, "", "comma separated list of formats to export your docs + sheets files")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, true, "performs the pull action recursively")
+	cmd.NoRecursive = fs.Bool(drive.CLIOptionNoRecursive, false, drive.DescNoRecursive)
+	cmd.ConcurrentList = fs.Int(drive.CLIOptionConcurrentList, 0, drive.DescConcurrentList)
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before applying the pull action")
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows pulling of hidden paths")
 	cmd.Force = fs.Bool(drive.ForceKey, false, "forces a pull even if no changes present")
@@ -728,6 +1421,12 @@ func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 
 	cmd.ExportsDir = fs.String(drive.ExportsDirKey, "", "directory to place exports")
 	cmd.ExportsDumpToSameDirectory = fs.Bool(drive.CLIOptionExportsDumpToSameDirectory, false, "exports are put in the same directory")
+	cmd.ExportsStripExtension = fs.Bool(drive.CLIOptionExportsStripExtension, false, drive.DescExportsStripExtension)
+	cmd.ExportsKeepOriginalName = fs.Bool(drive.CLIOptionExportsKeepOriginalName, false, drive.DescExportsStripExtension)
+	cmd.ExportAll = fs.Bool(drive.CLIOptionExportAll, false, drive.DescExportAll)
+	cmd.ExportAllFormats = fs.Bool(drive.CLIOptionExportAllFormats, false, drive.DescExportAll)
+	cmd.PullAs = fs.String(drive.CLIOptionPullAs, "", drive.DescPullAs)
+	cmd.MapRootTo = fs.String(drive.CLIOptionMapRootTo, "", drive.DescPullAs)
 
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix")
 	cmd.Piped = fs.Bool(drive.CLIOptionPiped, false, drive.DescPiped)
@@ -746,7 +1445,50 @@ func (cmd *pullCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "pull only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "pull only directories")
+	fs.BoolVar(cmd.Files, drive.CLIOptionOnlyFiles, false, "alias for -"+drive.CLIOptionFiles)
+	fs.BoolVar(cmd.Directories, drive.CLIOptionOnlyFolders, false, "alias for -"+drive.CLIOptionDirectories+", recreates the remote directory skeleton without downloading any file content")
 	cmd.AllowURLLinkedFiles = fs.Bool(drive.CLIOptionDesktopLinks, true, drive.DescAllowDesktopLinks)
+	cmd.LinkShortcuts = fs.Bool(drive.CLIOptionLinkShortcuts, false, drive.DescLinkShortcuts)
+	cmd.CheckpointInterval = fs.Int(drive.CLIOptionCheckpointInterval, 0, drive.DescCheckpointInterval)
+	cmd.Resume = fs.Bool(drive.CLIOptionResume, false, drive.DescResume)
+	cmd.ResumableStateTTL = fs.String(drive.CLIOptionResumableStateTTL, "7d", drive.DescResumableStateTTL)
+	cmd.IgnoreCase = fs.Bool(drive.CLIOptionIgnoreCase, false, drive.DescIgnoreCase)
+	cmd.PruneDepth = fs.Int(drive.CLIOptionPruneDepth, 0, drive.DescPruneDepth)
+	cmd.FollowShortcuts = fs.Bool(drive.CLIOptionFollowShortcuts, false, drive.DescFollowShortcuts)
+	cmd.RenameOnCollision = fs.Bool(drive.CLIOptionRenameOnCollision, false, drive.DescRenameOnCollision)
+	cmd.TempDir = fs.String(drive.CLIOptionTempDir, "", drive.DescTempDir)
+	cmd.Atomic = fs.Bool(drive.CLIOptionAtomic, true, drive.DescAtomic)
+	cmd.RetryOnChecksumMismatch = fs.Int(drive.CLIOptionRetryOnChecksumMismatch, 0, drive.DescRetryOnChecksumMismatch)
+	cmd.MinFileSize = fs.String(drive.CLIOptionMinFileSize, "", drive.DescMinFileSize)
+	cmd.MaxFileSize = fs.String(drive.CLIOptionMaxFileSize, "", drive.DescMaxFileSize)
+	cmd.ApplyRemoteDeletes = fs.Bool(drive.CLIOptionApplyRemoteDeletes, false, drive.DescApplyRemoteDeletes)
+	cmd.PromptAll = fs.Bool(drive.CLIOptionPromptAll, false, drive.DescPromptAll)
+	cmd.Mirror = fs.Bool(drive.CLIOptionMirror, false, drive.DescMirror)
+	cmd.Decompress = fs.Bool(drive.CLIOptionDecompress, false, drive.DescDecompress)
+	cmd.PreserveMode = fs.Bool(drive.CLIOptionPreserveMode, false, drive.DescPreserveMode)
+	cmd.MetadataOnly = fs.Bool(drive.CLIOptionMetadataOnly, false, drive.DescMetadataOnly)
+	cmd.Archive = fs.String(drive.CLIOptionArchive, "", drive.DescArchive)
+	cmd.ToArchive = fs.String(drive.CLIOptionToArchive, "", "alias for -"+drive.CLIOptionArchive)
+	cmd.ExcludeGoogleDocs = fs.Bool(drive.CLIOptionExcludeGoogleDocs, false, drive.DescExcludeGoogleDocs)
+	cmd.OwnedOnly = fs.Bool(drive.CLIOptionOwnedOnly, false, drive.DescOwnedOnly)
+	cmd.ExcludeShared = fs.Bool(drive.CLIOptionExcludeShared, false, "alias for -"+drive.CLIOptionOwnedOnly)
+	cmd.PreserveEmptyDirs = fs.Bool(drive.CLIOptionPreserveEmptyDirs, true, drive.DescPreserveEmptyDirs)
+	cmd.NoEmptyDirs = fs.Bool(drive.CLIOptionNoEmptyDirs, false, drive.DescNoEmptyDirs)
+	cmd.RetriesPerFile = fs.Int(drive.CLIOptionRetriesPerFile, 0, drive.DescRetriesPerFile)
+	cmd.FlattenSingleChild = fs.Bool(drive.CLIOptionFlattenSingleChild, false, drive.DescFlattenSingleChild)
+	cmd.LocalChecksumAlgo = fs.String(drive.CLIOptionLocalChecksumAlgo, "", drive.DescLocalChecksumAlgo)
+	cmd.Plan = fs.String(drive.CLIOptionPlan, "", drive.DescPlan)
+	cmd.ApplyPlan = fs.String(drive.CLIOptionApplyPlan, "", drive.DescApplyPlan)
+	cmd.ProgressJSON = fs.String(drive.CLIOptionProgressJSON, "", drive.DescProgressJSON)
+	cmd.UnicodeNormalization = fs.String(drive.CLIOptionUnicodeNormalization, drive.UnicodeNFC, drive.DescUnicodeNormalization)
+	cmd.WaitForLock = fs.Bool(drive.CLIOptionWaitForLock, false, drive.DescWaitForLock)
+	cmd.RemoteHashOnly = fs.Bool(drive.CLIOptionRemoteHashOnly, false, drive.DescRemoteHashOnly)
+	cmd.PullQueue = fs.Bool(drive.CLIOptionPullQueue, false, drive.DescPullQueue)
+	cmd.ContinueOnError = fs.Bool(drive.CLIOptionContinueOnError, false, drive.DescContinueOnError)
+	cmd.SummaryOnly = fs.Bool(drive.CLIOptionSummaryOnly, false, drive.DescSummaryOnly)
+	cmd.RenameMap = fs.String(drive.CLIOptionRenameMap, "", drive.DescRenameMap)
+	cmd.SlashReplacement = fs.String(drive.CLIOptionSlashReplacement, drive.DefaultSlashReplacement, drive.DescSlashReplacement)
+	cmd.BandwidthSchedule = fs.String(drive.CLIOptionBandwidthSchedule, "", drive.DescBandwidthSchedule)
 
 	return fs
 }
@@ -809,6 +1551,44 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		exitWithError(fmt.Errorf("Unknown fix mode: %s", *cmd.FixMode))
 	}
 
+	minFileSize, maxFileSize, err := parseFileSizeRange(*cmd.MinFileSize, *cmd.MaxFileSize)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	var renameRules []drive.RenameRule
+	if renameMapPath := strings.TrimSpace(*cmd.RenameMap); renameMapPath != "" {
+		renameRules, err = readRenameMap(renameMapPath)
+		if err != nil {
+			exitWithError(err)
+		}
+	}
+
+	bandwidthSchedule, err := drive.ParseBandwidthSchedule(*cmd.BandwidthSchedule)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	resumableStateTTL, err := drive.ParseAge(*cmd.ResumableStateTTL)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	unicodeNormalization, err := drive.ParseUnicodeNormalization(*cmd.UnicodeNormalization)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	pullAs := *cmd.PullAs
+	if pullAs == "" {
+		pullAs = *cmd.MapRootTo
+	}
+
+	archive := *cmd.Archive
+	if archive == "" {
+		archive = *cmd.ToArchive
+	}
+
 	options := &drive.Options{
 		Path:       path,
 		Sources:    sources,
@@ -841,21 +1621,66 @@ func (pCmd *pullCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 		AllowURLLinkedFiles:          *cmd.AllowURLLinkedFiles,
 		ExportsDumpToSameDirectory:   *cmd.ExportsDumpToSameDirectory,
+		ExportsStripExtension:        *cmd.ExportsStripExtension || *cmd.ExportsKeepOriginalName,
 		ExponentialBackoffRetryCount: retryCount,
+
+		CheckpointInterval: *cmd.CheckpointInterval,
+		Resume:             *cmd.Resume,
+		ResumableStateTTL:  resumableStateTTL,
+		IgnoreCase:         *cmd.IgnoreCase,
+		PruneDepth:         *cmd.PruneDepth,
+		FollowShortcuts:    *cmd.FollowShortcuts,
+		RenameOnCollision:  *cmd.RenameOnCollision,
+		TempDir:            strings.TrimSpace(*cmd.TempDir),
+		Atomic:             *cmd.Atomic,
+		MinFileSize:        minFileSize,
+		MaxFileSize:        maxFileSize,
+		ApplyRemoteDeletes: *cmd.ApplyRemoteDeletes,
+		PromptAll:          *cmd.PromptAll,
+		Mirror:             *cmd.Mirror,
+		Decompress:         *cmd.Decompress,
+		PreserveMode:       *cmd.PreserveMode,
+		MetadataOnly:       *cmd.MetadataOnly,
+		ExcludeGoogleDocs:  *cmd.ExcludeGoogleDocs,
+		FlattenSingleChild: *cmd.FlattenSingleChild,
+		LocalChecksumAlgo:  *cmd.LocalChecksumAlgo,
+		PlanPath:           *cmd.Plan,
+		ApplyPlanPath:      *cmd.ApplyPlan,
+		ProgressJSON:       *cmd.ProgressJSON,
+		PullQueue:          *cmd.PullQueue,
+		ContinueOnError:    *cmd.ContinueOnError,
+		SummaryOnly:        *cmd.SummaryOnly,
+		RenameRules:        renameRules,
+		SlashReplacement:   *cmd.SlashReplacement,
+		BandwidthSchedule:  bandwidthSchedule,
+
+		ChecksumMismatchRetries: *cmd.RetryOnChecksumMismatch,
+		UnicodeNormalization:    unicodeNormalization,
+		WaitForLock:             *cmd.WaitForLock,
+		RemoteHashOnly:          *cmd.RemoteHashOnly,
+		ExportAll:               *cmd.ExportAll || *cmd.ExportAllFormats,
+		PullAs:                  pullAs,
+		NoRecursive:             *cmd.NoRecursive,
+		ConcurrentList:          *cmd.ConcurrentList,
+		LinkShortcuts:           *cmd.LinkShortcuts,
+		OwnedOnly:               *cmd.OwnedOnly || *cmd.ExcludeShared,
+		NoEmptyDirs:             *cmd.NoEmptyDirs || !*cmd.PreserveEmptyDirs,
+		RetriesPerFile:          *cmd.RetriesPerFile,
+		Archive:                 archive,
 	}
 
 	if *cmd.Matches || *cmd.Starred {
 		if *cmd.AllStarred {
-			exitWithError(drive.New(context, options).PullAllStarred())
+			exitWithError(newCommands(context, options).PullAllStarred())
 		} else {
-			exitWithError(drive.New(context, options).PullMatchLike())
+			exitWithError(newCommands(context, options).PullMatchLike())
 		}
 	} else if *cmd.Piped {
-		exitWithError(drive.New(context, options).PullPiped(*cmd.ById))
+		exitWithError(newCommands(context, options).PullPiped(*cmd.ById))
 	} else if *cmd.ById {
-		exitWithError(drive.New(context, options).PullById())
+		exitWithError(newCommands(context, options).PullById())
 	} else {
-		exitWithError(drive.New(context, options).Pull())
+		exitWithError(newCommands(context, options).Pull())
 	}
 }
 
@@ -891,12 +1716,58 @@ type pushCmd struct {
 	Files           *bool `json:"files"`
 	Directories     *bool `json:"directories"`
 	UploadChunkSize *int  `json:"upload-chunk-size"`
+
+	CheckpointInterval *int    `json:"checkpoint"`
+	Resume             *bool   `json:"resume"`
+	ResumableStateTTL  *string `json:"resumable-state-ttl"`
+	MaxInflightBytes   *int64  `json:"max-inflight-bytes"`
+	PruneDepth         *int    `json:"prune-depth"`
+
+	Properties *repeatedStringsFlag `json:"-"`
+
+	MinFileSize *string `json:"min-size"`
+	MaxFileSize *string `json:"max-size"`
+	ParentId    *string `json:"parent-id"`
+	PromptAll   *bool   `json:"prompt-all"`
+	Mirror      *bool   `json:"mirror"`
+	Compress    *bool   `json:"compress"`
+	CreatedTime *string `json:"created-time"`
+
+	ContinueOnError   *bool   `json:"continue-on-error"`
+	BandwidthSchedule *string `json:"bwlimit-schedule"`
+	UploadAsCopy      *bool   `json:"upload-as-copy"`
+	SummaryOnly       *bool   `json:"summary-only"`
+	SkipExisting      *bool   `json:"skip-existing"`
+	PreserveMode      *bool   `json:"preserve-mode"`
+	StripPrefix       *string `json:"strip-prefix"`
+	Plan              *string `json:"plan"`
+	ApplyPlan         *string `json:"apply-plan"`
+	IndexableText     *string `json:"indexable-text"`
+	ProgressJSON      *string `json:"progress-json"`
+
+	UnicodeNormalization *string `json:"unicode-normalization"`
+	WaitForLock          *bool   `json:"wait"`
+	RemoteHashOnly       *bool   `json:"remote-hash-only"`
+	NoRecursive          *bool   `json:"no-recursive"`
+	ConcurrentList       *int    `json:"concurrent-list"`
+	PinRevision          *bool   `json:"pin-revision"`
+	KeepRevisions        *bool   `json:"keep-revisions"`
+	OwnedOnly            *bool   `json:"owned-only"`
+	ExcludeShared        *bool   `json:"exclude-shared"`
+	RetriesPerFile       *int    `json:"retries-per-file"`
 }
 
 func (cmd *pushCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.NoClobber = fs.Bool(drive.CLIOptionNoClobber, false, "prevents overwriting of old content")
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows pushing of hidden paths")
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, true, "performs the push action recursively")
+	cmd.NoRecursive = fs.Bool(drive.CLIOptionNoRecursive, false, drive.DescNoRecursive)
+	cmd.ConcurrentList = fs.Int(drive.CLIOptionConcurrentList, 0, drive.DescConcurrentList)
+	cmd.PinRevision = fs.Bool(drive.CLIOptionPinRevision, false, drive.DescPinRevision)
+	cmd.KeepRevisions = fs.Bool(drive.CLIOptionKeepRevisions, false, "alias for -"+drive.CLIOptionPinRevision)
+	cmd.OwnedOnly = fs.Bool(drive.CLIOptionOwnedOnly, false, drive.DescOwnedOnly)
+	cmd.ExcludeShared = fs.Bool(drive.CLIOptionExcludeShared, false, "alias for -"+drive.CLIOptionOwnedOnly)
+	cmd.RetriesPerFile = fs.Int(drive.CLIOptionRetriesPerFile, 0, drive.DescRetriesPerFile)
 	cmd.FixMode = fs.String(drive.CLIOptionFixClashesMode, "rename", drive.DescFixClashesMode)
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before applying the push action")
 	cmd.Force = fs.Bool(drive.ForceKey, false, "forces a push even if no changes present")
@@ -920,6 +1791,36 @@ func (cmd *pushCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Files = fs.Bool(drive.CLIOptionFiles, false, "push only files")
 	cmd.Directories = fs.Bool(drive.CLIOptionDirectories, false, "push only directories")
 	cmd.UploadChunkSize = fs.Int(drive.CLIOptionUploadChunkSize, 0, "specifies the size of each data chunk to be uploaded. Only set it if you want a custom chunk size. Otherwise the default value of googleapi.DefaultUploadChunkSize ie 8MiB will be used. However it must be at least googleapi.MinUploadChunkSize ie 256KiB. See https://godoc.org/google.golang.org/api/googleapi#pkg-constants")
+	cmd.CheckpointInterval = fs.Int(drive.CLIOptionCheckpointInterval, 0, drive.DescCheckpointInterval)
+	cmd.Resume = fs.Bool(drive.CLIOptionResume, false, drive.DescResume)
+	cmd.ResumableStateTTL = fs.String(drive.CLIOptionResumableStateTTL, "7d", drive.DescResumableStateTTL)
+	cmd.MaxInflightBytes = fs.Int64(drive.CLIOptionMaxInflightBytes, 0, drive.DescMaxInflightBytes)
+	cmd.PruneDepth = fs.Int(drive.CLIOptionPruneDepth, 0, drive.DescPruneDepth)
+
+	cmd.Properties = &repeatedStringsFlag{}
+	fs.Var(cmd.Properties, drive.CLIOptionProperty, drive.DescProperty)
+
+	cmd.MinFileSize = fs.String(drive.CLIOptionMinFileSize, "", drive.DescMinFileSize)
+	cmd.MaxFileSize = fs.String(drive.CLIOptionMaxFileSize, "", drive.DescMaxFileSize)
+	cmd.ParentId = fs.String(drive.CLIOptionParentId, "", drive.DescParentId)
+	cmd.PromptAll = fs.Bool(drive.CLIOptionPromptAll, false, drive.DescPromptAll)
+	cmd.Mirror = fs.Bool(drive.CLIOptionMirror, false, drive.DescMirror)
+	cmd.Compress = fs.Bool(drive.CLIOptionCompress, false, drive.DescCompress)
+	cmd.CreatedTime = fs.String(drive.CLIOptionCreatedTime, "", drive.DescCreatedTime)
+	cmd.ContinueOnError = fs.Bool(drive.CLIOptionContinueOnError, false, drive.DescContinueOnError)
+	cmd.BandwidthSchedule = fs.String(drive.CLIOptionBandwidthSchedule, "", drive.DescBandwidthSchedule)
+	cmd.UploadAsCopy = fs.Bool(drive.CLIOptionUploadAsCopy, false, drive.DescUploadAsCopy)
+	cmd.SummaryOnly = fs.Bool(drive.CLIOptionSummaryOnly, false, drive.DescSummaryOnly)
+	cmd.SkipExisting = fs.Bool(drive.CLIOptionSkipExisting, false, drive.DescSkipExisting)
+	cmd.PreserveMode = fs.Bool(drive.CLIOptionPreserveMode, false, drive.DescPreserveMode)
+	cmd.StripPrefix = fs.String(drive.CLIOptionStripPrefix, "", drive.DescStripPrefix)
+	cmd.Plan = fs.String(drive.CLIOptionPlan, "", drive.DescPlan)
+	cmd.ApplyPlan = fs.String(drive.CLIOptionApplyPlan, "", drive.DescApplyPlan)
+	cmd.ProgressJSON = fs.String(drive.CLIOptionProgressJSON, "", drive.DescProgressJSON)
+	cmd.UnicodeNormalization = fs.String(drive.CLIOptionUnicodeNormalization, drive.UnicodeNFC, drive.DescUnicodeNormalization)
+	cmd.WaitForLock = fs.Bool(drive.CLIOptionWaitForLock, false, drive.DescWaitForLock)
+	cmd.RemoteHashOnly = fs.Bool(drive.CLIOptionRemoteHashOnly, false, drive.DescRemoteHashOnly)
+	cmd.IndexableText = fs.String(drive.CLIOptionIndexableText, "", drive.DescIndexableText)
 
 	return fs
 }
@@ -940,10 +1841,23 @@ func (cmd *pushCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	options.Path = path
 	options.Sources = sources
 
+	if options.StripPrefix != "" {
+		// The prefix is a local path like the sources, so it
+		// is made relative to the root of the context too.
+		prefixes, err := relativePaths(context.AbsPathOf(""), options.StripPrefix)
+		if err != nil {
+			exitWithError(err)
+		}
+		if len(prefixes) < 1 || strings.HasPrefix(prefixes[0], "/..") {
+			exitWithError(fmt.Errorf("-%s: %q is not within the drive context %s", drive.CLIOptionStripPrefix, options.StripPrefix, context.AbsPathOf("")))
+		}
+		options.StripPrefix = prefixes[0]
+	}
+
 	if *cmd.Piped {
-		exitWithError(drive.New(context, options).PushPiped())
+		exitWithError(newCommands(context, options).PushPiped())
 	} else {
-		exitWithError(drive.New(context, options).Push())
+		exitWithError(newCommands(context, options).Push())
 	}
 }
 
@@ -986,7 +1900,7 @@ func (qCmd *qrLinkCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Verbose: *cmd.Verbose,
 	}
 
-	exitWithError(drive.New(context, &opts).QR(*cmd.ById))
+	exitWithError(newCommands(context, &opts).QR(*cmd.ById))
 }
 
 type touchCmd struct {
@@ -1060,10 +1974,20 @@ func (tcmd *touchCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	opts.Meta = &meta
 
 	if *cmd.Matches {
-		exitWithError(drive.New(context, &opts).TouchByMatch())
+		exitWithError(newCommands(context, &opts).TouchByMatch())
 	} else {
-		exitWithError(drive.New(context, &opts).Touch(*cmd.ById))
+		exitWithError(newCommands(context, &opts).Touch(*cmd.ById))
+	}
+}
+
+func readRenameMap(p string) ([]drive.RenameRule, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
 	}
+	defer f.Close()
+
+	return drive.ParseRenameMap(f)
 }
 
 func exitIfIllogicalFileAndFolder(mask int) {
@@ -1135,6 +2059,43 @@ func (pCmd *pushCmd) createPushOptions(absEntryPath string, definedFlags map[str
 		exitWithError(fmt.Errorf("Unknown fix mode: %s", *cmd.FixMode))
 	}
 
+	// Properties aren't read from .driverc so they are
+	// retrieved from the flags as they were parsed.
+	var properties map[string]string
+	if pCmd.Properties != nil && len(*pCmd.Properties) >= 1 {
+		var err error
+		if properties, err = drive.ParseProperties(*pCmd.Properties...); err != nil {
+			return nil, err
+		}
+	}
+
+	minFileSize, maxFileSize, err := parseFileSizeRange(*cmd.MinFileSize, *cmd.MaxFileSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var createdTime time.Time
+	if createdTimeStr := strings.TrimSpace(*cmd.CreatedTime); createdTimeStr != "" {
+		if createdTime, err = time.Parse(time.RFC3339, createdTimeStr); err != nil {
+			return nil, fmt.Errorf("-%s: %q must be an RFC3339 time e.g 2009-11-10T23:00:00Z", drive.CLIOptionCreatedTime, createdTimeStr)
+		}
+	}
+
+	bandwidthSchedule, err := drive.ParseBandwidthSchedule(*cmd.BandwidthSchedule)
+	if err != nil {
+		return nil, err
+	}
+
+	resumableStateTTL, err := drive.ParseAge(*cmd.ResumableStateTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	unicodeNormalization, err := drive.ParseUnicodeNormalization(*cmd.UnicodeNormalization)
+	if err != nil {
+		return nil, err
+	}
+
 	opts := &drive.Options{
 		Force:                        *cmd.Force,
 		Hidden:                       *cmd.Hidden,
@@ -1157,6 +2118,38 @@ func (pCmd *pushCmd) createPushOptions(absEntryPath string, definedFlags map[str
 		ExponentialBackoffRetryCount: retryCount,
 		UploadChunkSize:              *cmd.UploadChunkSize,
 		FixClashesMode:               fixMode,
+		CheckpointInterval:           *cmd.CheckpointInterval,
+		Resume:                       *cmd.Resume,
+		ResumableStateTTL:            resumableStateTTL,
+		MaxInflightBytes:             *cmd.MaxInflightBytes,
+		PruneDepth:                   *cmd.PruneDepth,
+		Properties:                   properties,
+		MinFileSize:                  minFileSize,
+		MaxFileSize:                  maxFileSize,
+		ParentId:                     *cmd.ParentId,
+		PromptAll:                    *cmd.PromptAll,
+		Mirror:                       *cmd.Mirror,
+		Compress:                     *cmd.Compress,
+		CreatedTime:                  createdTime,
+		ContinueOnError:              *cmd.ContinueOnError,
+		BandwidthSchedule:            bandwidthSchedule,
+		UploadAsCopy:                 *cmd.UploadAsCopy,
+		SummaryOnly:                  *cmd.SummaryOnly,
+		SkipExisting:                 *cmd.SkipExisting,
+		PreserveMode:                 *cmd.PreserveMode,
+		StripPrefix:                  *cmd.StripPrefix,
+		PlanPath:                     *cmd.Plan,
+		ApplyPlanPath:                *cmd.ApplyPlan,
+		ProgressJSON:                 *cmd.ProgressJSON,
+		IndexableText:                *cmd.IndexableText,
+		UnicodeNormalization:         unicodeNormalization,
+		WaitForLock:                  *cmd.WaitForLock,
+		RemoteHashOnly:               *cmd.RemoteHashOnly,
+		NoRecursive:                  *cmd.NoRecursive,
+		ConcurrentList:               *cmd.ConcurrentList,
+		PinRevision:                  *cmd.PinRevision || *cmd.KeepRevisions,
+		OwnedOnly:                    *cmd.OwnedOnly || *cmd.ExcludeShared,
+		RetriesPerFile:               *cmd.RetriesPerFile,
 	}
 
 	return opts, nil
@@ -1205,7 +2198,7 @@ func (cmd *pushCmd) pushMounted(args []string, definedFlags map[string]*flag.Fla
 	options.Mount = mount
 	options.Sources = sources
 
-	return drive.New(context, options).Push()
+	return newCommands(context, options).Push()
 }
 
 type aboutCmd struct {
@@ -1254,7 +2247,7 @@ func (acmd *aboutCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		mask = drive.AboutQuota | drive.AboutFeatures | drive.AboutFileSizes
 	}
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Quiet: *cmd.Quiet,
 	}).About(mask))
 }
@@ -1270,6 +2263,17 @@ type diffCmd struct {
 	Unified           *bool `json:"unified"`
 	BaseLocal         *bool `json:"base-local"`
 	SkipContentCheck  *bool `json:"skip-content-check"`
+	IgnoreCase        *bool `json:"ignore-case"`
+
+	Since *string `json:"since"`
+
+	LocalOnly  *bool `json:"local-only"`
+	RemoteOnly *bool `json:"remote-only"`
+
+	Revision *string `json:"revision"`
+	Report   *string `json:"report"`
+
+	ConcurrentList *int `json:"concurrent-list"`
 }
 
 func (cmd *diffCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1283,6 +2287,13 @@ func (cmd *diffCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Unified = fs.Bool(drive.CLIOptionUnifiedShortKey, true, drive.DescUnifiedDiff)
 	cmd.BaseLocal = fs.Bool(drive.CLIOptionDiffBaseLocal, true, drive.DescDiffBaseLocal)
 	cmd.SkipContentCheck = fs.Bool(drive.SkipContentCheckKey, false, drive.DescSkipContentCheck)
+	cmd.IgnoreCase = fs.Bool(drive.CLIOptionIgnoreCase, false, drive.DescIgnoreCase)
+	cmd.Since = fs.String(drive.CLIOptionSince, "", drive.DescSince)
+	cmd.LocalOnly = fs.Bool(drive.CLIOptionLocalOnly, false, drive.DescLocalOnly)
+	cmd.RemoteOnly = fs.Bool(drive.CLIOptionRemoteOnly, false, drive.DescRemoteOnly)
+	cmd.Revision = fs.String(drive.CLIOptionDiffRevision, "", drive.DescDiffRevision)
+	cmd.Report = fs.String(drive.CLIOptionDiffReport, "", drive.DescDiffReport)
+	cmd.ConcurrentList = fs.Int(drive.CLIOptionConcurrentList, 0, drive.DescConcurrentList)
 
 	return fs
 }
@@ -1290,10 +2301,24 @@ func (cmd *diffCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *diffCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgs(args)
 
+	var since time.Time
+	if sinceStr := strings.TrimSpace(*cmd.Since); sinceStr != "" {
+		var err error
+		if since, err = drive.ParseSince(sinceStr, time.Now()); err != nil {
+			exitWithError(err)
+		}
+	}
+
 	mask := drive.DiffNone
 	if *cmd.Unified {
 		mask |= drive.DiffUnified
 	}
+	if *cmd.LocalOnly {
+		mask |= drive.DiffLocalOnly
+	}
+	if *cmd.RemoteOnly {
+		mask |= drive.DiffRemoteOnly
+	}
 
 	var metaPtr *map[string][]string
 	if *cmd.SkipContentCheck {
@@ -1303,7 +2328,7 @@ func (cmd *diffCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		metaPtr = &meta
 	}
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path:              path,
 		Sources:           sources,
 		Hidden:            *cmd.Hidden,
@@ -1316,6 +2341,11 @@ func (cmd *diffCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		BaseLocal:         *cmd.BaseLocal,
 		Meta:              metaPtr,
 		TypeMask:          mask,
+		IgnoreCase:        *cmd.IgnoreCase,
+		Since:             since,
+		DiffRevision:      strings.TrimSpace(*cmd.Revision),
+		DiffReport:        drive.ExpandPath(strings.TrimSpace(*cmd.Report)),
+		ConcurrentList:    *cmd.ConcurrentList,
 	}).Diff())
 }
 
@@ -1347,7 +2377,7 @@ func (uCmd *unpublishCmd) Run(args []string, definedFlags map[string]*flag.Flag)
 		exitWithError(err)
 	}
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path:    path,
 		Sources: sources,
 		Quiet:   *cmd.Quiet,
@@ -1367,18 +2397,49 @@ func (cmd *emptyTrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 
 func (cmd *emptyTrashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	_, context, _ := preprocessArgs(args)
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		NoPrompt: *cmd.NoPrompt,
 		Quiet:    *cmd.Quiet,
 	}).EmptyTrash())
 }
 
+type undoCmd struct {
+	NoPrompt *bool `json:"no-prompt"`
+	Quiet    *bool `json:"quiet"`
+	Verbose  *bool `json:"verbose"`
+
+	ExponentialBackoffRetryCount *int `json:"retry-count"`
+}
+
+func (cmd *undoCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
+	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "shows no prompt before untrashing the files")
+	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
+	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	return fs
+}
+
+func (cmd *undoCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	_, context, _ := preprocessArgs(args)
+	exitWithError(newCommands(context, &drive.Options{
+		NoPrompt: *cmd.NoPrompt,
+		Quiet:    *cmd.Quiet,
+		Verbose:  *cmd.Verbose,
+
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+	}).Undo())
+}
+
 type deleteCmd struct {
 	Hidden   *bool `json:"hidden"`
 	Matches  *bool `json:"matches"`
 	Quiet    *bool `json:"quiet"`
 	ById     *bool `json:"by-id"`
 	NoPrompt *bool `json:"no-prompt"`
+
+	ExponentialBackoffRetryCount *int  `json:"retry-count"`
+	CheckpointInterval           *int  `json:"checkpoint"`
+	Resume                       *bool `json:"resume"`
 }
 
 func (cmd *deleteCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1387,6 +2448,9 @@ func (cmd *deleteCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "delete by id instead of path")
 	cmd.NoPrompt = fs.Bool(drive.NoPromptKey, false, "disables the prompt")
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.CheckpointInterval = fs.Int(drive.CLIOptionCheckpointInterval, 0, drive.DescCheckpointInterval)
+	cmd.Resume = fs.Bool(drive.CLIOptionResume, false, drive.DescResume)
 
 	return fs
 }
@@ -1403,12 +2467,16 @@ func (cmd *deleteCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Sources: sources,
 		Quiet:   *cmd.Quiet,
 		Match:   *cmd.Matches,
+
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+		CheckpointInterval:           *cmd.CheckpointInterval,
+		Resume:                       *cmd.Resume,
 	}
 
 	if !*cmd.Matches {
-		exitWithError(drive.New(context, &opts).Delete(*cmd.ById))
+		exitWithError(newCommands(context, &opts).Delete(*cmd.ById))
 	} else {
-		exitWithError(drive.New(context, &opts).DeleteByMatch())
+		exitWithError(newCommands(context, &opts).DeleteByMatch())
 	}
 }
 
@@ -1418,6 +2486,10 @@ type trashCmd struct {
 	Quiet   *bool `json:"quiet"`
 	ById    *bool `json:"by-id"`
 	Verbose *bool `json:"verbose"`
+
+	ExponentialBackoffRetryCount *int  `json:"retry-count"`
+	CheckpointInterval           *int  `json:"checkpoint"`
+	Resume                       *bool `json:"resume"`
 }
 
 func (cmd *trashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1426,6 +2498,9 @@ func (cmd *trashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "trash by id instead of path")
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, false, drive.DescVerbose)
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.CheckpointInterval = fs.Int(drive.CLIOptionCheckpointInterval, 0, drive.DescCheckpointInterval)
+	cmd.Resume = fs.Bool(drive.CLIOptionResume, false, drive.DescResume)
 
 	return fs
 }
@@ -1439,12 +2514,16 @@ func (cmd *trashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Quiet:   *cmd.Quiet,
 		Match:   *cmd.Matches,
 		Verbose: *cmd.Verbose,
+
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+		CheckpointInterval:           *cmd.CheckpointInterval,
+		Resume:                       *cmd.Resume,
 	}
 
 	if !*cmd.Matches {
-		exitWithError(drive.New(context, &opts).Trash(*cmd.ById))
+		exitWithError(newCommands(context, &opts).Trash(*cmd.ById))
 	} else {
-		exitWithError(drive.New(context, &opts).TrashByMatch())
+		exitWithError(newCommands(context, &opts).TrashByMatch())
 	}
 }
 
@@ -1474,22 +2553,24 @@ func (cmd *newCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	opts.Meta = &meta
 
 	if *cmd.Folder {
-		exitWithError(drive.New(context, &opts).NewFolder())
+		exitWithError(newCommands(context, &opts).NewFolder())
 	} else {
-		exitWithError(drive.New(context, &opts).NewFile())
+		exitWithError(newCommands(context, &opts).NewFile())
 	}
 }
 
 type copyCmd struct {
-	Quiet     *bool `json:"quiet"`
-	Recursive *bool `json:"recursive"`
-	ById      *bool `json:"by-id"`
+	Quiet        *bool `json:"quiet"`
+	Recursive    *bool `json:"recursive"`
+	ById         *bool `json:"by-id"`
+	WithComments *bool `json:"with-comments"`
 }
 
 func (cmd *copyCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "recursive copying")
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "copy by id instead of path")
+	cmd.WithComments = fs.Bool(drive.CLIOptionWithComments, false, drive.DescWithComments)
 	return fs
 }
 
@@ -1515,11 +2596,12 @@ func (cmd *copyCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	dest = destRels[0]
 	sources = append(sources, dest)
 
-	exitWithError(drive.New(context, &drive.Options{
-		Path:      path,
-		Sources:   sources,
-		Recursive: *cmd.Recursive,
-		Quiet:     *cmd.Quiet,
+	exitWithError(newCommands(context, &drive.Options{
+		Path:         path,
+		Sources:      sources,
+		Recursive:    *cmd.Recursive,
+		Quiet:        *cmd.Quiet,
+		CopyComments: *cmd.WithComments,
 	}).Copy(*cmd.ById))
 }
 
@@ -1528,6 +2610,10 @@ type untrashCmd struct {
 	Matches *bool `json:"matches"`
 	Quiet   *bool `json:"quiet"`
 	ById    *bool `json:"by-id"`
+
+	ExponentialBackoffRetryCount *int  `json:"retry-count"`
+	CheckpointInterval           *int  `json:"checkpoint"`
+	Resume                       *bool `json:"resume"`
 }
 
 func (cmd *untrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1535,6 +2621,9 @@ func (cmd *untrashCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Matches = fs.Bool(drive.MatchesKey, false, "search by prefix and untrash")
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "untrash by id instead of path")
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.CheckpointInterval = fs.Int(drive.CLIOptionCheckpointInterval, 0, drive.DescCheckpointInterval)
+	cmd.Resume = fs.Bool(drive.CLIOptionResume, false, drive.DescResume)
 
 	return fs
 }
@@ -1547,34 +2636,82 @@ func (cmd *untrashCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Sources: sources,
 		Quiet:   *cmd.Quiet,
 		Match:   *cmd.Matches,
+
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+		CheckpointInterval:           *cmd.CheckpointInterval,
+		Resume:                       *cmd.Resume,
 	}
 
 	if !*cmd.Matches {
-		exitWithError(drive.New(context, &opts).Untrash(*cmd.ById))
+		exitWithError(newCommands(context, &opts).Untrash(*cmd.ById))
 	} else {
-		exitWithError(drive.New(context, &opts).UntrashByMatch())
+		exitWithError(newCommands(context, &opts).UntrashByMatch())
 	}
 }
 
 type publishCmd struct {
-	Hidden *bool `json:"hidden"`
-	Quiet  *bool `json:"quiet"`
-	ById   *bool `json:"by-id"`
+	Hidden    *bool   `json:"hidden"`
+	Quiet     *bool   `json:"quiet"`
+	ById      *bool   `json:"by-id"`
+	Role      *string `json:"role"`
+	WithLink  *bool   `json:"with-link"`
+	Public    *bool   `json:"public"`
+	Recursive *bool   `json:"recursive"`
+	Manifest  *string `json:"manifest"`
+	Stream    *bool   `json:"stream"`
+
+	ViewersCanCopy  *string `json:"viewers-can-copy"`
+	WritersCanShare *string `json:"writers-can-share"`
 }
 
 func (cmd *publishCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Hidden = fs.Bool(drive.HiddenKey, false, "allows publishing of hidden paths")
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "publish by id instead of path")
+	cmd.Role = fs.String(drive.RoleKey, "", drive.DescPublishRole)
+	cmd.WithLink = fs.Bool(drive.CLIOptionWithLink, false, drive.DescPublishWithLink)
+	cmd.Public = fs.Bool(drive.CLIOptionPublic, false, drive.DescPublic)
+	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "publish folders and all their descendants")
+	cmd.Manifest = fs.String(drive.CLIOptionPublishManifest, "", drive.DescPublishManifest)
+	cmd.Stream = fs.Bool(drive.CLIOptionStream, false, drive.DescStream)
+	cmd.ViewersCanCopy = fs.String(drive.CLIOptionViewersCanCopy, "", drive.DescViewersCanCopy)
+	cmd.WritersCanShare = fs.String(drive.CLIOptionWritersCanShare, "", drive.DescWritersCanShare)
 	return fs
 }
 
 func (cmd *publishCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
+	if *cmd.WithLink && *cmd.Public {
+		exitWithError(fmt.Errorf("cannot use both `%s` and `%s`", drive.CLIOptionWithLink, drive.CLIOptionPublic))
+	}
+
 	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
-	exitWithError(drive.New(context, &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+
+	restrictions, err := drive.ParseRestrictions(*cmd.ViewersCanCopy, *cmd.WritersCanShare)
+	if err != nil {
+		exitWithError(err)
+	}
+
+	meta := map[string][]string{
+		drive.RoleKey: uniqOrderedStr(drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Role, ",")...)),
+	}
+
+	mask := drive.NoopOnShare
+	if *cmd.WithLink {
+		mask |= drive.WithLink
+	}
+
+	exitWithError(newCommands(context, &drive.Options{
+		Path:     path,
+		Sources:  sources,
+		Meta:     &meta,
+		TypeMask: mask,
+		Quiet:    *cmd.Quiet,
+		Hidden:   *cmd.Hidden,
+
+		Recursive:       *cmd.Recursive,
+		PublishManifest: *cmd.Manifest,
+		Stream:          *cmd.Stream,
+		Restrictions:    restrictions,
 	}).Publish(*cmd.ById))
 }
 
@@ -1589,6 +2726,12 @@ type shareCmd struct {
 	Quiet       *bool   `json:"quiet"`
 	Verbose     *bool   `json:"verbose"`
 	WithLink    *bool   `json:"with-link"`
+	Recursive   *bool   `json:"recursive"`
+
+	ExponentialBackoffRetryCount *int `json:"retry-count"`
+
+	ViewersCanCopy  *string `json:"viewers-can-copy"`
+	WritersCanShare *string `json:"writers-can-share"`
 }
 
 func (cmd *shareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
@@ -1602,6 +2745,10 @@ func (cmd *shareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "share by id instead of path")
 	cmd.Verbose = fs.Bool(drive.CLIOptionVerboseKey, true, drive.DescVerbose)
+	cmd.Recursive = fs.Bool(drive.RecursiveKey, false, "share folders and all their descendants")
+	cmd.ExponentialBackoffRetryCount = fs.Int(drive.CLIOptionRetryCount, drive.MaxFailedRetryCount, drive.DescExponentialBackoffRetryCount)
+	cmd.ViewersCanCopy = fs.String(drive.CLIOptionViewersCanCopy, "", drive.DescViewersCanCopy)
+	cmd.WritersCanShare = fs.String(drive.CLIOptionWritersCanShare, "", drive.DescWritersCanShare)
 
 	return fs
 }
@@ -1609,6 +2756,11 @@ func (cmd *shareCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 func (cmd *shareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	sources, context, path := preprocessArgsByToggle(args, *cmd.ById)
 
+	restrictions, err := drive.ParseRestrictions(*cmd.ViewersCanCopy, *cmd.WritersCanShare)
+	if err != nil {
+		exitWithError(err)
+	}
+
 	meta := map[string][]string{
 		drive.EmailMessageKey: []string{*cmd.Message},
 		drive.EmailsKey:       uniqOrderedStr(drive.NonEmptyTrimmedStrings(strings.Split(*cmd.Emails, ",")...)),
@@ -1624,7 +2776,7 @@ func (cmd *shareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		mask |= drive.WithLink
 	}
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path:     path,
 		Sources:  sources,
 		Meta:     &meta,
@@ -1632,6 +2784,10 @@ func (cmd *shareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		NoPrompt: *cmd.NoPrompt,
 		Quiet:    *cmd.Quiet,
 		Verbose:  *cmd.Verbose,
+
+		Recursive:                    *cmd.Recursive,
+		ExponentialBackoffRetryCount: *cmd.ExponentialBackoffRetryCount,
+		Restrictions:                 restrictions,
 	}).Share(*cmd.ById))
 }
 
@@ -1666,7 +2822,7 @@ func (cmd *unshareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		drive.AccountTypeKey: uniqOrderedStr(drive.NonEmptyTrimmedStrings(strings.Split(*cmd.AccountType, ",")...)),
 	}
 
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Meta:     &meta,
 		Path:     path,
 		Sources:  sources,
@@ -1677,15 +2833,17 @@ func (cmd *unshareCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 }
 
 type moveCmd struct {
-	Quiet      *bool `json:"quiet"`
-	ById       *bool `json:"by-id"`
-	KeepParent *bool `json:"keep-parent"`
+	Quiet        *bool   `json:"quiet"`
+	ById         *bool   `json:"by-id"`
+	KeepParent   *bool   `json:"keep-parent"`
+	RenameFolder *string `json:"rename-folder"`
 }
 
 func (cmd *moveCmd) Flags(fs *flag.FlagSet) *flag.FlagSet {
 	cmd.Quiet = fs.Bool(drive.QuietKey, false, "if set, do not log anything but errors")
 	cmd.ById = fs.Bool(drive.CLIOptionId, false, "move by id instead of path")
 	cmd.KeepParent = fs.Bool(drive.CLIOptionKeepParent, false, drive.DescKeepParent)
+	cmd.RenameFolder = fs.String(drive.CLIOptionRenameFolder, "", drive.DescRenameFolder)
 	return fs
 }
 
@@ -1705,10 +2863,11 @@ func (cmd *moveCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 
 	sources = append(sources, destRels[0])
 
-	exitWithError(drive.New(context, &drive.Options{
-		Path:    path,
-		Sources: sources,
-		Quiet:   *cmd.Quiet,
+	exitWithError(newCommands(context, &drive.Options{
+		Path:         path,
+		Sources:      sources,
+		Quiet:        *cmd.Quiet,
+		RenameFolder: *cmd.RenameFolder,
 	}).Move(*cmd.ById, *cmd.KeepParent))
 }
 
@@ -1751,7 +2910,7 @@ func (cmd *renameCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 	}
 
 	sources = append(sources, last)
-	exitWithError(drive.New(context, &drive.Options{
+	exitWithError(newCommands(context, &drive.Options{
 		Path:       path,
 		Sources:    sources,
 		Force:      *cmd.Force,
@@ -1782,7 +2941,7 @@ func (cmd *starCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Quiet:   *cmd.Quiet,
 	}
 
-	exitWithError(drive.New(context, opts).Star(*cmd.ById))
+	exitWithError(newCommands(context, opts).Star(*cmd.ById))
 }
 
 type unstarCmd struct {
@@ -1797,7 +2956,7 @@ func (cmd *unstarCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Sources: sources,
 	}
 
-	exitWithError(drive.New(context, opts).UnStar(*cmd.ById))
+	exitWithError(newCommands(context, opts).UnStar(*cmd.ById))
 }
 
 type idCmd struct {
@@ -1832,7 +2991,7 @@ func (icmd *idCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Hidden:  *cmd.Hidden,
 	}
 
-	exitWithError(drive.New(context, opts).Id())
+	exitWithError(newCommands(context, opts).Id())
 }
 
 type clashesCmd struct {
@@ -1896,7 +3055,7 @@ func (ccmd *clashesCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		FixClashesMode: fixMode,
 	}
 
-	driveInstance := drive.New(context, opts)
+	driveInstance := newCommands(context, opts)
 	fn := driveInstance.ListClashes
 	if *cmd.Fix {
 		fn = driveInstance.FixClashes
@@ -1937,7 +3096,7 @@ func (cmd *issueCmd) Run(args []string, definedFlags map[string]*flag.Flag) {
 		Meta:    &meta,
 	}
 
-	exitWithError(drive.New(context, opts).FileIssue())
+	exitWithError(newCommands(context, opts).FileIssue())
 }
 
 func initContext(args []string) *config.Context {
@@ -1945,7 +3104,7 @@ func initContext(args []string) *config.Context {
 	var gdPath string
 	var firstInit bool
 
-	gdPath, firstInit, context, err = config.Initialize(getContextPath(args))
+	gdPath, firstInit, context, err = config.Initialize(getContextPath(args), getConfigDir())
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, os.Kill)
@@ -1963,16 +3122,54 @@ func initContext(args []string) *config.Context {
 	return context
 }
 
+// newCommands is drive.New except that with the global check flag set,
+// the configuration is validated and reported then the program exits
+// before any command gets to make API calls.
+func newCommands(context *config.Context, opts *drive.Options) *drive.Commands {
+	g := newLocalCommands(context, opts)
+
+	root := context.RemoteRoot
+	if remoteRoot != nil && *remoteRoot != "" {
+		root = *remoteRoot
+	}
+	exitWithError(g.RootAtRemoteRoot(root))
+	return g
+}
+
+// newLocalCommands is newCommands without the remote root lookup,
+// for the commands that only work with the local configuration.
+func newLocalCommands(context *config.Context, opts *drive.Options) *drive.Commands {
+	if impersonate != nil && *impersonate != "" {
+		opts.Impersonate = *impersonate
+	}
+	if maxQPS != nil && *maxQPS > 0 {
+		opts.MaxQPS = *maxQPS
+	}
+
+	g := drive.New(context, opts)
+	exitWithError(g.VerifyImpersonation())
+	exitIfCheckOnly(g)
+	return g
+}
+
+// exitIfCheckOnly reports the configuration and exits if the global check flag is set.
+func exitIfCheckOnly(g *drive.Commands) {
+	if checkOnly != nil && *checkOnly {
+		exitWithError(g.Check(flag.Arg(0)))
+		os.Exit(0)
+	}
+}
+
 func discoverContext(args []string) (*config.Context, string) {
 	var err error
 	ctxPath := getContextPath(args)
-	context, err = config.Discover(ctxPath)
+	context, err = config.Discover(ctxPath, getConfigDir())
 	drive.DebugPrintf("contextPath: %q", ctxPath)
 	exitWithError(err)
 	relPath := ""
 	if len(args) > 0 {
 		var headAbsArg string
-		headAbsArg, err = filepath.Abs(args[0])
+		headAbsArg, err = filepath.Abs(drive.ExpandPath(args[0]))
 		if err == nil {
 			relPath, err = filepath.Rel(context.AbsPath, headAbsArg)
 		}
@@ -1987,7 +3184,7 @@ func discoverContext(args []string) (*config.Context, string) {
 
 func getContextPath(args []string) (contextPath string) {
 	if len(args) > 0 {
-		contextPath, _ = filepath.Abs(args[0])
+		contextPath, _ = filepath.Abs(drive.ExpandPath(args[0]))
 	}
 	if contextPath == "" {
 		contextPath, _ = os.Getwd()
@@ -1995,6 +3192,15 @@ func getContextPath(args []string) (contextPath string) {
 	return
 }
 
+func getConfigDir() string {
+	if configDir == nil || *configDir == "" {
+		return ""
+	}
+	absConfigDir, err := filepath.Abs(*configDir)
+	exitWithError(err)
+	return absConfigDir
+}
+
 func uniqOrderedStr(sources []string) []string {
 	cache := map[string]bool{}
 	var uniqPaths []string
@@ -2009,20 +3215,69 @@ func uniqOrderedStr(sources []string) []string {
 	return uniqPaths
 }
 
+func parseFileSizeRange(minSize, maxSize string) (min, max int64, err error) {
+	if minSize = strings.TrimSpace(minSize); minSize != "" {
+		if min, err = drive.ParseByteSize(minSize); err != nil {
+			return
+		}
+	}
+	if maxSize = strings.TrimSpace(maxSize); maxSize != "" {
+		if max, err = drive.ParseByteSize(maxSize); err != nil {
+			return
+		}
+	}
+	if max > 0 && min > max {
+		err = fmt.Errorf("-%s %s cannot be greater than -%s %s", drive.CLIOptionMinFileSize, minSize, drive.CLIOptionMaxFileSize, maxSize)
+	}
+	return
+}
+
+// repeatedStringsFlag collects the values of
+// a flag that can be passed in more than once.
+type repeatedStringsFlag []string
+
+func (r *repeatedStringsFlag) String() string {
+	if r == nil {
+		return ""
+	}
+	return strings.Join(*r, ",")
+}
+
+func (r *repeatedStringsFlag) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
 func exitWithError(err error) {
 	if err == nil {
 		return
 	}
 
-	msg := err.Error()
-	code := -1
+	drive.FprintfShadow(os.Stderr, "%s\n", err.Error())
+	os.Exit(exitCode(err))
+}
 
-	if codedErr, ok := err.(*drive.Error); ok {
-		code = codedErr.Code()
-	}
+// Exit codes by the category of error, as documented in the README.
+const (
+	exitGeneric  = 1
+	exitAuth     = 2
+	exitNetwork  = 3
+	exitConflict = 4
+	exitNotFound = 5
+)
 
-	drive.FprintfShadow(os.Stderr, "%s\n", msg)
-	os.Exit(code)
+func exitCode(err error) int {
+	switch drive.Category(err) {
+	case drive.ErrAuth:
+		return exitAuth
+	case drive.ErrNetwork:
+		return exitNetwork
+	case drive.ErrConflict:
+		return exitConflict
+	case drive.ErrNotFound:
+		return exitNotFound
+	}
+	return exitGeneric
 }
 
 func relativePaths(root string, args ...string) ([]string, error) {
@@ -2035,7 +3290,7 @@ func relativePathsOpt(root string, args []string, leastNonExistant bool) ([]stri
 	var relPaths []string
 
 	for _, p := range args {
-		p, err = filepath.Abs(p)
+		p, err = filepath.Abs(drive.ExpandPath(p))
 		if err != nil {
 			drive.FprintfShadow(os.Stderr, "%s %v\n", p, err)
 			continue
@@ -15,15 +15,21 @@
 package config
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/jwt"
 
 	"github.com/boltdb/bolt"
@@ -57,6 +63,29 @@ type Context struct {
 	ClientSecret string `json:"client_secret"`
 	RefreshToken string `json:"refresh_token"`
 	AbsPath      string `json:"-"`
+
+	// ConfigDir when set is the directory in which the credentials,
+	// index database and other state are kept instead of in the .gd
+	// directory at the root of the context.
+	ConfigDir string `json:"-"`
+	// RootPath is the absolute path of the context root. It is only
+	// persisted when ConfigDir is set, since the context root can then
+	// no longer be discovered by searching for the .gd directory.
+	RootPath string `json:"root_path,omitempty"`
+	// RemoteRoot when set is the path or id of the remote folder
+	// that the context maps to instead of the root of the Drive.
+	RemoteRoot string `json:"remote_root,omitempty"`
+
+	// MimeOverrides maps lower cased file extensions e.g ".md"
+	// to the mimeType to upload files with that extension as.
+	MimeOverrides map[string]string `json:"mime_overrides,omitempty"`
+
+	// MaxIdleConns, MaxIdleConnsPerHost and IdleConnTimeoutSeconds tune
+	// the pool of connections to the API. When unset, defaults scaled to
+	// the number of concurrent transfers are used.
+	MaxIdleConns           int `json:"max_idle_conns,omitempty"`
+	MaxIdleConnsPerHost    int `json:"max_idle_conns_per_host,omitempty"`
+	IdleConnTimeoutSeconds int `json:"idle_conn_timeout_seconds,omitempty"`
 }
 
 type Index struct {
@@ -107,8 +136,53 @@ func (c *Context) Cwd() string {
 	return cwd
 }
 
+// GDPath returns the directory in which the context's state is kept.
+func (c *Context) GDPath() string {
+	if c.ConfigDir != "" {
+		return c.ConfigDir
+	}
+	return gdPath(c.AbsPath)
+}
+
+// NormalizeExt lower cases ext and ensures that it starts with a ".".
+func NormalizeExt(ext string) string {
+	ext = strings.ToLower(strings.TrimSpace(ext))
+	if ext != "" && !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+// MimeOverride returns the mimeType that files with extension ext were
+// configured to be uploaded as, if any.
+func (c *Context) MimeOverride(ext string) (mimeType string, ok bool) {
+	ext = NormalizeExt(ext)
+	if ext == "" || c.MimeOverrides == nil {
+		return "", false
+	}
+	mimeType, ok = c.MimeOverrides[ext]
+	return mimeType, ok
+}
+
+func (c *Context) SetMimeOverride(ext, mimeType string) {
+	if c.MimeOverrides == nil {
+		c.MimeOverrides = make(map[string]string)
+	}
+	c.MimeOverrides[NormalizeExt(ext)] = mimeType
+}
+
+// UnsetMimeOverride removes the override of ext, returning false if there was none.
+func (c *Context) UnsetMimeOverride(ext string) bool {
+	ext = NormalizeExt(ext)
+	if _, ok := c.MimeOverrides[ext]; !ok {
+		return false
+	}
+	delete(c.MimeOverrides, ext)
+	return true
+}
+
 func (c *Context) Read() error {
-	data, err := ioutil.ReadFile(credentialsPath(c.AbsPath))
+	data, err := ioutil.ReadFile(credentialsPath(c.GDPath()))
 	if err != nil {
 		return err
 	}
@@ -288,14 +362,15 @@ func (c *Context) Write() error {
 	if err != nil {
 		return err
 	}
-	return ioutil.WriteFile(credentialsPath(c.AbsPath), data, 0600)
+	return ioutil.WriteFile(credentialsPath(c.GDPath()), data, 0600)
 }
 
 func (c *Context) DeInitialize(prompter func(...interface{}) bool, returnOnAnyError bool) error {
-	rootDir := c.AbsPathOf("")
+	pathGD := c.GDPath()
 	pathsToRemove := []string{
-		credentialsPath(rootDir),
-		DbSuffixedPath(rootDir),
+		credentialsPath(pathGD),
+		tokenPath(pathGD),
+		path.Join(pathGD, DriveDb),
 	}
 
 	for _, p := range pathsToRemove {
@@ -316,7 +391,7 @@ func (c *Context) DeInitialize(prompter func(...interface{}) bool, returnOnAnyEr
 }
 
 func (c *Context) OpenDB() (*bolt.DB, error) {
-	dbPath := DbSuffixedPath(c.AbsPathOf(""))
+	dbPath := path.Join(c.GDPath(), DriveDb)
 	db, err := bolt.Open(dbPath, O_RWForAll, nil)
 	if err != nil {
 		return db, err
@@ -331,7 +406,12 @@ func (c *Context) OpenDB() (*bolt.DB, error) {
 
 // Discovers the gd directory, if no gd directory or credentials
 // could be found for the path, returns ErrNoContext.
-func Discover(currentAbsPath string) (*Context, error) {
+// If configDir is set, the credentials and state are read from it instead.
+func Discover(currentAbsPath, configDir string) (*Context, error) {
+	if configDir != "" {
+		return discoverFromConfigDir(currentAbsPath, configDir)
+	}
+
 	p := currentAbsPath
 	found := false
 	for {
@@ -357,8 +437,27 @@ func Discover(currentAbsPath string) (*Context, error) {
 	return context, nil
 }
 
-func Initialize(absPath string) (pathGD string, firstInit bool, c *Context, err error) {
+func discoverFromConfigDir(currentAbsPath, configDir string) (*Context, error) {
+	info, err := os.Stat(configDir)
+	if err != nil || !info.IsDir() {
+		return nil, ErrNoDriveContext
+	}
+
+	context := &Context{AbsPath: currentAbsPath, ConfigDir: configDir}
+	if err := context.Read(); err != nil {
+		return nil, err
+	}
+	if context.RootPath != "" {
+		context.AbsPath = context.RootPath
+	}
+	return context, nil
+}
+
+func Initialize(absPath, configDir string) (pathGD string, firstInit bool, c *Context, err error) {
 	pathGD = gdPath(absPath)
+	if configDir != "" {
+		pathGD = configDir
+	}
 	sInfo, sErr := os.Stat(pathGD)
 	if sErr != nil {
 		if os.IsNotExist(sErr) {
@@ -375,7 +474,10 @@ func Initialize(absPath string) (pathGD string, firstInit bool, c *Context, err
 	if err = os.MkdirAll(pathGD, 0755); err != nil {
 		return
 	}
-	c = &Context{AbsPath: absPath}
+	c = &Context{AbsPath: absPath, ConfigDir: configDir}
+	if configDir != "" {
+		c.RootPath = absPath
+	}
 	err = c.Write()
 	return
 }
@@ -384,14 +486,429 @@ func gdPath(absPath string) string {
 	return path.Join(absPath, GDDirSuffix)
 }
 
-func credentialsPath(absPath string) string {
-	return path.Join(gdPath(absPath), "credentials.json")
+func credentialsPath(pathGD string) string {
+	return path.Join(pathGD, "credentials.json")
+}
+
+func tokenPath(pathGD string) string {
+	return path.Join(pathGD, "token.json")
+}
+
+// ReadToken retrieves the access token that was last refreshed, if any,
+// for it to be reused while it is valid instead of refreshing it again.
+func (c *Context) ReadToken() (*oauth2.Token, error) {
+	data, err := ioutil.ReadFile(tokenPath(c.GDPath()))
+	if err != nil {
+		return nil, err
+	}
+
+	token := &oauth2.Token{}
+	err = json.Unmarshal(data, token)
+	return token, err
+}
+
+// WriteToken saves the access token that was just refreshed along with
+// the refresh token that it came from, to detect it getting stale on reinit.
+func (c *Context) WriteToken(token *oauth2.Token) error {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(tokenPath(c.GDPath()), data)
 }
 
 func DbSuffixedPath(dir string) string {
 	return path.Join(gdPath(dir), DriveDb)
 }
 
+// writeFileAtomic replaces the file at p with data by writing it to a
+// temporary file next to it first, that is then renamed over it, so that
+// a crash mid-write doesn't leave behind a corrupted file. Each writer
+// has its own temporary file, so concurrent writers of p don't collide.
+func writeFileAtomic(p string, data []byte) (err error) {
+	f, err := ioutil.TempFile(filepath.Dir(p), filepath.Base(p)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = f.Write(data); err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Chmod(f.Name(), 0600)
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), p)
+}
+
+// readJSONFile decodes the JSON file at p into v, leaving
+// v as is if the file doesn't exist since nothing was saved yet.
+func readJSONFile(p string, v interface{}) error {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func checkpointPath(pathGD, name string) string {
+	return path.Join(pathGD, fmt.Sprintf("%s-checkpoint.json", name))
+}
+
+// ReadCheckpoint retrieves the relative paths that were recorded
+// as completed by a previous, possibly interrupted, operation.
+func (c *Context) ReadCheckpoint(name string) ([]string, error) {
+	data, err := ioutil.ReadFile(checkpointPath(c.GDPath(), name))
+	if err != nil {
+		return nil, err
+	}
+
+	var completed []string
+	err = json.Unmarshal(data, &completed)
+	return completed, err
+}
+
+// WriteCheckpoint persists the relative paths of the items that
+// an operation has completed so far, so that it can later be resumed.
+func (c *Context) WriteCheckpoint(name string, completed []string) error {
+	data, err := json.Marshal(completed)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(checkpointPath(c.GDPath(), name), data)
+}
+
+func (c *Context) RemoveCheckpoint(name string) error {
+	err := os.Remove(checkpointPath(c.GDPath(), name))
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// CheckpointModTime returns when the named checkpoint was last saved.
+func (c *Context) CheckpointModTime(name string) (time.Time, error) {
+	fi, err := os.Stat(checkpointPath(c.GDPath(), name))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return fi.ModTime(), nil
+}
+
+// RemoveCheckpoints removes all the checkpoints, including those left
+// half written, and returns the paths of the files that were removed.
+func (c *Context) RemoveCheckpoints() (removed []string, err error) {
+	for _, pattern := range []string{checkpointPath(c.GDPath(), "*"), checkpointPath(c.GDPath(), "*") + ".*tmp"} {
+		matches, gErr := filepath.Glob(pattern)
+		if gErr != nil {
+			return removed, gErr
+		}
+
+		for _, p := range matches {
+			if rErr := os.Remove(p); rErr != nil && !os.IsNotExist(rErr) {
+				return removed, rErr
+			}
+			removed = append(removed, p)
+		}
+	}
+	return removed, nil
+}
+
+func changeCursorPath(pathGD string) string {
+	return path.Join(pathGD, "changes-cursor.json")
+}
+
+type changeCursor struct {
+	NextChangeId int64 `json:"nextChangeId"`
+}
+
+// ReadChangeCursor retrieves the id of the next change to stream, which
+// is 0 if the changes have never been streamed to completion before.
+func (c *Context) ReadChangeCursor() (int64, error) {
+	var cursor changeCursor
+	err := readJSONFile(changeCursorPath(c.GDPath()), &cursor)
+	return cursor.NextChangeId, err
+}
+
+func (c *Context) WriteChangeCursor(nextChangeId int64) error {
+	data, err := json.Marshal(&changeCursor{NextChangeId: nextChangeId})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(changeCursorPath(c.GDPath()), data)
+}
+
+func titlesPath(pathGD string) string {
+	return path.Join(pathGD, "titles.json")
+}
+
+// ReadTitles retrieves the original remote titles of the files that
+// were pulled under other local names, keyed by their local paths.
+func (c *Context) ReadTitles() (map[string]string, error) {
+	titles := make(map[string]string)
+	err := readJSONFile(titlesPath(c.GDPath()), &titles)
+	return titles, err
+}
+
+func (c *Context) WriteTitles(titles map[string]string) error {
+	data, err := json.MarshalIndent(titles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(titlesPath(c.GDPath()), data)
+}
+
+func flattenedPath(pathGD string) string {
+	return path.Join(pathGD, "flattened.json")
+}
+
+// ReadFlattened retrieves the remote paths of the chains of single child
+// folders that were pulled as one folder, keyed by their local paths.
+func (c *Context) ReadFlattened() (map[string]string, error) {
+	flattened := make(map[string]string)
+	err := readJSONFile(flattenedPath(c.GDPath()), &flattened)
+	return flattened, err
+}
+
+func (c *Context) WriteFlattened(flattened map[string]string) error {
+	data, err := json.MarshalIndent(flattened, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(flattenedPath(c.GDPath()), data)
+}
+
+func metadataIndexPath(pathGD string) string {
+	return path.Join(pathGD, "metadata-index.json")
+}
+
+// MetadataEntry describes a remote file that was indexed without pulling its content.
+type MetadataEntry struct {
+	Path        string `json:"path"`
+	Id          string `json:"id"`
+	Size        int64  `json:"size"`
+	Md5Checksum string `json:"md5Checksum,omitempty"`
+	ModTime     string `json:"mtime"`
+	MimeType    string `json:"mimeType"`
+}
+
+// LockPath returns the path of the lock that operations changing the
+// state of the context hold, for only one of them to run at a time.
+func (c *Context) LockPath() string {
+	return path.Join(c.GDPath(), "lock")
+}
+
+// MetadataIndexPath returns the path of the file that the metadata index is kept in.
+func (c *Context) MetadataIndexPath() string {
+	return metadataIndexPath(c.GDPath())
+}
+
+func (c *Context) ReadMetadataIndex() ([]*MetadataEntry, error) {
+	var entries []*MetadataEntry
+	err := readJSONFile(c.MetadataIndexPath(), &entries)
+	return entries, err
+}
+
+func (c *Context) WriteMetadataIndex(entries []*MetadataEntry) error {
+	if entries == nil {
+		entries = []*MetadataEntry{}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(c.MetadataIndexPath(), data)
+}
+
+func hashSnapshotPath(pathGD string) string {
+	return path.Join(pathGD, "hash-snapshot.json")
+}
+
+// HashSnapshotEntry is the md5 checksum of a local file as it was
+// when its size and modification time in Unix seconds were recorded.
+type HashSnapshotEntry struct {
+	Size        int64  `json:"size"`
+	ModTime     int64  `json:"mtime"`
+	Md5Checksum string `json:"md5Checksum"`
+}
+
+// ReadHashSnapshot retrieves the recorded checksums of local
+// files keyed by their paths relative to the root of the context.
+func (c *Context) ReadHashSnapshot() (map[string]*HashSnapshotEntry, error) {
+	entries := make(map[string]*HashSnapshotEntry)
+	err := readJSONFile(hashSnapshotPath(c.GDPath()), &entries)
+	return entries, err
+}
+
+func (c *Context) WriteHashSnapshot(entries map[string]*HashSnapshotEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(hashSnapshotPath(c.GDPath()), data)
+}
+
+func localChecksumsPath(pathGD, algo string) string {
+	return path.Join(pathGD, algo+"sums")
+}
+
+// LocalChecksumsPath returns the path of the manifest that the
+// checksums of pulled files computed with algo are kept in.
+func (c *Context) LocalChecksumsPath(algo string) string {
+	return localChecksumsPath(c.GDPath(), algo)
+}
+
+// ReadLocalChecksums retrieves the checksums of pulled files computed with
+// algo keyed by their paths. The manifest is in the format of `sha256sum`
+// and the like, with paths relative to the root of the context.
+func (c *Context) ReadLocalChecksums(algo string) (map[string]string, error) {
+	sums := make(map[string]string)
+	f, err := os.Open(c.LocalChecksumsPath(algo))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return sums, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// Lines are `<checksum>  <path>` or `<checksum> *<path>` in binary mode.
+		line := scanner.Text()
+		i := strings.Index(line, " ")
+		if i < 0 || i+2 > len(line) {
+			continue
+		}
+		sums["/"+line[i+2:]] = line[:i]
+	}
+
+	return sums, scanner.Err()
+}
+
+func (c *Context) WriteLocalChecksums(algo string, sums map[string]string) error {
+	var paths []string
+	for p := range sums {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+
+	buf := new(bytes.Buffer)
+	for _, p := range paths {
+		fmt.Fprintf(buf, "%s  %s\n", sums[p], strings.TrimPrefix(p, "/"))
+	}
+
+	return writeFileAtomic(c.LocalChecksumsPath(algo), buf.Bytes())
+}
+
+func pullQueuePath(pathGD string) string {
+	return path.Join(pathGD, "pull-queue.json")
+}
+
+// QueueItem is an entry of the persisted pull queue.
+type QueueItem struct {
+	Path        string `json:"path"`
+	Id          string `json:"id,omitempty"`
+	Md5Checksum string `json:"md5Checksum,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	State       string `json:"state"`
+	Verified    bool   `json:"verified,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ReadPullQueue retrieves the items of the persisted pull queue. The queue is
+// kept as one JSON item per line and since updates are appended, the last
+// line for a path holds its current state.
+func (c *Context) ReadPullQueue() ([]*QueueItem, error) {
+	f, err := os.Open(pullQueuePath(c.GDPath()))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var items []*QueueItem
+	dec := json.NewDecoder(f)
+	for {
+		item := &QueueItem{}
+		if err := dec.Decode(item); err != nil {
+			if err == io.EOF {
+				break
+			}
+			// A crash mid-append can truncate the last line, what's before it is intact.
+			if err == io.ErrUnexpectedEOF {
+				break
+			}
+			return items, err
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
+
+// WritePullQueue replaces the persisted pull queue with items.
+func (c *Context) WritePullQueue(items []*QueueItem) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	return writeFileAtomic(pullQueuePath(c.GDPath()), buf.Bytes())
+}
+
+// AppendPullQueue records updates to items of the persisted pull queue.
+func (c *Context) AppendPullQueue(items ...*QueueItem) error {
+	f, err := os.OpenFile(pullQueuePath(c.GDPath()), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(f)
+	for _, item := range items {
+		if err = enc.Encode(item); err != nil {
+			break
+		}
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+func (c *Context) RemovePullQueue() error {
+	err := os.Remove(pullQueuePath(c.GDPath()))
+	if err != nil && os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
 func LeastNonExistantRoot(contextAbsPath string) string {
 	last := ""
 	p := contextAbsPath
@@ -497,3 +1014,83 @@ func MountPoints(contextPath, contextAbsPath string, paths []string, hidden bool
 	}
 	return
 }
+
+// UndoEntry records a file that a destructive operation affected, for
+// the operation to be reversed later. Op is shared by all the entries
+// of the same operation.
+type UndoEntry struct {
+	Op     string `json:"op"`
+	Time   string `json:"time"`
+	Action string `json:"action"`
+	Id     string `json:"id"`
+	Path   string `json:"path"`
+}
+
+func undoLogPath(pathGD string) string {
+	return path.Join(pathGD, "undo.log")
+}
+
+// AppendUndoEntry appends entry to the undo log as a line of JSON, right
+// away so that the affected files are known even if the operation is killed.
+func (c *Context) AppendUndoEntry(entry *UndoEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(undoLogPath(c.GDPath()), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadUndoLog retrieves the entries of the undo log in the order that
+// they were appended, lines that cannot be parsed are skipped.
+func (c *Context) ReadUndoLog() ([]*UndoEntry, error) {
+	f, err := os.Open(undoLogPath(c.GDPath()))
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []*UndoEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) < 1 {
+			continue
+		}
+
+		entry := &UndoEntry{}
+		if err := json.Unmarshal(line, entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// WriteUndoLog replaces the undo log with entries, e.g once the
+// last operation in it was undone.
+func (c *Context) WriteUndoLog(entries []*UndoEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	return writeFileAtomic(undoLogPath(c.GDPath()), buf.Bytes())
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"net/http"
+
+	driveactivity "google.golang.org/api/driveactivity/v2"
+	"google.golang.org/api/googleapi"
+)
+
+// DefaultActivityCount is the number of recent edits that stat -activity shows.
+const DefaultActivityCount = 10
+
+// editActivityFilter only keeps the edits out of the activity of a file.
+const editActivityFilter = "detail.action_detail_case:EDIT"
+
+// recentEdits returns at most count of the latest edits
+// of the file, as recorded by the Drive Activity API.
+func (r *Remote) recentEdits(fileId string, count int) ([]*driveactivity.DriveActivity, error) {
+	svc, err := driveactivity.New(r.client)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := svc.Activity.Query(&driveactivity.QueryDriveActivityRequest{
+		ItemName: "items/" + fileId,
+		Filter:   editActivityFilter,
+		PageSize: int64(count),
+	}).Do()
+	if err != nil {
+		if gErr, ok := err.(*googleapi.Error); ok && gErr.Code == http.StatusForbidden {
+			err = fmt.Errorf("%v, querying activity needs the %s scope, re-run `drive init -%s` to grant it", err, DriveActivityScope, ActivityKey)
+		}
+		return nil, err
+	}
+
+	if len(res.Activities) > count {
+		return res.Activities[:count], nil
+	}
+	return res.Activities, nil
+}
+
+// activityTime returns when the activity happened, the
+// end of its time range if it spanned a period of time.
+func activityTime(act *driveactivity.DriveActivity) string {
+	if act.Timestamp != "" {
+		return act.Timestamp
+	}
+	if act.TimeRange != nil {
+		return act.TimeRange.EndTime
+	}
+	return ""
+}
+
+// activityActor describes who performed an activity. Only the people ids of
+// known users other than the authenticated user are provided by the API.
+func activityActor(actor *driveactivity.Actor) string {
+	switch {
+	case actor == nil:
+		return "unknown"
+	case actor.User != nil:
+		user := actor.User
+		if user.KnownUser != nil {
+			if user.KnownUser.IsCurrentUser {
+				return "you"
+			}
+			return user.KnownUser.PersonName
+		}
+		if user.DeletedUser != nil {
+			return "a deleted user"
+		}
+		return "unknown"
+	case actor.Anonymous != nil:
+		return "anonymous"
+	case actor.Administrator != nil:
+		return "an administrator"
+	case actor.System != nil:
+		return "the system"
+	case actor.Impersonation != nil:
+		return "an impersonated user"
+	}
+	return "unknown"
+}
+
+func activityActors(act *driveactivity.DriveActivity) string {
+	var actors []string
+	for _, actor := range act.Actors {
+		actors = append(actors, activityActor(actor))
+	}
+	if len(actors) < 1 {
+		return "unknown"
+	}
+	return sepJoin(" & ", actors...)
+}
+
+func (g *Commands) statActivity(file *File) error {
+	edits, err := g.rem.recentEdits(file.Id, DefaultActivityCount)
+	if err != nil {
+		return err
+	}
+
+	if len(edits) < 1 {
+		g.log.Logf("\nNo recent edits\n")
+		return nil
+	}
+
+	g.log.Logf("\nRecent edits:\n")
+	for _, edit := range edits {
+		g.log.Logf("%-25s %-30v\n", activityTime(edit), activityActors(edit))
+	}
+	return nil
+}
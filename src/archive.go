@@ -0,0 +1,359 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// The formats of the archives that -archive writes, picked by extension.
+const (
+	ArchiveTarGz = "tar.gz"
+	ArchiveTar   = "tar"
+	ArchiveZip   = "zip"
+)
+
+// archiveFormat returns the format of the archive at p by its extension.
+func archiveFormat(p string) (string, error) {
+	lower := strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return ArchiveTarGz, nil
+	case strings.HasSuffix(lower, ".tar"):
+		return ArchiveTar, nil
+	case strings.HasSuffix(lower, ".zip"):
+		return ArchiveZip, nil
+	}
+	return "", invalidArgumentsErr(fmt.Errorf("%q: unknown archive format, expecting a .tar.gz, .tgz, .tar or .zip file", p))
+}
+
+// archiveWriter writes the entries of an archive one after the other.
+// The size of a file is -1 if it isn't known before its content is read.
+type archiveWriter interface {
+	addDir(name string, modTime time.Time) error
+	addFile(name string, modTime time.Time, size int64, r io.Reader) error
+	Close() error
+}
+
+func newArchiveWriter(w io.Writer, format string) archiveWriter {
+	switch format {
+	case ArchiveZip:
+		return &zipArchive{zw: zip.NewWriter(w)}
+	case ArchiveTarGz:
+		gzw := gzip.NewWriter(w)
+		return &tarArchive{gzw: gzw, tw: tar.NewWriter(gzw)}
+	default:
+		return &tarArchive{tw: tar.NewWriter(w)}
+	}
+}
+
+type tarArchive struct {
+	gzw *gzip.Writer
+	tw  *tar.Writer
+}
+
+func (ta *tarArchive) addDir(name string, modTime time.Time) error {
+	return ta.tw.WriteHeader(&tar.Header{
+		Name:     strings.TrimSuffix(name, "/") + "/",
+		Mode:     0755,
+		ModTime:  modTime,
+		Typeflag: tar.TypeDir,
+	})
+}
+
+func (ta *tarArchive) addFile(name string, modTime time.Time, size int64, r io.Reader) error {
+	// Tar headers come before the content so content of an unknown size,
+	// such as exports, has to be read up into memory to find its size.
+	if size < 0 {
+		blob, err := ioutil.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		size, r = int64(len(blob)), bytes.NewReader(blob)
+	}
+
+	err := ta.tw.WriteHeader(&tar.Header{
+		Name:     name,
+		Mode:     0644,
+		Size:     size,
+		ModTime:  modTime,
+		Typeflag: tar.TypeReg,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(ta.tw, r)
+	return err
+}
+
+func (ta *tarArchive) Close() error {
+	err := ta.tw.Close()
+	if ta.gzw != nil {
+		if gzErr := ta.gzw.Close(); err == nil {
+			err = gzErr
+		}
+	}
+	return err
+}
+
+type zipArchive struct {
+	zw *zip.Writer
+}
+
+func (za *zipArchive) addDir(name string, modTime time.Time) error {
+	hdr := &zip.FileHeader{Name: strings.TrimSuffix(name, "/") + "/"}
+	hdr.SetModTime(modTime)
+	hdr.SetMode(os.ModeDir | 0755)
+	_, err := za.zw.CreateHeader(hdr)
+	return err
+}
+
+func (za *zipArchive) addFile(name string, modTime time.Time, size int64, r io.Reader) error {
+	hdr := &zip.FileHeader{Name: name, Method: zip.Deflate}
+	hdr.SetModTime(modTime)
+	hdr.SetMode(0644)
+	w, err := za.zw.CreateHeader(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func (za *zipArchive) Close() error {
+	return za.zw.Close()
+}
+
+// archivePath returns the path of a remote in the archive, which
+// is its path from the root without the leading separator.
+func archivePath(relToRootPath string) string {
+	return strings.TrimPrefix(path.Clean(relToRootPath), "/")
+}
+
+// archiveSession is an archive that the files of a pull are being added to.
+type archiveSession struct {
+	aw    archiveWriter
+	count int
+	// broken is set once an entry failed partway through, after
+	// which nothing more can be added to the archive.
+	broken bool
+}
+
+// pullToArchive pulls the remote files under the sources straight into
+// the archive in the options, in place of the local tree, without writing
+// any of them to disk. Every file is added whatever the state of the local
+// tree since the archive is a snapshot of the remote.
+func (g *Commands) pullToArchive() (err error) {
+	format, err := archiveFormat(g.opts.Archive)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(g.opts.Archive)
+	if err != nil {
+		return err
+	}
+
+	as := &archiveSession{aw: newArchiveWriter(f, format)}
+	defer func() {
+		if cErr := as.aw.Close(); cErr != nil {
+			err = reComposeError(err, fmt.Sprintf("closing the archive: %v", cErr))
+		}
+		if cErr := f.Close(); cErr != nil {
+			err = reComposeError(err, fmt.Sprintf("closing the archive: %v", cErr))
+		}
+		g.summarizeSkippedNatives()
+		g.log.Logf("archived %d file(s) into %s\n", as.count, g.opts.Archive)
+	}()
+
+	for _, relToRootPath := range g.opts.Sources {
+		remote, fErr := g.rem.FindByPath(relToRootPath)
+		if fErr == nil && remote == nil {
+			fErr = ErrPathNotExists
+		}
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+			continue
+		}
+
+		if aErr := g.archiveTree(as, relToRootPath, remote, g.opts.Depth); aErr != nil {
+			err = reComposeError(err, aErr.Error())
+		}
+		if as.broken {
+			break
+		}
+	}
+
+	return err
+}
+
+func (g *Commands) archiveTree(as *archiveSession, relToRootPath string, f *File, depth int) (err error) {
+	if anyMatch(g.opts.Ignorer, path.Base(relToRootPath), relToRootPath) {
+		return nil
+	}
+
+	if !f.IsDir {
+		if err := g.archiveFile(as, relToRootPath, f); err != nil {
+			return fmt.Errorf("%s: %v", relToRootPath, err)
+		}
+		as.count += 1
+		return nil
+	}
+
+	if !rootLike(relToRootPath) {
+		if err := as.aw.addDir(archivePath(relToRootPath), f.ModTime); err != nil {
+			as.broken = true
+			return fmt.Errorf("%s: %v", relToRootPath, err)
+		}
+	}
+
+	childDepth := decrementTraversalDepth(depth)
+	if childDepth == 0 {
+		return nil
+	}
+
+	var children []*File
+	pagePair := g.rem.FindByParentId(f.Id, g.opts.Hidden)
+
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child == nil {
+				continue
+			}
+
+			if g.skipNotOwned(remotePathJoin(relToRootPath, child.Name), child) {
+				continue
+			}
+			if g.opts.ExcludeGoogleDocs && googleNative(child) {
+				continue
+			}
+			if child.IsDir && !g.opts.Recursive {
+				continue
+			}
+			children = append(children, child)
+		}
+	}
+
+	if listErr != nil {
+		return fmt.Errorf("%s: %v", relToRootPath, listErr)
+	}
+
+	for _, child := range children {
+		if aErr := g.archiveTree(as, remotePathJoin(relToRootPath, child.Name), child, childDepth); aErr != nil {
+			err = reComposeError(err, aErr.Error())
+		}
+		if as.broken {
+			break
+		}
+	}
+
+	return err
+}
+
+// archiveFile adds the content of f to the archive, or its exports
+// if it is a Google-native file, each named after it with the
+// extension of its format.
+func (g *Commands) archiveFile(as *archiveSession, relToRootPath string, f *File) error {
+	name := archivePath(relToRootPath)
+	if f.BlobAt != "" {
+		return g.archiveContent(as, name, f, "")
+	}
+
+	exports := g.exportFormats(f, g.opts.Exports)
+	if len(exports) < 1 || !hasExportLinks(f) {
+		reason := nativeSkipReason(f, exports)
+		g.log.LogErrf("%s: skipping, %s\n", relToRootPath, reason)
+		g.skippedNatives.add(relToRootPath, reason)
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, format := range exports {
+		ext := exportExtension(format)
+		_, exportURL, ok := exportLink(f.ExportLinks, ext)
+		if seen[ext] || !ok {
+			continue
+		}
+		seen[ext] = true
+
+		if err := g.archiveContent(as, sepJoin(".", name, ext), f, exportURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (g *Commands) archiveContent(as *archiveSession, name string, f *File, exportURL string) error {
+	blob, err := g.rem.Download(f.Id, exportURL)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	// Only the content as it is stored remotely has a known size and checksum
+	size, md5Checksum := f.Size, f.Md5Checksum
+	if exportURL != "" || g.rem.decrypter != nil {
+		size, md5Checksum = -1, ""
+	}
+	if g.opts.Decompress && compressedOnRemote(f) {
+		if blob, err = gunzipReader(blob); err != nil {
+			return err
+		}
+		size, md5Checksum = -1, ""
+	}
+	if g.opts.IgnoreChecksum {
+		md5Checksum = ""
+	}
+
+	hasher := md5.New()
+	if err := as.aw.addFile(name, f.ModTime, size, io.TeeReader(g.rem.throttle(blob), hasher)); err != nil {
+		as.broken = true
+		return err
+	}
+
+	if gotMd5 := fmt.Sprintf("%x", hasher.Sum(nil)); md5Checksum != "" && gotMd5 != md5Checksum {
+		return downloadFailedErr(fmt.Errorf("md5 checksum mismatch of %s, got %s expected %s", name, gotMd5, md5Checksum))
+	}
+
+	if !g.opts.SummaryOnly {
+		g.log.Logf("Archived %s\n", name)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// DefaultAuthBindAddr is the address that the server receiving the
+// authorization redirect binds to by default, the loopback interface.
+const DefaultAuthBindAddr = "127.0.0.1"
+
+// DefaultAuthTimeout is how long the consent screen
+// is waited on for before the authorization gives up.
+const DefaultAuthTimeout = 5 * time.Minute
+
+type authCodeResult struct {
+	code string
+	err  error
+}
+
+// listenLoopback listens on port of bindAddr, the loopback interface if
+// it is empty, falling back to any free port if that one is taken. A port
+// of 0 always picks a free one.
+func listenLoopback(bindAddr string, port int) (net.Listener, error) {
+	if bindAddr == "" {
+		bindAddr = DefaultAuthBindAddr
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(bindAddr, strconv.Itoa(port)))
+	if err == nil || port == 0 {
+		return listener, err
+	}
+
+	fmt.Fprintf(os.Stderr, "port %d is unavailable: %v, picking another\n", port, err)
+	return net.Listen("tcp", net.JoinHostPort(bindAddr, "0"))
+}
+
+// authRedirectURL returns the redirect URI to advertise for a server
+// bound to bindAddr and listening on addr. Wildcard addresses such as
+// 0.0.0.0 can't be browsed to, so the loopback address is advertised
+// for them, which is what a forwarded port is reached on.
+func authRedirectURL(bindAddr string, addr net.Addr) string {
+	host := strings.TrimSpace(bindAddr)
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = DefaultAuthBindAddr
+	}
+
+	_, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return fmt.Sprintf("http://%s/", addr)
+	}
+	return fmt.Sprintf("http://%s/", net.JoinHostPort(host, port))
+}
+
+// authCodeHandler receives the redirect of the consent screen and sends its
+// authorization code on results, once. Requests without the state that the
+// flow was started with, e.g for a favicon, are turned away.
+func authCodeHandler(state string, results chan<- authCodeResult) http.Handler {
+	var once sync.Once
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if query.Get("state") != state {
+			http.Error(w, "unexpected state", http.StatusBadRequest)
+			return
+		}
+
+		result := authCodeResult{code: query.Get("code")}
+		switch {
+		case query.Get("error") != "":
+			result.err = fmt.Errorf("authorization was denied: %s", query.Get("error"))
+		case result.code == "":
+			result.err = fmt.Errorf("the redirect has no authorization code")
+		}
+
+		if result.err != nil {
+			http.Error(w, fmt.Sprintf("drive was not authorized: %v", result.err), http.StatusBadRequest)
+		} else {
+			fmt.Fprintln(w, "drive is now authorized, you can close this window.")
+		}
+
+		once.Do(func() { results <- result })
+	})
+}
+
+// RetrieveRefreshTokenViaLoopback runs the authorization flow with the consent
+// screen redirecting back to a server on port of bindAddr, which is the
+// loopback interface by default. Access to extraScopes is requested too.
+func RetrieveRefreshTokenViaLoopback(ctx context.Context, context *config.Context, bindAddr string, port int, extraScopes ...string) (string, error) {
+	listener, err := listenLoopback(bindAddr, port)
+	if err != nil {
+		return "", err
+	}
+	defer listener.Close()
+
+	config := newAuthConfig(context, extraScopes...)
+	config.RedirectURL = authRedirectURL(bindAddr, listener.Addr())
+
+	randState := fmt.Sprintf("%v%v", time.Now().UnixNano(), rand.Uint32())
+	results := make(chan authCodeResult, 1)
+	go http.Serve(listener, authCodeHandler(randState, results))
+
+	url := config.AuthCodeURL(randState, oauth2.AccessTypeOffline)
+	fmt.Printf("Visit this URL to authorize drive, it redirects back to %s\n%s\n", config.RedirectURL, url)
+	fmt.Printf("Without a browser on this machine, run `drive %s -%s` instead\n", InitKey, CLIOptionAuthManual)
+
+	code, err := awaitAuthCode(ctx, results, DefaultAuthTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := config.Exchange(ctx, code)
+	if err != nil {
+		return "", err
+	}
+	return token.RefreshToken, nil
+}
+
+// awaitAuthCode waits for the authorization code on results, giving
+// up once ctx is done or after timeout, e.g if the browser was closed.
+func awaitAuthCode(ctx context.Context, results <-chan authCodeResult, timeout time.Duration) (string, error) {
+	select {
+	case result := <-results:
+		return result.code, result.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-time.After(timeout):
+		return "", fmt.Errorf("gave up waiting for authorization after %v, run `drive %s -%s` if the redirect can't be reached", timeout, InitKey, CLIOptionAuthManual)
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// bandwidthReevaluationInterval is how often the scheduled rate is looked
+	// up again, so that long transfers adapt once another rule kicks in.
+	bandwidthReevaluationInterval = time.Minute
+
+	// bandwidthChunkSize caps the size of each throttled read
+	// so that waits are spread out evenly over a transfer.
+	bandwidthChunkSize = 32 * 1024
+)
+
+// BandwidthRule limits transfers to Rate bytes per second, 0 meaning
+// unlimited, between the Start and End times of the day. A rule whose
+// End is before its Start spans midnight.
+type BandwidthRule struct {
+	Start time.Duration
+	End   time.Duration
+	Rate  int64
+}
+
+func (rule BandwidthRule) covers(sinceMidnight time.Duration) bool {
+	switch {
+	case rule.Start == rule.End:
+		return true
+	case rule.Start < rule.End:
+		return sinceMidnight >= rule.Start && sinceMidnight < rule.End
+	default:
+		return sinceMidnight >= rule.Start || sinceMidnight < rule.End
+	}
+}
+
+func parseTimeOfDay(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// ParseBandwidthSchedule parses comma separated `<start>-<end>:<rate>` rules
+// e.g `09:00-17:00:512K,17:00-09:00:0` where rate is a size per second.
+func ParseBandwidthSchedule(schedule string) (rules []BandwidthRule, err error) {
+	for _, spec := range strings.Split(schedule, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		dashIndex := strings.Index(spec, "-")
+		rateIndex := strings.LastIndex(spec, ":")
+		if dashIndex < 0 || rateIndex < dashIndex {
+			return nil, invalidArgumentsErr(fmt.Errorf("bandwidth rule %q: expecting `<start>-<end>:<rate>`", spec))
+		}
+
+		start, sErr := parseTimeOfDay(spec[:dashIndex])
+		end, eErr := parseTimeOfDay(spec[dashIndex+1 : rateIndex])
+		if sErr != nil || eErr != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("bandwidth rule %q: times must be formatted as HH:MM", spec))
+		}
+
+		rate, rErr := ParseByteSize(spec[rateIndex+1:])
+		if rErr != nil {
+			return nil, rErr
+		}
+
+		rules = append(rules, BandwidthRule{Start: start, End: end, Rate: rate})
+	}
+
+	return rules, nil
+}
+
+// scheduledRate returns the rate of the first rule that covers the time
+// of the day of t, transfers are unlimited at times no rule covers.
+func scheduledRate(rules []BandwidthRule, t time.Time) int64 {
+	sinceMidnight := time.Duration(t.Hour())*time.Hour +
+		time.Duration(t.Minute())*time.Minute +
+		time.Duration(t.Second())*time.Second
+
+	for _, rule := range rules {
+		if rule.covers(sinceMidnight) {
+			return rule.Rate
+		}
+	}
+	return 0
+}
+
+// bandwidthLimiter paces all the transfers that share it to the
+// rate that its schedule sets for the current local time.
+type bandwidthLimiter struct {
+	sync.Mutex
+	schedule []BandwidthRule
+	logf     func(string, ...interface{})
+
+	rate        int64
+	evaluatedAt time.Time
+	// next is when the bytes that were reserved so far will have been transferred.
+	next time.Time
+}
+
+func newBandwidthLimiter(schedule []BandwidthRule, logf func(string, ...interface{})) *bandwidthLimiter {
+	if len(schedule) < 1 {
+		return nil
+	}
+	return &bandwidthLimiter{schedule: schedule, logf: logf}
+}
+
+// wait blocks until n more bytes can be transferred within the current rate.
+func (bl *bandwidthLimiter) wait(n int) {
+	if n < 1 {
+		return
+	}
+
+	bl.Lock()
+	now := time.Now()
+	if firstTime := bl.evaluatedAt.IsZero(); firstTime || now.Sub(bl.evaluatedAt) >= bandwidthReevaluationInterval {
+		rate := scheduledRate(bl.schedule, now)
+		if (firstTime || rate != bl.rate) && bl.logf != nil {
+			if rate > 0 {
+				bl.logf("bandwidth limit: %s/s\n", prettyBytes(rate))
+			} else {
+				bl.logf("bandwidth limit: none\n")
+			}
+		}
+		bl.rate = rate
+		bl.evaluatedAt = now
+	}
+
+	if bl.rate <= 0 {
+		bl.Unlock()
+		return
+	}
+
+	if bl.next.Before(now) {
+		bl.next = now
+	}
+	bl.next = bl.next.Add(time.Duration(float64(n) / float64(bl.rate) * float64(time.Second)))
+	delay := bl.next.Sub(now)
+	bl.Unlock()
+
+	time.Sleep(delay)
+}
+
+type throttledReader struct {
+	io.Reader
+	limiter *bandwidthLimiter
+}
+
+func (tr *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunkSize {
+		p = p[:bandwidthChunkSize]
+	}
+	n, err := tr.Reader.Read(p)
+	tr.limiter.wait(n)
+	return n, err
+}
+
+// throttle returns a reader that is paced by the bandwidth limiter, if any.
+func (r *Remote) throttle(rd io.Reader) io.Reader {
+	if r.bandwidth == nil || rd == nil {
+		return rd
+	}
+	return &throttledReader{Reader: rd, limiter: r.bandwidth}
+}
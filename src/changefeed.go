@@ -0,0 +1,175 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"time"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+const DefaultChangesPollInterval = 30 * time.Second
+
+// changeEvent is a line of the NDJSON stream of changes. Removed is set
+// for files that were deleted or that are no longer accessible, in
+// which case only the ids and the time of the change are known.
+type changeEvent struct {
+	Id           int64    `json:"id"`
+	FileId       string   `json:"fileId"`
+	Time         string   `json:"time"`
+	Removed      bool     `json:"removed"`
+	Title        string   `json:"title,omitempty"`
+	MimeType     string   `json:"mimeType,omitempty"`
+	IsDir        bool     `json:"isDir,omitempty"`
+	Size         int64    `json:"size,omitempty"`
+	Md5Checksum  string   `json:"md5Checksum,omitempty"`
+	ModifiedTime string   `json:"modifiedTime,omitempty"`
+	Trashed      bool     `json:"trashed,omitempty"`
+	Parents      []string `json:"parents,omitempty"`
+}
+
+func newChangeEvent(c *drive.Change) *changeEvent {
+	ev := &changeEvent{
+		Id:      c.Id,
+		FileId:  c.FileId,
+		Time:    c.ModificationDate,
+		Removed: c.Deleted || c.File == nil,
+	}
+	if ev.Removed {
+		return ev
+	}
+
+	f := NewRemoteFile(c.File)
+	ev.Title = f.Name
+	ev.MimeType = f.MimeType
+	ev.IsDir = f.IsDir
+	ev.Size = f.Size
+	ev.Md5Checksum = f.Md5Checksum
+	ev.ModifiedTime = f.ModTime.UTC().Format(time.RFC3339)
+	ev.Trashed = f.Labels != nil && f.Labels.Trashed
+	for _, parent := range f.Parents {
+		if parent != nil {
+			ev.Parents = append(ev.Parents, parent.Id)
+		}
+	}
+	return ev
+}
+
+// listChanges hands fn each change from startChangeId on, or from the first
+// change if it isn't set, and returns the largest id of a change so far.
+func (r *Remote) listChanges(startChangeId int64, fn func(*drive.Change) error) (largestChangeId int64, err error) {
+	req := r.service.Changes.List()
+	if startChangeId > 0 {
+		req = req.StartChangeId(startChangeId)
+	}
+
+	for pageToken := ""; ; {
+		res, err := req.PageToken(pageToken).Do()
+		if err != nil {
+			return largestChangeId, err
+		}
+
+		largestChangeId = res.LargestChangeId
+		for _, change := range res.Items {
+			if change == nil {
+				continue
+			}
+			if err := fn(change); err != nil {
+				return largestChangeId, err
+			}
+		}
+
+		if pageToken = res.NextPageToken; pageToken == "" {
+			return largestChangeId, nil
+		}
+	}
+}
+
+// nextChangeId returns the id to resume from after the changes from start on
+// were streamed, which never goes backwards even if no changes were seen.
+func nextChangeId(start, largestSeen, largestChangeId int64) int64 {
+	next := start
+	for _, candidate := range []int64{largestSeen + 1, largestChangeId + 1} {
+		if candidate > next {
+			next = candidate
+		}
+	}
+	return next
+}
+
+// ChangeFeed prints the changes since the stored cursor, or since the change
+// id in the options, as NDJSON without downloading anything. The cursor is only
+// advanced once the changes have been streamed to completion, so changes are
+// printed at least once and an interrupted stream is resumed where it started.
+func (g *Commands) ChangeFeed() error {
+	next := g.opts.SinceChangeId
+	if next < 1 {
+		stored, err := g.context.ReadChangeCursor()
+		if err != nil {
+			return err
+		}
+		next = stored
+	}
+
+	var err error
+	if next, err = g.streamChanges(next); err != nil || !g.opts.Follow {
+		return err
+	}
+
+	interval := g.opts.PollInterval
+	if interval <= 0 {
+		interval = DefaultChangesPollInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		streamedNext, sErr := g.streamChanges(next)
+		if sErr != nil {
+			g.log.LogErrf("changes: %v, retrying from change %d in %v\n", sErr, next, interval)
+			continue
+		}
+		next = streamedNext
+	}
+
+	return nil
+}
+
+func (g *Commands) streamChanges(start int64) (int64, error) {
+	largestSeen := int64(0)
+	largestChangeId, err := g.rem.listChanges(start, func(c *drive.Change) error {
+		blob, err := json.Marshal(newChangeEvent(c))
+		if err != nil {
+			return err
+		}
+
+		g.log.Logln(string(blob))
+		if c.Id > largestSeen {
+			largestSeen = c.Id
+		}
+		return nil
+	})
+	if err != nil {
+		return start, err
+	}
+
+	next := nextChangeId(start, largestSeen, largestChangeId)
+	if err := g.context.WriteChangeCursor(next); err != nil {
+		return start, err
+	}
+	return next, nil
+}
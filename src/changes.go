@@ -19,6 +19,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -156,7 +157,7 @@ func (g *Commands) byRemoteResolve(relToRoot, fsPath string, r *File, push bool)
 }
 
 func (g *Commands) changeListResolve(relToRoot, fsPath string, push bool) (cl, clashes []*Change, err error) {
-	pagePair := g.rem.FindByPathM(relToRoot)
+	pagePair := g.findByPathM(relToRoot)
 	iterCount := uint64(0)
 	noClashThreshold := uint64(1)
 
@@ -199,9 +200,70 @@ func (g *Commands) changeListResolve(relToRoot, fsPath string, push bool) (cl, c
 		// err = reComposeError(err, ErrClashesDetected.Error())
 	}
 
+	cl = g.boundDeletionsByDepth(relToRoot, cl)
 	return
 }
 
+func pathDepth(p string) int {
+	return len(NonEmptyStrings(strings.Split(path.Clean(p), RemoteSeparator)...))
+}
+
+func (g *Commands) sizeWithinRange(size int64) bool {
+	if g.opts.MinFileSize > 0 && size < g.opts.MinFileSize {
+		return false
+	}
+	if g.opts.MaxFileSize > 0 && size > g.opts.MaxFileSize {
+		return false
+	}
+	return true
+}
+
+// filterBySize drops and reports the additions and modifications of files
+// whose source sizes are outside of opts.MinFileSize and opts.MaxFileSize.
+func (g *Commands) filterBySize(cl []*Change) []*Change {
+	if g.opts.MinFileSize < 1 && g.opts.MaxFileSize < 1 {
+		return cl
+	}
+
+	var filtered []*Change
+	for _, c := range cl {
+		if c == nil || c.Src == nil || c.Src.IsDir {
+			filtered = append(filtered, c)
+			continue
+		}
+
+		if op := c.Op(); (op == OpAdd || op == OpMod || op == OpModConflict) && !g.sizeWithinRange(c.Src.Size) {
+			g.log.Logf("%s: skipping, its size %s is outside of the allowed range\n", c.Path, prettyBytes(c.Src.Size))
+			continue
+		}
+
+		filtered = append(filtered, c)
+	}
+
+	return filtered
+}
+
+// boundDeletionsByDepth drops and reports the deletions that are
+// more than opts.PruneDepth levels below base.
+func (g *Commands) boundDeletionsByDepth(base string, cl []*Change) []*Change {
+	if g.opts.PruneDepth < 1 {
+		return cl
+	}
+
+	baseDepth := pathDepth(base)
+
+	var bounded []*Change
+	for _, c := range cl {
+		if c != nil && c.Op() == OpDelete && pathDepth(c.Path)-baseDepth > g.opts.PruneDepth {
+			g.log.LogErrf("%s: not deleting, it is more than %d level(s) below %s\n", c.Path, g.opts.PruneDepth, base)
+			continue
+		}
+		bounded = append(bounded, c)
+	}
+
+	return bounded
+}
+
 func directionalComplement(local, remote *File, push bool) *File {
 	first, other := remote, local
 	if push {
@@ -317,6 +379,52 @@ type changeSliceArg struct {
 	changeListPtr *[]*Change
 }
 
+// defaultChangeSliceSize is the number of the children of a folder that
+// each goroutine resolves the changes of.
+const defaultChangeSliceSize = 100
+
+// listSlots bounds the number of folders whose remote children are
+// listed at once while resolving changes, a nil listSlots is unbounded.
+type listSlots chan bool
+
+func newListSlots(n int) listSlots {
+	if n < 1 {
+		return nil
+	}
+	return make(listSlots, n)
+}
+
+func (ls listSlots) acquire() {
+	if ls != nil {
+		ls <- true
+	}
+}
+
+func (ls listSlots) release() {
+	if ls != nil {
+		<-ls
+	}
+}
+
+// changeSliceSize returns the number of the n children of a folder that
+// each goroutine resolves, such that with workers > 0 the children are
+// spread over at most that many goroutines and their subfolders are
+// listed in parallel instead of one after the other.
+func changeSliceSize(n, workers int) int {
+	if workers < 1 {
+		return defaultChangeSliceSize
+	}
+
+	size := (n + workers - 1) / workers
+	if size < 1 {
+		return 1
+	}
+	if size > defaultChangeSliceSize {
+		return defaultChangeSliceSize
+	}
+	return size
+}
+
 func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []*Change, err error) {
 	l := clr.local
 	r := clr.remote
@@ -341,7 +449,13 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		return
 	}
 
-	explicitlyRequested := g.opts.ExplicitlyExport && hasExportLinks(r) && len(g.opts.Exports) >= 1
+	if g.skipNotOwned(clr.remoteBase, r) {
+		return
+	}
+
+	explicitlyRequested := g.opts.ExplicitlyExport && hasExportLinks(r) && (len(g.opts.Exports) >= 1 || g.opts.ExportAll)
+
+	g.hashSnapshot.apply(l)
 
 	if clr.push {
 		// Handle the case of doc files for which we don't have a direct download
@@ -349,8 +463,15 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		if hasExportLinks(r) {
 			return cl, clashes, nil
 		}
+		// Files that were already ingested are left as they are
+		if g.opts.SkipExisting && l != nil && r != nil && !l.IsDir && !r.IsDir {
+			return cl, clashes, nil
+		}
 		change = &Change{Path: clr.remoteBase, Src: l, Dest: r, Parent: dir, g: g}
 	} else {
+		if g.opts.ExcludeGoogleDocs && googleNative(r) {
+			return cl, clashes, nil
+		}
 		exportable := !g.opts.Force && hasExportLinks(r)
 		if exportable && !explicitlyRequested {
 			// The case when we have files that don't provide the download urls
@@ -437,8 +558,16 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 
 	var pagePair *paginationPair
 
+	// The slot is only held while listing so that the subfolders
+	// resolved below can take it up without waiting on their parent.
+	g.listSlots.acquire()
 	if r != nil {
 		pagePair = g.rem.FindByParentId(r.Id, g.opts.Hidden)
+		if g.compressionToggled() {
+			pagePair = g.uncompressedView(pagePair)
+		}
+		pagePair = g.flattenedView(clr.remoteBase, pagePair, clr.push)
+		pagePair = g.renamedView(clr.remoteBase, pagePair, clr.push)
 	} else {
 		// TODO: Figure out if the condition
 		// file == nil && err == nil
@@ -451,11 +580,21 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		pagePair = &paginationPair{errsChan: errsChan, filesChan: filesChan}
 	}
 
-	dirlist, clashingFiles, err := merge(pagePair, localChildren, g.opts.IgnoreNameClashes)
+	dirlist, clashingFiles, caseClashes, err := merge(pagePair, localChildren, g.opts.IgnoreNameClashes, g.opts.IgnoreCase, g.rem.normalize)
+	g.listSlots.release()
 	if err != nil {
 		return nil, nil, err
 	}
 
+	for _, caseClash := range caseClashes {
+		var names []string
+		for _, f := range caseClash {
+			names = append(names, remotePathJoin(clr.remoteBase, f.Name))
+		}
+		g.log.LogErrf("warning: %s only differ by case, only %q will be considered\n",
+			strings.Join(names, ", "), names[0])
+	}
+
 	if !g.opts.IgnoreNameClashes && len(clashingFiles) >= 1 {
 		remoteBase := clr.remoteBase
 		if rootLike(remoteBase) {
@@ -474,9 +613,8 @@ func (g *Commands) resolveChangeListRecv(clr *changeListResolve) (cl, clashes []
 		}
 	}
 
-	// Arbitrary value. TODO: Calibrate or calculate this value
-	chunkSize := 100
 	srcLen := len(dirlist)
+	chunkSize := changeSliceSize(srcLen, g.opts.ConcurrentList)
 	chunkCount, remainder := srcLen/chunkSize, srcLen%chunkSize
 	i := 0
 
@@ -567,18 +705,38 @@ func (g *Commands) changeSlice(cslArg *changeSliceArg) {
 		}
 
 		if cErr == ErrClashesDetected {
-			clashesMap[id] = childClashes
+			cslArg.mu.Lock()
+			clashesMap[id] = append(clashesMap[id], childClashes...)
+			cslArg.mu.Unlock()
 			continue
 		} else if cErr != ErrPathNotExists {
 			g.log.LogErrf("%s: %v\n", localBase, cErr)
+			if g.continueOnError(remoteBase, cErr) {
+				continue
+			}
 			break
 		}
 	}
 }
 
-func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool) (merged []*dirList, clashes []*File, err error) {
+// merge pairs up the remote and local files that share the same name. If ignoreCase
+// is set, names are compared case-insensitively and remote files whose names only
+// differ by case are returned in caseClashes keyed by their case-folded name.
+// If normalize is set, names are compared in the Unicode form that it normalizes to.
+func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes, ignoreCase bool, normalize func(string) string) (merged []*dirList, clashes []*File, caseClashes map[string][]*File, err error) {
 	localsMap := map[string]*File{}
 	remotesMap := map[string]*File{}
+	foldedRemotesMap := map[string]*File{}
+
+	keyOf := func(name string) string {
+		if normalize != nil {
+			name = normalize(name)
+		}
+		if ignoreCase {
+			return strings.ToLower(name)
+		}
+		return name
+	}
 
 	uniqClashes := map[string]bool{}
 
@@ -596,7 +754,7 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 
 	// TODO: Add support for FileSystems that allow same names but different files.
 	for l := range locals {
-		localsMap[l.Name] = l
+		localsMap[keyOf(l.Name)] = l
 	}
 
 	working := true
@@ -614,7 +772,7 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 			// and a multitude of other issues that were caused by error responses
 			// from remote falsely being translated as "the file doesn't exist"
 			if err != nil {
-				return merged, clashes, err
+				return merged, clashes, caseClashes, err
 			}
 		case r, stillHasContent := <-remotePagePair.filesChan:
 			if !stillHasContent {
@@ -623,6 +781,24 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 			}
 			list := &dirList{remote: r}
 
+			if ignoreCase {
+				// Files whose names only differ by case cannot both
+				// be represented on a case-insensitive filesystem.
+				key := keyOf(r.Name)
+				prev, present := foldedRemotesMap[key]
+				if present && prev.Name != r.Name {
+					if caseClashes == nil {
+						caseClashes = make(map[string][]*File)
+					}
+					if len(caseClashes[key]) < 1 {
+						caseClashes[key] = append(caseClashes[key], prev)
+					}
+					caseClashes[key] = append(caseClashes[key], r)
+					continue
+				}
+				foldedRemotesMap[key] = r
+			}
+
 			if !ignoreClashes {
 				prev, present := remotesMap[r.Name]
 				if present {
@@ -634,11 +810,11 @@ func merge(remotePagePair *paginationPair, locals chan *File, ignoreClashes bool
 				remotesMap[r.Name] = r
 			}
 
-			l, ok := localsMap[r.Name]
+			l, ok := localsMap[keyOf(r.Name)]
 			// look for local
 			if ok && l != nil && l.IsDir == r.IsDir {
 				list.local = l
-				delete(localsMap, r.Name)
+				delete(localsMap, keyOf(r.Name))
 			}
 			merged = append(merged, list)
 		}
@@ -799,6 +975,11 @@ type changeListArg struct {
 	noPrompt   bool
 	noClobber  bool
 	canPreview bool
+	// selectable when set lets the user deselect changes from a
+	// numbered listing, after which changes is only the selected ones.
+	selectable bool
+	// summaryOnly when set only previews the totals of the changes.
+	summaryOnly bool
 }
 
 func previewChanges(clArgs *changeListArg, reduce bool, opMap map[Operation]sizeCounter) {
@@ -806,6 +987,9 @@ func previewChanges(clArgs *changeListArg, reduce bool, opMap map[Operation]size
 	cl := clArgs.changes
 
 	for _, c := range cl {
+		if clArgs.summaryOnly {
+			break
+		}
 		op := c.Op()
 		if op != OpNone {
 			logy.Logln(c.Symbol(), c.Path)
@@ -823,6 +1007,80 @@ func previewChanges(clArgs *changeListArg, reduce bool, opMap map[Operation]size
 	}
 }
 
+// ParseChangeSelection parses a selection of 1-based change numbers
+// e.g "3,7,9" or with ranges "2-5,8" for a list of n changes.
+func ParseChangeSelection(selection string, n int) (map[int]bool, error) {
+	selected := make(map[int]bool)
+	for _, part := range NonEmptyTrimmedStrings(strings.Split(selection, ",")...) {
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := strconv.Atoi(strings.TrimSpace(bounds[0]))
+		if err != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("%q is not a change number", part))
+		}
+
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(strings.TrimSpace(bounds[1])); err != nil {
+				return nil, invalidArgumentsErr(fmt.Errorf("%q is not a range of change numbers", part))
+			}
+		}
+
+		if start < 1 || end > n || start > end {
+			return nil, invalidArgumentsErr(fmt.Errorf("%q is not within the changes 1-%d", part, n))
+		}
+
+		for i := start; i <= end; i++ {
+			selected[i] = true
+		}
+	}
+
+	return selected, nil
+}
+
+// selectChanges prints a numbered listing of the changes and
+// returns those that the user didn't deselect by number.
+func selectChanges(clArg *changeListArg) []*Change {
+	logy := clArg.logy
+
+	var actionable, kept []*Change
+	for _, c := range clArg.changes {
+		if c.Op() == OpNone {
+			kept = append(kept, c)
+		} else {
+			actionable = append(actionable, c)
+		}
+	}
+
+	if len(actionable) < 1 {
+		return clArg.changes
+	}
+
+	for i, c := range actionable {
+		logy.Logf("%4d %s %s\n", i+1, c.Symbol(), c.Path)
+	}
+
+	var skipped map[int]bool
+	for {
+		input := prompt(os.Stdin, os.Stdout, "Numbers of the changes to skip e.g 3,7,9 or 2-5 [none]: ")
+
+		var err error
+		if skipped, err = ParseChangeSelection(input, len(actionable)); err == nil {
+			break
+		}
+		logy.LogErrln(err)
+	}
+
+	for i, c := range actionable {
+		if skipped[i+1] {
+			logy.Logf("Skipping %s %s\n", c.Symbol(), c.Path)
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	return kept
+}
+
 func rejected(status Agreement) bool {
 	return (status & Rejected) != 0
 }
@@ -853,6 +1111,14 @@ func printChangeList(clArg *changeListArg) (Agreement, *map[Operation]sizeCounte
 		return AcceptedImplicitly, nil
 	}
 
+	if clArg.selectable && !clArg.noPrompt {
+		clArg.changes = selectChanges(clArg)
+		if len(clArg.changes) == 0 {
+			clArg.logy.Logln("No changes selected.")
+			return NotApplicable, nil
+		}
+	}
+
 	opMap := opChangeCount(clArg.changes)
 	previewChanges(clArg, true, opMap)
 
@@ -0,0 +1,81 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Check validates the credentials, ignore patterns and sources that
+// command would run with and reports them, without any API calls.
+func (g *Commands) Check(command string) (err error) {
+	g.log.Logf("command: %s\n", command)
+	g.log.Logf("context: %s\n", g.context.AbsPathOf(""))
+	if g.context.ConfigDir != "" {
+		g.log.Logf("config dir: %s\n", g.context.ConfigDir)
+	}
+	if g.context.RemoteRoot != "" {
+		g.log.Logf("remote root: %s\n", g.context.RemoteRoot)
+	}
+
+	switch {
+	case g.context.GSAJWTConfig != nil:
+		g.log.Logf("credentials: service account %s\n", g.context.GSAJWTConfig.Email)
+	case g.context.RefreshToken != "":
+		g.log.Logf("credentials: refresh token\n")
+	default:
+		err = reComposeError(err, fmt.Sprintf("credentials: none found, run `drive %s` first", InitKey))
+	}
+
+	ignoresPath := filepath.Join(g.context.AbsPath, DriveIgnoreSuffix)
+	if _, iErr := combineIgnores(ignoresPath); iErr != nil {
+		err = reComposeError(err, fmt.Sprintf("%s: %v", ignoresPath, iErr))
+	} else if _, sErr := os.Stat(ignoresPath); sErr == nil {
+		g.log.Logf("ignores: %s\n", ignoresPath)
+	}
+
+	if g.opts == nil {
+		return err
+	}
+
+	if g.opts.ExcludeCrudMask != None {
+		var excluded []string
+		for _, crud := range []struct {
+			value CrudValue
+			name  string
+		}{{Create, "create"}, {Read, "read"}, {Update, "update"}, {Delete, "delete"}} {
+			if g.opts.ExcludeCrudMask&crud.value != 0 {
+				excluded = append(excluded, crud.name)
+			}
+		}
+		g.log.Logf("excluded operations: %s\n", strings.Join(excluded, ", "))
+	}
+
+	for _, relToRootPath := range g.opts.Sources {
+		status := "exists locally"
+		if _, sErr := os.Lstat(g.context.AbsPathOf(relToRootPath)); sErr != nil {
+			status = "not found locally"
+		}
+		if anyMatch(g.opts.Ignorer, filepath.Base(relToRootPath), relToRootPath) {
+			status = "ignored"
+		}
+		g.log.Logf("source: %s (%s)\n", relToRootPath, status)
+	}
+
+	return err
+}
@@ -0,0 +1,217 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultResumableStateTTL is the age after which checkpoints are no longer
+// resumed. It matches the week after which Drive expires upload sessions.
+const DefaultResumableStateTTL = 7 * 24 * time.Hour
+
+// checkpointer keeps track of the changes that have been successfully
+// applied during a push or pull and periodically flushes them to
+// the .gd directory so that an interrupted operation can be resumed.
+type checkpointer struct {
+	sync.Mutex
+
+	g         *Commands
+	name      string
+	interval  int
+	pending   int
+	completed map[string]bool
+}
+
+func newCheckpointer(g *Commands, name string) *checkpointer {
+	cp := &checkpointer{
+		g:         g,
+		name:      name,
+		interval:  g.opts.CheckpointInterval,
+		completed: make(map[string]bool),
+	}
+
+	if !g.opts.Resume || g.discardStaleCheckpoint(name) {
+		return cp
+	}
+
+	prevCompleted, err := g.context.ReadCheckpoint(name)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			g.log.LogErrf("checkpoint: reading %q %v\n", name, err)
+		}
+		return cp
+	}
+
+	for _, p := range prevCompleted {
+		cp.completed[p] = true
+	}
+
+	return cp
+}
+
+// discardStaleCheckpoint removes the named checkpoint if it is older than the
+// resumable state TTL, since the state that it refers to has likely changed
+// or expired since, reporting whether it did.
+func (g *Commands) discardStaleCheckpoint(name string) bool {
+	ttl := g.opts.ResumableStateTTL
+	if ttl <= 0 {
+		ttl = DefaultResumableStateTTL
+	}
+
+	modTime, err := g.context.CheckpointModTime(name)
+	if err != nil {
+		return false
+	}
+
+	age := time.Since(modTime)
+	if age <= ttl {
+		return false
+	}
+
+	g.log.LogErrf("%s: the checkpoint was saved %v ago, more than the TTL of %v, starting over\n", name, age-age%time.Second, ttl)
+	if err := g.context.RemoveCheckpoint(name); err != nil {
+		g.log.LogErrf("checkpoint: removing %q %v\n", name, err)
+	}
+	return true
+}
+
+// ResetSessions removes the checkpoints of all interrupted operations,
+// so that none of them get resumed.
+func (g *Commands) ResetSessions() error {
+	removed, err := g.context.RemoveCheckpoints()
+	for _, p := range removed {
+		g.log.Logf("Removed %s\n", p)
+	}
+	if err != nil {
+		return err
+	}
+
+	if len(removed) < 1 {
+		g.log.Logln("No checkpoints to remove.")
+	}
+	return nil
+}
+
+// resumeFromCheckpoint sets up checkpointing for the named operation and
+// if resuming, drops the changes that were completed in a previous run.
+func (g *Commands) resumeFromCheckpoint(name string, cl []*Change, opMap *map[Operation]sizeCounter) (*checkpointer, []*Change, *map[Operation]sizeCounter) {
+	cp := newCheckpointer(g, name)
+
+	remaining, skipped := cp.skippable(cl)
+	if skipped < 1 {
+		return cp, cl, opMap
+	}
+
+	g.log.Logf("%s: resuming, skipping %d already completed changes\n", name, skipped)
+	result := opChangeCount(remaining)
+	return cp, remaining, &result
+}
+
+func (cp *checkpointer) enabled() bool {
+	return cp != nil && cp.interval > 0
+}
+
+// skippable filters out the changes that a previous run already completed.
+func (cp *checkpointer) skippable(cl []*Change) (remaining []*Change, skipped int) {
+	if len(cp.completed) < 1 {
+		return cl, 0
+	}
+
+	for _, c := range cl {
+		if c != nil && cp.completed[c.Path] {
+			skipped += 1
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	return
+}
+
+// done records the change at the path v as completed, which is
+// kept track of even if not flushed periodically so that it can
+// still be saved if the operation has to stop early.
+func (cp *checkpointer) done(v interface{}) {
+	p, ok := v.(string)
+	if !ok || cp == nil {
+		return
+	}
+
+	cp.Lock()
+	defer cp.Unlock()
+
+	cp.completed[p] = true
+	if !cp.enabled() {
+		return
+	}
+
+	cp.pending += 1
+	if cp.pending < cp.interval {
+		return
+	}
+
+	cp.pending = 0
+	cp.flushLocked()
+}
+
+// save flushes the completed changes regardless of the interval.
+func (cp *checkpointer) save() {
+	if cp == nil {
+		return
+	}
+
+	cp.Lock()
+	defer cp.Unlock()
+	cp.flushLocked()
+}
+
+func (cp *checkpointer) flushLocked() {
+	completed := make([]string, 0, len(cp.completed))
+	for p := range cp.completed {
+		completed = append(completed, p)
+	}
+
+	if err := cp.g.context.WriteCheckpoint(cp.name, completed); err != nil {
+		cp.g.log.LogErrf("checkpoint: writing %q %v\n", cp.name, err)
+	}
+}
+
+// finish flushes any unsaved progress if the operation failed, otherwise
+// the checkpoint is no longer needed and gets removed.
+func (cp *checkpointer) finish(opErr error) {
+	if cp == nil {
+		return
+	}
+
+	cp.Lock()
+	defer cp.Unlock()
+
+	if opErr != nil {
+		if cp.enabled() {
+			cp.flushLocked()
+		}
+		return
+	}
+
+	if !cp.enabled() && !cp.g.opts.Resume {
+		return
+	}
+
+	if err := cp.g.context.RemoveCheckpoint(cp.name); err != nil {
+		cp.g.log.LogErrf("checkpoint: removing %q %v\n", cp.name, err)
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"io"
+	"sync"
+
+	"google.golang.org/api/googleapi"
+)
+
+// chunkGrowAfter is the number of chunked uploads in a row that have to
+// succeed before a downgraded chunk size is doubled back towards the
+// configured one.
+const chunkGrowAfter = 5
+
+// chunkSizer adapts the size of the chunks of resumable uploads to the
+// network. Each attempt at an upload that fails halves it, down to the
+// minimum that the API accepts, and it grows back after sustained
+// successes. The zero value uses the configured size.
+type chunkSizer struct {
+	sync.Mutex
+
+	// size is the downgraded chunk size, 0 while the configured one is used.
+	size      int
+	successes int
+	// logf when set reports the changes of the chunk size.
+	logf func(string, ...interface{})
+}
+
+func configuredChunkSize(uploadChunkSize int) int {
+	if uploadChunkSize > 0 {
+		return uploadChunkSize
+	}
+	return googleapi.DefaultUploadChunkSize
+}
+
+func (cs *chunkSizer) current(configured int) int {
+	cs.Lock()
+	defer cs.Unlock()
+	return cs.currentLocked(configured)
+}
+
+func (cs *chunkSizer) currentLocked(configured int) int {
+	if cs.size > 0 && cs.size < configured {
+		return cs.size
+	}
+	return configured
+}
+
+// failed halves the chunk size, reporting false if it is already at the floor.
+func (cs *chunkSizer) failed(configured int) (size int, downgraded bool) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	cs.successes = 0
+	size = cs.currentLocked(configured)
+	if size <= googleapi.MinUploadChunkSize {
+		return size, false
+	}
+
+	size /= 2
+	if size < googleapi.MinUploadChunkSize {
+		size = googleapi.MinUploadChunkSize
+	}
+	cs.size = size
+	return size, true
+}
+
+// succeeded doubles a downgraded chunk size back, up to the configured
+// one, once chunkGrowAfter uploads in a row have succeeded.
+func (cs *chunkSizer) succeeded(configured int) (size int, grown bool) {
+	cs.Lock()
+	defer cs.Unlock()
+
+	size = cs.currentLocked(configured)
+	if size >= configured {
+		cs.successes = 0
+		return size, false
+	}
+
+	cs.successes += 1
+	if cs.successes < chunkGrowAfter {
+		return size, false
+	}
+
+	cs.successes = 0
+	size *= 2
+	if size >= configured {
+		size = configured
+		cs.size = 0
+	} else {
+		cs.size = size
+	}
+	return size, true
+}
+
+func (cs *chunkSizer) logChange(format string, args ...interface{}) {
+	if cs.logf != nil {
+		cs.logf(format, args...)
+	}
+}
+
+// networkFailure reports whether err is one that smaller chunks could
+// help with, a failure of the transport itself or of the server. Others,
+// such as running out of quota, fail whatever the chunk size.
+func networkFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	gErr, ok := err.(*googleapi.Error)
+	if !ok {
+		return true
+	}
+	return gErr.Code >= 500 && gErr.Code <= 599
+}
+
+// uploadChunkSize is the size of the chunks that the content of
+// args is sent in, as downgraded by the failures so far.
+func (r *Remote) uploadChunkSize(args *upsertOpt) int {
+	return r.chunks.current(configuredChunkSize(args.uploadChunkSize))
+}
+
+// uploadEmitter returns the attempts to make at an upload with upload,
+// each retry sending content from its start again. Every attempt whose
+// content was sent in chunks adapts the chunk size right away, so the
+// retries of an upload that failed on the network go out in smaller chunks.
+func (r *Remote) uploadEmitter(args *upsertOpt, content io.Seeker, upload func() (*File, bool, error)) func() (interface{}, error) {
+	attempts := 0
+	return func() (interface{}, error) {
+		if attempts += 1; attempts > 1 && content != nil {
+			if _, err := content.Seek(0, io.SeekStart); err != nil {
+				return &tuple{last: err}, err
+			}
+		}
+
+		f, mediaInserted, err := upload()
+		if mediaInserted {
+			r.adaptChunkSize(args, err)
+		}
+		return &tuple{first: f, second: mediaInserted, last: err}, err
+	}
+}
+
+// adaptChunkSize records how an attempt at an upload whose content was
+// sent in chunks went, so that its retries and the uploads after it use
+// chunks that the network copes with. Uploads that fit in a single chunk
+// say nothing about it.
+func (r *Remote) adaptChunkSize(args *upsertOpt, uploadErr error) {
+	configured := configuredChunkSize(args.uploadChunkSize)
+	before := r.chunks.current(configured)
+	if args.src == nil || args.src.Size <= int64(before) {
+		return
+	}
+
+	if uploadErr == nil {
+		if size, grown := r.chunks.succeeded(configured); grown {
+			r.chunks.logChange("uploads are succeeding, growing the upload chunk size back to %s\n", prettyBytes(int64(size)))
+		}
+		return
+	}
+
+	if !networkFailure(uploadErr) {
+		return
+	}
+	if size, downgraded := r.chunks.failed(configured); downgraded {
+		r.chunks.logChange("%s: uploading with chunks of %s failed, retrying with chunks of %s\n",
+			args.src.Name, prettyBytes(int64(before)), prettyBytes(int64(size)))
+	}
+}
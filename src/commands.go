@@ -21,6 +21,8 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/cheggaaa/pb"
 	"github.com/mattn/go-isatty"
@@ -47,6 +49,16 @@ type Options struct {
 	// same directory instead of in a directory that is prefixed first by the file name
 	ExportsDumpToSameDirectory bool
 
+	// ExportsStripExtension when set keeps the original name of an exported
+	// file instead of appending the extension of the export format to it.
+	ExportsStripExtension bool
+	// PullAs when set is the local name that the single folder or file
+	// pulled by id is written into, in place of its remote title.
+	PullAs string
+	// ExportAll when set exports Google Docs + Sheets to every format
+	// that they can be exported as, in place of Exports.
+	ExportAll bool
+
 	// Force once set always converts NoChange into an Addition
 	Force bool
 	// Hidden discovers hidden paths if set
@@ -100,6 +112,13 @@ type Options struct {
 	Destination                  string
 	RenameMode                   RenameMode
 	ExponentialBackoffRetryCount int
+	// RetriesPerFile when set to n > 0 bounds the retries of all
+	// the requests made for a file, on top of the retry count.
+	RetriesPerFile int
+	// StripPrefix is the leading folder path, relative to the root of the
+	// context, that is removed from the paths of pushed sources before they
+	// are joined to the Destination.
+	StripPrefix string
 
 	Encrypter func(io.Reader) (io.Reader, error)
 	Decrypter func(io.Reader) (io.ReadCloser, error)
@@ -109,11 +128,260 @@ type Options struct {
 	// clickable files where applicable.
 	// See issue #697.
 	AllowURLLinkedFiles bool
+	// LinkShortcuts when set pulls Google-native files as internet
+	// shortcuts to open them with in the browser, instead of
+	// exporting them.
+	LinkShortcuts bool
 
 	// Chunksize is the size per block of data uploaded.
 	// If not set, the default value from googleapi.DefaultUploadChunkSize
 	// is used instead.
 	UploadChunkSize int
+
+	// CheckpointInterval when set is the number of successfully transferred
+	// files after which progress is flushed to a checkpoint in the .gd directory.
+	CheckpointInterval int
+	// Resume when set skips the files that a previous and
+	// checkpointed push or pull had already completed.
+	Resume bool
+	// PullQueue when set persists all the changes of a pull to a queue
+	// in the .gd directory up front and records each as it completes.
+	PullQueue bool
+	// PublishManifest when set is the path of the file that the
+	// paths and URLs of published files are saved to.
+	PublishManifest string
+	// Stream when set publishes audio and video with their
+	// direct content links, that support range requests.
+	Stream bool
+	// CreatedTime when set is the created date given to newly uploaded files.
+	CreatedTime time.Time
+	// UploadAsCopy when set uploads changes to remote files that are
+	// shared with others as new files instead of updating them.
+	UploadAsCopy bool
+	// SkipExisting when set only pushes the files that don't exist
+	// remotely, those that do are skipped without being compared.
+	SkipExisting bool
+	// PreserveMode when set records the permission bits of files
+	// on push and restores them on pull e.g to keep scripts executable.
+	PreserveMode bool
+	// MetadataOnly when set makes pull index the remote files
+	// in the context's metadata index instead of downloading them.
+	MetadataOnly bool
+	// Restrictions when set are applied to shared and published files.
+	Restrictions *Restrictions
+	// ExcludeGoogleDocs when set skips all the Google-native files on pull.
+	ExcludeGoogleDocs bool
+	// OwnedOnly when set skips the remote files that aren't owned
+	// by the user, such as those merely shared with them.
+	OwnedOnly bool
+	// NoEmptyDirs when set doesn't create the remote folders
+	// that have no files to be pulled into them locally.
+	NoEmptyDirs bool
+	// Archive when set is the .tar.gz, .tar or .zip file that pull writes
+	// the remote files into, in place of the files of the local tree.
+	Archive string
+	// Activity when set makes stat show the recent edits of
+	// each file, as recorded by the Drive Activity API.
+	Activity bool
+	// FlattenSingleChild when set pulls chains of folders that each contain
+	// exactly one folder and nothing else as one folder named after the chain.
+	FlattenSingleChild bool
+	// CommentMessage is the content of the comment to add to files.
+	CommentMessage string
+	// IncludeDeletedComments when set also lists the deleted comments and replies.
+	IncludeDeletedComments bool
+	// CopyComments when set re-creates the comments of copied files on their copies.
+	CopyComments bool
+	// LocalChecksumAlgo when set is the algorithm that the checksums of pulled
+	// files are computed with and recorded in a manifest in the .gd directory.
+	LocalChecksumAlgo string
+	// ResumableStateTTL is the age after which checkpoints are discarded
+	// instead of resumed, DefaultResumableStateTTL if it is not set.
+	ResumableStateTTL time.Duration
+	// PlanPath when set is the file that the changes of a push or pull
+	// are written to as a JSON plan for review, instead of applying them.
+	PlanPath string
+	// ApplyPlanPath when set is the file of a plan written by PlanPath, the
+	// push or pull makes exactly its changes without computing them again.
+	ApplyPlanPath string
+	// RemoteHashOnly when set trusts the checksums that were recorded for local
+	// files whose size and modification time haven't changed since, instead of
+	// hashing them again, when checksums are compared.
+	RemoteHashOnly bool
+	// WaitForLock when set waits for another operation on the context to
+	// release its lock, instead of failing right away.
+	WaitForLock bool
+	// ProgressJSON when set is the file, or fd:<n> for a file descriptor, that
+	// progress events of pushes and pulls are periodically written to as JSON.
+	ProgressJSON string
+	// RenameRules are applied to remote titles to get their local names on
+	// pull, before the default rules for characters illegal in local names.
+	RenameRules []RenameRule
+	// SlashReplacement replaces the "/" in remote titles
+	// on pull, it defaults to DefaultSlashReplacement.
+	SlashReplacement string
+	// BandwidthSchedule limits the rate of uploads and
+	// downloads depending on the time of the day.
+	BandwidthSchedule []BandwidthRule
+	// DiffRevision when set is the id of the remote revision
+	// that local files are diffed against.
+	DiffRevision string
+	// DiffReport when set is the path of a CSV file to write the
+	// comparison of each file to instead of printing the differences.
+	DiffReport string
+	// ContinueOnError when set records the failures of individual files
+	// and carries on with the rest, failing with their count at the end.
+	ContinueOnError bool
+	// SummaryOnly when set suppresses the per-file lines of pushes and
+	// pulls and instead prints a report of their totals at the end.
+	SummaryOnly bool
+	// DryRun when set only reports what would be done.
+	DryRun bool
+	// AuthPort is the loopback port that the authorization flow of Init
+	// receives its redirect on, any free port is picked if it is 0 or taken.
+	AuthPort int
+	// AuthBindAddr is the address that the server receiving the redirect
+	// binds to and advertises, the loopback interface if it is empty.
+	AuthBindAddr string
+	// AuthManual when set makes Init print the authorization URL and
+	// read the pasted code instead, for machines without a browser.
+	AuthManual bool
+	// AuthActivity when set makes Init also ask for access to the
+	// Drive Activity API, which stat -activity queries.
+	AuthActivity bool
+	// Impersonate when set is the email of the user that service account
+	// credentials act as, which requires domain-wide delegation.
+	Impersonate string
+	// VerifyDeep when set makes Verify download the content of
+	// remote files to check it against their stored md5 checksums.
+	VerifyDeep bool
+	// OlderThan is the cutoff before which files were
+	// last modified to be trashed by TrashOlderThan.
+	OlderThan time.Time
+	// RepointShortcuts when set makes deduplication repoint the
+	// shortcuts to the trashed duplicates to the kept files.
+	RepointShortcuts bool
+	// DuplicatesByContent and DuplicatesByName select whether Duplicates
+	// groups files by their md5 checksums, their titles or both.
+	DuplicatesByContent bool
+	DuplicatesByName    bool
+
+	// OrderBy contains the keys by which listed items are ordered,
+	// the first key being the primary ordering.
+	OrderBy []string
+	// Reverse when set reverses the ordering requested by OrderBy.
+	Reverse bool
+	// ListFormat is the text/template with which each listed item is printed.
+	ListFormat string
+	// ListJSON when set prints the listed items as a JSON array.
+	ListJSON bool
+	// ChunkedList when set prints listed items page by page as they
+	// arrive, in the order that the server returns them.
+	ChunkedList bool
+	// FindQuery holds the predicates that Find searches with.
+	FindQuery *FindQuery
+
+	// MaxInflightBytes when set caps the sum of the sizes
+	// of the files that are concurrently being pushed.
+	MaxInflightBytes int64
+
+	// IgnoreCase when set makes path resolution and the
+	// comparison of local and remote names case-insensitive.
+	IgnoreCase bool
+	// UnicodeNormalization is the Unicode form, one of UnicodeNFC, UnicodeNFD or
+	// UnicodeNone, that local and remote names are compared in, NFC if not set.
+	UnicodeNormalization string
+
+	// PruneDepth when set to n > 0 bounds deletions to those at most
+	// n levels below the path being synced, deeper ones are only reported.
+	PruneDepth int
+	// NoRecursive when set only handles the direct children of the folders
+	// pushed or pulled, unlike a Recursive of false that only handles
+	// the folders themselves.
+	NoRecursive bool
+	// ConcurrentList when set to n > 0 lists the remote children of up
+	// to n folders at once while resolving changes or listing recursively.
+	ConcurrentList int
+	// MaxQPS when set to n > 0 limits the requests sent to
+	// the API to n per second, across all goroutines.
+	MaxQPS float64
+	// PinRevision when set pins the new revisions of the files that a
+	// push updates, so that they are kept forever instead of purged.
+	PinRevision bool
+
+	// Properties are custom key/value properties to
+	// set on the files that get pushed.
+	Properties map[string]string
+	// IndexableText is the text that Drive indexes pushed files by for
+	// search, for files like images whose content can't be indexed.
+	IndexableText string
+
+	// FollowShortcuts when set makes a pull download the
+	// content of the files that shortcuts point to.
+	FollowShortcuts bool
+
+	// RenameOnCollision when set makes a pull rename incoming files
+	// instead of overwriting local files that aren't tracked as them.
+	RenameOnCollision bool
+
+	// Since when non-zero makes a diff only compare the files
+	// that were modified either locally or remotely after it.
+	Since time.Time
+
+	// TempDir is the directory in which downloads are staged before being
+	// moved into place. If not set, the system's temp directory is used.
+	TempDir string
+
+	// Atomic when set makes pulls download to a staging file that is
+	// only renamed into place once fully received and verified.
+	Atomic bool
+	// ChecksumMismatchRetries is the number of times that a pulled file whose
+	// content doesn't match its md5 checksum is downloaded again.
+	ChecksumMismatchRetries int
+
+	// MinFileSize and MaxFileSize when set bound the sizes in
+	// bytes of the files that get pushed or pulled.
+	MinFileSize int64
+	MaxFileSize int64
+
+	// ParentId when set is the id of the folder that pushed
+	// files are inserted into instead of resolving it by path.
+	ParentId string
+
+	// ApplyRemoteDeletes when set makes pulls delete the local
+	// copies of paths that were trashed remotely since last pulled.
+	ApplyRemoteDeletes bool
+
+	// PromptAll when set lets the user deselect changes from a
+	// numbered listing of them before confirming a push or pull.
+	PromptAll bool
+
+	// Mirror when set makes a push or pull leave its destination an exact
+	// copy of its source, except for the ignored paths, by overwriting
+	// conflicting content and applying deletions at any depth.
+	Mirror bool
+
+	// Debounce is how long watching waits for local
+	// changes to settle before pushing them.
+	Debounce time.Duration
+
+	// PollInterval is how often the changes feed is
+	// queried when polling for remote changes to pull.
+	PollInterval time.Duration
+	// SinceChangeId when set is the id of the change to stream
+	// changes from instead of the one stored in the .gd directory.
+	SinceChangeId int64
+	// Follow when set keeps polling for changes to stream.
+	Follow bool
+
+	// RenameFolder is the new name of the folder being moved.
+	RenameFolder string
+
+	// Compress when set gzips uploads that aren't already compressed.
+	Compress bool
+
+	// Decompress when set gunzips downloads that were compressed on push.
+	Decompress bool
 }
 
 func (opts *Options) CryptoEnabled() bool {
@@ -128,7 +396,71 @@ type Commands struct {
 	log     *log.Logger
 
 	progress      *pb.ProgressBar
+	progressJSON  *progressJSONReporter
+	hashSnapshot  *hashSnapshot
 	mkdirAllCache *expirableCache.OperationCache
+
+	// skippedNatives are the Google-native files that
+	// couldn't be downloaded during a pull.
+	skippedNatives pathMap
+	// failures are the files that failed while ContinueOnError was set.
+	failures pathMap
+	// renamedTitles are the original titles of the remote
+	// files that were pulled under their local names.
+	renamedTitles pathMap
+	// localChecksums are the checksums of the files pulled, by path.
+	localChecksums pathMap
+	titles         titleSidecar
+	flattened      flattenSidecar
+	summary        transferSummary
+	// uploadLimitHit is set once an upload hits the daily limit.
+	uploadLimitHit int32
+	undo           undoLog
+	listSlots      listSlots
+}
+
+// resetRun clears what was recorded by the last push or pull, for
+// another one to be run by the same Commands and report only its own.
+func (c *Commands) resetRun() {
+	c.summary.reset()
+	c.skippedNatives.reset()
+	c.failures.reset()
+	c.renamedTitles.reset()
+	c.localChecksums.reset()
+
+	c.undo.Lock()
+	c.undo.op = ""
+	c.undo.Unlock()
+}
+
+// continueOnError records the failure of relToRootPath and reports
+// whether the operation should carry on with the remaining files.
+func (c *Commands) continueOnError(relToRootPath string, err error) bool {
+	if c.opts == nil || !c.opts.ContinueOnError || err == nil {
+		return false
+	}
+	c.failures.add(relToRootPath, err.Error())
+	return true
+}
+
+// failuresErr returns the aggregate of the recorded failures if
+// ContinueOnError was set, otherwise it returns err unchanged.
+func (c *Commands) failuresErr(err error) error {
+	if c.opts == nil || !c.opts.ContinueOnError {
+		return err
+	}
+
+	paths, reasons := c.failures.sorted()
+	if len(paths) < 1 {
+		return err
+	}
+
+	var messages []string
+	for _, p := range paths {
+		messages = append(messages, fmt.Sprintf("%s: %s", p, reasons[p]))
+	}
+	messages = append(messages, fmt.Sprintf("%d file(s) failed", len(paths)))
+	return copyErrStatusCode(reComposeError(nil, messages...), err)
 }
 
 func (opts *Options) canPrompt() bool {
@@ -148,6 +480,51 @@ func (c *Commands) DebugPrintf(fmt_ string, args ...interface{}) {
 	FDebugPrintf(c.log, fmt_, args...)
 }
 
+// mirror applies the options implied by opts.Mirror, failing
+// if they contradict any options that were explicitly set.
+func (opts *Options) mirror() error {
+	if opts == nil || !opts.Mirror {
+		return nil
+	}
+
+	if opts.NoClobber {
+		return invalidArgumentsErr(fmt.Errorf("cannot use both `%s` and `%s`", CLIOptionMirror, CLIOptionNoClobber))
+	}
+	if opts.PruneDepth > 0 {
+		return invalidArgumentsErr(fmt.Errorf("cannot use both `%s` and `%s`", CLIOptionMirror, CLIOptionPruneDepth))
+	}
+	if (opts.ExcludeCrudMask & Delete) != 0 {
+		return invalidArgumentsErr(fmt.Errorf("`%s` needs deletions yet they are excluded", CLIOptionMirror))
+	}
+
+	opts.Recursive = true
+	opts.Depth = InfiniteDepth
+	opts.IgnoreConflict = true
+	return nil
+}
+
+// DirectChildrenDepth is the traversal depth that covers a
+// folder and its direct children, but none of their descendants.
+const DirectChildrenDepth = 2
+
+// noRecursive applies opts.NoRecursive, a depth that was explicitly
+// set to less than that of direct children is kept as is.
+func (opts *Options) noRecursive() error {
+	if opts == nil || !opts.NoRecursive {
+		return nil
+	}
+
+	if opts.Mirror {
+		return invalidArgumentsErr(fmt.Errorf("cannot use both `%s` and `%s`", CLIOptionMirror, CLIOptionNoRecursive))
+	}
+
+	opts.Recursive = true
+	if opts.Depth < 0 || opts.Depth > DirectChildrenDepth {
+		opts.Depth = DirectChildrenDepth
+	}
+	return nil
+}
+
 func (opts *Options) canPreview() bool {
 	if opts == nil || !opts.StdoutIsTty {
 		return false
@@ -198,7 +575,11 @@ func New(context *config.Context, opts *Options) *Commands {
 	var err error
 
 	if context.GSAJWTConfig != nil {
-		rem, err = NewRemoteContextFromServiceAccount(context.GSAJWTConfig)
+		subject := ""
+		if opts != nil {
+			subject = opts.Impersonate
+		}
+		rem, err = NewRemoteContextFromServiceAccount(impersonating(context.GSAJWTConfig, subject), context)
 	} else {
 		rem, err = NewRemoteContext(context)
 	}
@@ -243,13 +624,78 @@ func New(context *config.Context, opts *Options) *Commands {
 		}
 	}
 
+	rem.chunks.logf = logger.LogErrf
+
+	var slots listSlots
+	if opts != nil {
+		rem.ignoreCase = opts.IgnoreCase
+		rem.normalize = unicodeNormalizer(opts.UnicodeNormalization)
+		slots = newListSlots(opts.ConcurrentList)
+		rem.limitQPS(opts.MaxQPS)
+	}
+
 	return &Commands{
 		context:       context,
 		rem:           rem,
 		opts:          opts,
 		log:           logger,
 		mkdirAllCache: expirableCache.New(),
+		listSlots:     slots,
+	}
+}
+
+// RootAtRemoteRoot makes paths resolve relative to the remote folder at root
+// instead of the root of the Drive. root is either a path from the root of
+// the Drive or the id of a folder.
+func (g *Commands) RootAtRemoteRoot(root string) error {
+	root = strings.TrimSpace(root)
+	if rootLike(root) {
+		return nil
+	}
+
+	folder, err := g.findRemoteRoot(root)
+	if err != nil {
+		return err
+	}
+
+	g.rem.rootFolderId = folder.Id
+	return nil
+}
+
+// AdoptRemoteRoot maps the context to the existing remote folder at root,
+// pinning it by id so that it keeps mapping there even if it is moved.
+func (g *Commands) AdoptRemoteRoot(root string) error {
+	folder, err := g.findRemoteRoot(strings.TrimSpace(root))
+	if err != nil {
+		return err
+	}
+
+	g.context.RemoteRoot = folder.Id
+	if err := g.context.Write(); err != nil {
+		return err
+	}
+
+	g.log.Logf("%s is now mapped to the remote folder %s (%s), run `drive %s` to populate it\n",
+		g.context.AbsPathOf(""), root, folder.Id, PullKey)
+	return nil
+}
+
+func (g *Commands) findRemoteRoot(root string) (*File, error) {
+	folder, err := g.rem.FindByPath(path.Join("/", root))
+	if (err != nil || folder == nil) && !strings.Contains(root, "/") {
+		folder, err = g.rem.FindById(root)
+	}
+
+	if err != nil {
+		return nil, remoteLookupErr(fmt.Errorf("remote root %q: %v", root, err))
+	}
+	if folder == nil {
+		return nil, remoteLookupErr(fmt.Errorf("remote root %q: %v", root, ErrPathNotExists))
+	}
+	if !folder.IsDir {
+		return nil, invalidArgumentsErr(fmt.Errorf("remote root %q (%s) is not a folder", root, folder.Name))
 	}
+	return folder, nil
 }
 
 func (g *Commands) taskStart(tasks int64) {
@@ -268,6 +714,7 @@ func (g *Commands) taskAdd(n int64) {
 	if g.progress != nil {
 		g.progress.Add64(n)
 	}
+	g.progressJSON.addOverall(n)
 }
 
 func (g *Commands) taskFinish() {
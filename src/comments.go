@@ -0,0 +1,229 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+const maxCommentsPageSize = 100
+
+func (r *Remote) listComments(fileId string, includeDeleted bool) (comments []*drive.Comment, err error) {
+	req := r.service.Comments.List(fileId).IncludeDeleted(includeDeleted).MaxResults(maxCommentsPageSize)
+	for pageToken := ""; ; {
+		results, err := req.PageToken(pageToken).Do()
+		if err != nil {
+			return comments, err
+		}
+
+		comments = append(comments, results.Items...)
+		if pageToken = results.NextPageToken; pageToken == "" {
+			return comments, nil
+		}
+	}
+}
+
+// listReplies retrieves all the replies to a comment, since
+// the ones that come along with the comment could be cut short.
+func (r *Remote) listReplies(fileId, commentId string, includeDeleted bool) (replies []*drive.CommentReply, err error) {
+	req := r.service.Replies.List(fileId, commentId).IncludeDeleted(includeDeleted).MaxResults(maxCommentsPageSize)
+	for pageToken := ""; ; {
+		results, err := req.PageToken(pageToken).Do()
+		if err != nil {
+			return replies, err
+		}
+
+		replies = append(replies, results.Items...)
+		if pageToken = results.NextPageToken; pageToken == "" {
+			return replies, nil
+		}
+	}
+}
+
+func (r *Remote) addComment(fileId, content string) (*drive.Comment, error) {
+	return r.insertComment(fileId, &drive.Comment{Content: content})
+}
+
+func (r *Remote) insertComment(fileId string, comment *drive.Comment) (*drive.Comment, error) {
+	return r.service.Comments.Insert(fileId, comment).Do()
+}
+
+func (r *Remote) addReply(fileId, commentId string, reply *drive.CommentReply) (*drive.CommentReply, error) {
+	return r.service.Replies.Insert(fileId, commentId, reply).Do()
+}
+
+func commentAuthor(u *drive.User) string {
+	switch {
+	case u == nil:
+		return "unknown"
+	case u.EmailAddress == "":
+		return u.DisplayName
+	case u.DisplayName == "":
+		return u.EmailAddress
+	default:
+		return fmt.Sprintf("%s <%s>", u.DisplayName, u.EmailAddress)
+	}
+}
+
+// commentTime formats an RFC3339 time of the API in local time,
+// leaving it as is if it can't be parsed.
+func commentTime(s string) string {
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return s
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// attributedContent notes the original author and time of a comment or
+// reply in its content, since re-created ones are authored by the user.
+func attributedContent(author *drive.User, createdDate, content string) string {
+	attribution := fmt.Sprintf("[Originally by %s on %s]", commentAuthor(author), commentTime(createdDate))
+	if strings.TrimSpace(content) == "" {
+		return attribution
+	}
+	return attribution + "\n" + content
+}
+
+// indentLines prefixes each line of text with indent.
+func indentLines(text, indent string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	return indent + strings.Join(lines, "\n"+indent)
+}
+
+// Comments lists the comments on the files along with their replies.
+func (g *Commands) Comments(byId bool) error {
+	return g.perCommentedFile(byId, g.listComments)
+}
+
+// AddComment adds the comment in the options to each of the files.
+func (g *Commands) AddComment(byId bool) error {
+	if strings.TrimSpace(g.opts.CommentMessage) == "" {
+		return invalidArgumentsErr(fmt.Errorf("expecting a comment to add"))
+	}
+
+	return g.perCommentedFile(byId, func(key string, f *File) error {
+		comment, err := g.rem.addComment(f.Id, g.opts.CommentMessage)
+		if err != nil {
+			return err
+		}
+		g.log.Logf("Added comment %s to %s\n", comment.CommentId, key)
+		return nil
+	})
+}
+
+func (g *Commands) perCommentedFile(byId bool, fn func(string, *File) error) (err error) {
+	if len(g.opts.Sources) < 1 {
+		return invalidArgumentsErr(fmt.Errorf("expecting at least one file"))
+	}
+
+	kvChan := resolver(g, byId, g.opts.Sources, noopOnFile)
+	for kv := range kvChan {
+		f, ok := kv.value.(*File)
+		if !ok {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", kv.key, kv.value))
+			continue
+		}
+		if f == nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", kv.key, ErrPathNotExists))
+			continue
+		}
+
+		if fErr := fn(kv.key, f); fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", kv.key, fErr))
+		}
+	}
+
+	return err
+}
+
+func (g *Commands) listComments(key string, f *File) error {
+	comments, err := g.rem.listComments(f.Id, g.opts.IncludeDeletedComments)
+	if err != nil {
+		return err
+	}
+
+	g.log.Logf("%s: %d comment(s)\n", key, len(comments))
+	for _, comment := range comments {
+		status := comment.Status
+		if comment.Deleted {
+			status = "deleted"
+		}
+		g.log.Logf("\n  [%s] %s %s (%s)\n", comment.CommentId, commentAuthor(comment.Author), commentTime(comment.CreatedDate), status)
+		g.log.Logln(indentLines(comment.Content, "    "))
+
+		replies := comment.Replies
+		if len(replies) >= 1 {
+			if replies, err = g.rem.listReplies(f.Id, comment.CommentId, g.opts.IncludeDeletedComments); err != nil {
+				return err
+			}
+		}
+
+		for _, reply := range replies {
+			verb := ""
+			if reply.Verb != "" {
+				verb = fmt.Sprintf(" (%s)", reply.Verb)
+			}
+			g.log.Logf("    > %s %s%s\n", commentAuthor(reply.Author), commentTime(reply.CreatedDate), verb)
+			g.log.Logln(indentLines(reply.Content, "      "))
+		}
+	}
+
+	return nil
+}
+
+// copyComments re-creates the comments and replies of src on dest, in their
+// original order. Replies keep their verbs so that resolved comments end up
+// resolved too. Deleted comments and replies are left out.
+func (g *Commands) copyComments(src, dest *File) (copied int, err error) {
+	comments, err := g.rem.listComments(src.Id, false)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, comment := range comments {
+		replies := comment.Replies
+		if len(replies) >= 1 {
+			if replies, err = g.rem.listReplies(src.Id, comment.CommentId, false); err != nil {
+				return copied, err
+			}
+		}
+
+		recreated, err := g.rem.insertComment(dest.Id, &drive.Comment{
+			Content: attributedContent(comment.Author, comment.CreatedDate, comment.Content),
+			Context: comment.Context,
+		})
+		if err != nil {
+			return copied, err
+		}
+		copied += 1
+
+		for _, reply := range replies {
+			_, err := g.rem.addReply(dest.Id, recreated.CommentId, &drive.CommentReply{
+				Content: attributedContent(reply.Author, reply.CreatedDate, reply.Content),
+				Verb:    reply.Verb,
+			})
+			if err != nil {
+				return copied, err
+			}
+		}
+	}
+
+	return copied, nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	GzipExt      = ".gz"
+	GzipMimeType = "application/gzip"
+
+	// The checksum and size of the content before compression are recorded
+	// as custom properties so that compressed remotes can be compared to
+	// their uncompressed local counterparts.
+	UncompressedMd5ChecksumProperty = "driveUncompressedMd5Checksum"
+	UncompressedSizeProperty        = "driveUncompressedSize"
+)
+
+// compressedExts are the extensions of formats that are already
+// compressed and would gain barely anything from gzipping.
+var compressedExts = map[string]bool{
+	"7z": true, "apk": true, "avi": true, "bz2": true, "docx": true,
+	"flac": true, "gif": true, "gz": true, "jar": true, "jpeg": true,
+	"jpg": true, "m4a": true, "m4v": true, "mkv": true, "mov": true,
+	"mp3": true, "mp4": true, "odp": true, "ods": true, "odt": true,
+	"ogg": true, "png": true, "pptx": true, "rar": true, "tgz": true,
+	"webm": true, "webp": true, "xlsx": true, "xz": true, "zip": true,
+}
+
+func compressible(name string) bool {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), "."))
+	return !compressedExts[ext]
+}
+
+func (g *Commands) compressionToggled() bool {
+	return g.opts.Compress || g.opts.Decompress
+}
+
+// gzipReader returns a reader of the gzipped content of r.
+func gzipReader(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+
+	go func() {
+		gzw := gzip.NewWriter(pw)
+		_, err := io.Copy(gzw, r)
+		if cErr := gzw.Close(); err == nil {
+			err = cErr
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr
+}
+
+type gunzipReadCloser struct {
+	*gzip.Reader
+	src io.ReadCloser
+}
+
+func (grc *gunzipReadCloser) Close() error {
+	err := grc.Reader.Close()
+	if sErr := grc.src.Close(); err == nil {
+		err = sErr
+	}
+	return err
+}
+
+// gunzipReader returns a reader of the gunzipped content of rc,
+// closing it also closes rc.
+func gunzipReader(rc io.ReadCloser) (io.ReadCloser, error) {
+	gzr, err := gzip.NewReader(rc)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+	return &gunzipReadCloser{Reader: gzr, src: rc}, nil
+}
+
+func compressionProperties(f *File, properties map[string]string) map[string]string {
+	merged := make(map[string]string)
+	for key, value := range properties {
+		merged[key] = value
+	}
+
+	merged[UncompressedMd5ChecksumProperty] = md5Checksum(f)
+	merged[UncompressedSizeProperty] = fmt.Sprintf("%d", f.Size)
+	return merged
+}
+
+// uncompressedAttrs returns the checksum and size of the
+// content of f from before it was compressed on push.
+func uncompressedAttrs(f *File) (md5Checksum string, size int64, ok bool) {
+	if f == nil || f.IsDir {
+		return "", 0, false
+	}
+
+	var sizeStr string
+	for _, property := range f.Properties {
+		if property == nil {
+			continue
+		}
+		switch property.Key {
+		case UncompressedMd5ChecksumProperty:
+			md5Checksum = property.Value
+		case UncompressedSizeProperty:
+			sizeStr = property.Value
+		}
+	}
+
+	size, err := strconv.ParseInt(sizeStr, 10, 64)
+	if md5Checksum == "" || err != nil {
+		return "", 0, false
+	}
+	return md5Checksum, size, true
+}
+
+func compressedOnRemote(f *File) bool {
+	_, _, ok := uncompressedAttrs(f)
+	return ok
+}
+
+// uncompressedFile returns the view of a remote that was compressed on push as
+// its uncompressed self, otherwise it returns f unchanged.
+func uncompressedFile(f *File) *File {
+	md5Checksum, size, ok := uncompressedAttrs(f)
+	if !ok || !strings.HasSuffix(f.Name, GzipExt) {
+		return f
+	}
+
+	uncompressed := *f
+	uncompressed.Name = strings.TrimSuffix(f.Name, GzipExt)
+	uncompressed.Md5Checksum = md5Checksum
+	uncompressed.Size = size
+	return &uncompressed
+}
+
+func (g *Commands) uncompressedView(pagePair *paginationPair) *paginationPair {
+	filesChan := make(chan *File)
+
+	go func() {
+		defer close(filesChan)
+		for f := range pagePair.filesChan {
+			filesChan <- uncompressedFile(f)
+		}
+	}()
+
+	return &paginationPair{errsChan: pagePair.errsChan, filesChan: filesChan}
+}
+
+// findByPathM looks up relToRoot and if compression is toggled and it doesn't
+// exist, falls back to looking up its compressed counterpart.
+func (g *Commands) findByPathM(relToRoot string) *paginationPair {
+	relToRoot = g.remotePathOf(relToRoot)
+	if !g.compressionToggled() {
+		return g.rem.FindByPathM(relToRoot)
+	}
+
+	if f, err := g.rem.FindByPath(relToRoot); err != ErrPathNotExists {
+		return wrapInPaginationPair(f, err)
+	}
+
+	return g.uncompressedView(g.rem.FindByPathM(relToRoot + GzipExt))
+}
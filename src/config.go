@@ -0,0 +1,162 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const (
+	ConfigSet   = "set"
+	ConfigUnset = "unset"
+	ConfigGet   = "get"
+
+	ConfigMimeKey                = "mime"
+	ConfigMaxIdleConnsKey        = "max-idle-conns"
+	ConfigMaxIdleConnsPerHostKey = "max-idle-conns-per-host"
+	ConfigIdleConnTimeoutKey     = "idle-conn-timeout"
+)
+
+var configUsage = fmt.Sprintf("expecting `%s %s <ext> <mimeType>`, `%s %s <ext>`, `%s %s [ext]` or `%s|%s|%s <setting> [value]` where setting is one of %s, %s, %s",
+	ConfigSet, ConfigMimeKey, ConfigUnset, ConfigMimeKey, ConfigGet, ConfigMimeKey,
+	ConfigSet, ConfigUnset, ConfigGet, ConfigMaxIdleConnsKey, ConfigMaxIdleConnsPerHostKey, ConfigIdleConnTimeoutKey)
+
+// Config edits and prints the settings persisted in the context.
+// Its arguments are taken from the sources e.g `set mime .md text/markdown`.
+func (g *Commands) Config() error {
+	args := g.opts.Sources
+	if len(args) < 2 {
+		return invalidArgumentsErr(fmt.Errorf(configUsage))
+	}
+
+	action, setting, rest := args[0], args[1], args[2:]
+	if setting == ConfigMimeKey {
+		return g.configMime(action, rest)
+	}
+
+	var value *int
+	switch setting {
+	case ConfigMaxIdleConnsKey:
+		value = &g.context.MaxIdleConns
+	case ConfigMaxIdleConnsPerHostKey:
+		value = &g.context.MaxIdleConnsPerHost
+	case ConfigIdleConnTimeoutKey:
+		value = &g.context.IdleConnTimeoutSeconds
+	default:
+		return invalidArgumentsErr(fmt.Errorf(configUsage))
+	}
+
+	return g.configInt(action, setting, value, rest)
+}
+
+// configInt edits or prints the int setting that value points to, 0 meaning unset.
+// The idle connection timeout is set as a duration but kept in seconds.
+func (g *Commands) configInt(action, setting string, value *int, rest []string) error {
+	switch {
+	case action == ConfigSet && len(rest) == 1:
+		var n int64
+		var err error
+		if setting == ConfigIdleConnTimeoutKey {
+			var timeout time.Duration
+			timeout, err = time.ParseDuration(rest[0])
+			n = int64(timeout / time.Second)
+		} else {
+			n, err = strconv.ParseInt(rest[0], 10, 0)
+		}
+		if err != nil || n < 1 {
+			return invalidArgumentsErr(fmt.Errorf("%s: %q is not a positive value", setting, rest[0]))
+		}
+		*value = int(n)
+		return g.context.Write()
+
+	case action == ConfigUnset && len(rest) == 0:
+		*value = 0
+		return g.context.Write()
+
+	case action == ConfigGet && len(rest) == 0:
+		maxIdleConns, maxIdleConnsPerHost, idleConnTimeout := transportSettings(g.context)
+		switch setting {
+		case ConfigMaxIdleConnsKey:
+			g.log.Logf("%s %d\n", setting, maxIdleConns)
+		case ConfigMaxIdleConnsPerHostKey:
+			g.log.Logf("%s %d\n", setting, maxIdleConnsPerHost)
+		case ConfigIdleConnTimeoutKey:
+			g.log.Logf("%s %v\n", setting, idleConnTimeout)
+		}
+		return nil
+	}
+
+	return invalidArgumentsErr(fmt.Errorf(configUsage))
+}
+
+func (g *Commands) configMime(action string, rest []string) error {
+	switch {
+	case action == ConfigSet && len(rest) == 2:
+		ext := config.NormalizeExt(rest[0])
+		if ext == "" || rest[1] == "" {
+			return invalidArgumentsErr(fmt.Errorf(configUsage))
+		}
+		g.context.SetMimeOverride(ext, rest[1])
+		if err := g.context.Write(); err != nil {
+			return err
+		}
+		g.log.Logf("%s %s\n", ext, rest[1])
+		return nil
+
+	case action == ConfigUnset && len(rest) == 1:
+		ext := config.NormalizeExt(rest[0])
+		if !g.context.UnsetMimeOverride(ext) {
+			return invalidArgumentsErr(fmt.Errorf("no mime override for %q", ext))
+		}
+		return g.context.Write()
+
+	case action == ConfigGet && len(rest) <= 1:
+		if len(rest) == 1 {
+			ext := config.NormalizeExt(rest[0])
+			mimeType, ok := g.context.MimeOverride(ext)
+			if !ok {
+				return invalidArgumentsErr(fmt.Errorf("no mime override for %q", ext))
+			}
+			g.log.Logf("%s %s\n", ext, mimeType)
+			return nil
+		}
+
+		exts := make([]string, 0, len(g.context.MimeOverrides))
+		for ext := range g.context.MimeOverrides {
+			exts = append(exts, ext)
+		}
+		sort.Strings(exts)
+		for _, ext := range exts {
+			g.log.Logf("%s %s\n", ext, g.context.MimeOverrides[ext])
+		}
+		return nil
+	}
+
+	return invalidArgumentsErr(fmt.Errorf(configUsage))
+}
+
+// mimeOverride returns the mimeType configured for the extension of name, if any.
+func (g *Commands) mimeOverride(name string) (string, bool) {
+	if g.context == nil {
+		return "", false
+	}
+	return g.context.MimeOverride(filepath.Ext(name))
+}
@@ -117,7 +117,19 @@ func (g *Commands) copy(src *File, destPath string) (*File, error) {
 			parentId = destFile.Id
 			destBase = src.Name
 		}
-		return g.rem.copy(destBase, parentId, src)
+		copied, err := g.rem.copy(destBase, parentId, src)
+		if err != nil || !g.opts.CopyComments {
+			return copied, err
+		}
+
+		commentCount, err := g.copyComments(src, copied)
+		if commentCount >= 1 {
+			g.log.Logf("%s: copied %d comment(s), they are now attributed to you with the original authors noted in their content\n", destPath, commentCount)
+		}
+		if err != nil {
+			return copied, fmt.Errorf("copying comments: %v", err)
+		}
+		return copied, nil
 	}
 
 	destFile, destErr := g.remoteMkdirAll(destPath)
@@ -0,0 +1,243 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// dupGroup is a set of files in the same folder that
+// share both their title and their md5 checksum.
+type dupGroup struct {
+	path  string
+	keep  *File
+	trash []*File
+}
+
+// byNewest orders files from the most recently modified,
+// breaking ties by id for a stable choice.
+type byNewest []*File
+
+func (bn byNewest) Len() int      { return len(bn) }
+func (bn byNewest) Swap(i, j int) { bn[i], bn[j] = bn[j], bn[i] }
+func (bn byNewest) Less(i, j int) bool {
+	if !bn[i].ModTime.Equal(bn[j].ModTime) {
+		return bn[i].ModTime.After(bn[j].ModTime)
+	}
+	return bn[i].Id < bn[j].Id
+}
+
+type dedupeSt struct {
+	groups    []*dupGroup
+	shortcuts map[string]*File
+}
+
+// Dedupe trashes all but the newest of the files within the same
+// folder that have the same title and content.
+func (g *Commands) Dedupe() (err error) {
+	st := &dedupeSt{shortcuts: make(map[string]*File)}
+
+	for _, relToRootPath := range g.opts.Sources {
+		folder, fErr := g.rem.FindByPath(relToRootPath)
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+			continue
+		}
+		if folder == nil || !folder.IsDir {
+			err = reComposeError(err, fmt.Sprintf("%s: is not a folder", relToRootPath))
+			continue
+		}
+
+		if dErr := g.findDuplicates(relToRootPath, folder, g.opts.Depth, st); dErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, dErr))
+		}
+	}
+
+	if len(st.groups) < 1 {
+		g.log.Logln("no duplicates found")
+		return err
+	}
+
+	trashCount := 0
+	for _, group := range st.groups {
+		g.log.Logf("%s: keeping %s (%v)\n", group.path, group.keep.Id, group.keep.ModTime)
+		for _, dup := range group.trash {
+			g.log.Logf("\ttrash %s (%v)\n", dup.Id, dup.ModTime)
+		}
+		trashCount += len(group.trash)
+	}
+
+	g.log.Logf("%d duplicate(s) in %d group(s)\n", trashCount, len(st.groups))
+
+	if g.opts.DryRun {
+		return err
+	}
+
+	if g.opts.canPrompt() {
+		if status := promptForChanges(); !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	// Keep track of what each trashed duplicate was collapsed into
+	keptIds := make(map[string]string)
+	for _, group := range st.groups {
+		for _, dup := range group.trash {
+			if tErr := g.rem.Trash(dup.Id); tErr != nil {
+				err = reComposeError(err, fmt.Sprintf("%s: trashing %s %v", group.path, dup.Id, tErr))
+				continue
+			}
+			g.recordUndo(UndoActionTrash, group.path, dup)
+			g.log.Logf("Trashed %s %s\n", group.path, dup.Id)
+			keptIds[dup.Id] = group.keep.Id
+		}
+	}
+
+	if g.opts.RepointShortcuts {
+		if rErr := g.repointShortcuts(st.shortcuts, keptIds); rErr != nil {
+			err = reComposeError(err, rErr.Error())
+		}
+	}
+
+	return err
+}
+
+func (g *Commands) findDuplicates(relToRootPath string, folder *File, depth int, st *dedupeSt) (err error) {
+	if depth == 0 {
+		return nil
+	}
+
+	clusters := make(map[string][]*File)
+	var discoveryOrder []string
+	var subFolders []*File
+
+	pagePair := g.rem.FindByParentId(folder.Id, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child == nil {
+				continue
+			}
+
+			switch {
+			case child.IsDir:
+				subFolders = append(subFolders, child)
+			case child.isShortcut():
+				st.shortcuts[sepJoin(RemoteSeparator, relToRootPath, child.Name)] = child
+			case child.Md5Checksum != "":
+				key := child.Name + "\x00" + child.Md5Checksum
+				if _, seen := clusters[key]; !seen {
+					discoveryOrder = append(discoveryOrder, key)
+				}
+				clusters[key] = append(clusters[key], child)
+			}
+		}
+	}
+
+	if listErr != nil {
+		return listErr
+	}
+
+	for _, key := range discoveryOrder {
+		cluster := clusters[key]
+		if len(cluster) < 2 {
+			continue
+		}
+
+		// The newest is kept
+		sort.Sort(byNewest(cluster))
+
+		st.groups = append(st.groups, &dupGroup{
+			path:  sepJoin(RemoteSeparator, relToRootPath, cluster[0].Name),
+			keep:  cluster[0],
+			trash: cluster[1:],
+		})
+	}
+
+	decrementedDepth := decrementTraversalDepth(depth)
+	for _, subFolder := range subFolders {
+		subPath := sepJoin(RemoteSeparator, relToRootPath, subFolder.Name)
+		if fErr := g.findDuplicates(subPath, subFolder, decrementedDepth, st); fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", subPath, fErr))
+		}
+	}
+
+	return err
+}
+
+// repointShortcuts replaces the shortcuts that pointed to trashed duplicates
+// with ones pointing to the kept files. Since the target of a shortcut cannot
+// be changed, a replacement is created in the same folders and the old one is trashed.
+func (g *Commands) repointShortcuts(shortcuts map[string]*File, keptIds map[string]string) (err error) {
+	for relToRootPath, shortcut := range shortcuts {
+		keptId, ok := keptIds[shortcut.ShortcutTargetId]
+		if !ok {
+			continue
+		}
+
+		if _, sErr := g.rem.insertShortcut(shortcut, keptId); sErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: repointing shortcut %v", relToRootPath, sErr))
+			continue
+		}
+
+		if tErr := g.rem.Trash(shortcut.Id); tErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: trashing replaced shortcut %v", relToRootPath, tErr))
+			continue
+		}
+		g.recordUndo(UndoActionTrash, relToRootPath, shortcut)
+
+		g.log.Logf("Repointed shortcut %s to %s\n", relToRootPath, keptId)
+	}
+
+	return err
+}
+
+func (r *Remote) insertShortcut(shortcut *File, targetId string) (*File, error) {
+	f := &drive.File{
+		Title:    shortcut.Name,
+		MimeType: DriveShortcutMimeType,
+		ShortcutDetails: &drive.FileShortcutDetails{
+			TargetId: targetId,
+		},
+	}
+
+	for _, parent := range shortcut.Parents {
+		if parent != nil {
+			f.Parents = append(f.Parents, &drive.ParentReference{Id: parent.Id})
+		}
+	}
+
+	inserted, err := r.service.Files.Insert(f).Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(inserted), nil
+}
@@ -21,7 +21,9 @@ import (
 	"math/rand"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // MaxFileSize is the max number of bytes we
@@ -33,6 +35,10 @@ var Ruler = strings.Repeat("*", 4)
 const (
 	DiffNone = 1 << iota
 	DiffUnified
+	// DiffLocalOnly lists the files that only exist locally.
+	DiffLocalOnly
+	// DiffRemoteOnly lists the files that only exist remotely.
+	DiffRemoteOnly
 )
 
 type diffSt struct {
@@ -53,6 +59,65 @@ func (d diffSt) unified() bool {
 	return (d.mask & DiffUnified) != 0
 }
 
+var sinceUnits = map[string]time.Duration{
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// ParseSince parses a cutoff that is either an RFC3339 time or a time
+// relative to now such as 7d, 2w or any duration understood by time.ParseDuration.
+func ParseSince(since string, now time.Time) (time.Time, error) {
+	since = strings.TrimSpace(since)
+	if t, err := time.Parse(time.RFC3339, since); err == nil {
+		return t, nil
+	}
+
+	if d, ok := parseRelativeDuration(since); ok {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, invalidArgumentsErr(fmt.Errorf("since: %q is neither an RFC3339 time nor a relative time like 7d", since))
+}
+
+// ParseAge parses a non-negative duration such as 7d, 2w
+// or any duration understood by time.ParseDuration.
+func ParseAge(age string) (time.Duration, error) {
+	if d, ok := parseRelativeDuration(strings.TrimSpace(age)); ok {
+		return d, nil
+	}
+	return 0, invalidArgumentsErr(fmt.Errorf("%q is not a duration like 7d or 36h", age))
+}
+
+func parseRelativeDuration(s string) (time.Duration, bool) {
+	if n := len(s); n >= 2 {
+		if unit, ok := sinceUnits[s[n-1:]]; ok {
+			count, err := strconv.ParseFloat(s[:n-1], 64)
+			if err == nil && count >= 0 {
+				return time.Duration(count * float64(unit)), true
+			}
+		}
+	}
+
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return d, true
+	}
+	return 0, false
+}
+
+func modifiedSince(c *Change, since time.Time) bool {
+	if since.IsZero() {
+		return true
+	}
+
+	for _, f := range []*File{c.Src, c.Dest} {
+		if f != nil && f.ModTime.After(since) {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (g *Commands) Diff() (err error) {
 	var cl []*Change
 
@@ -90,6 +155,15 @@ func (g *Commands) Diff() (err error) {
 
 	spin.stop()
 
+	if g.opts.DiffReport != "" {
+		return g.writeDiffReport(g.opts.DiffReport, cl)
+	}
+
+	if onlyMask := g.opts.TypeMask & (DiffLocalOnly | DiffRemoteOnly); onlyMask != 0 {
+		g.listOneSided(cl, onlyMask)
+		return
+	}
+
 	var diffUtilPath string
 	diffUtilPath, err = exec.LookPath("diff")
 	if err != nil {
@@ -111,6 +185,10 @@ func (g *Commands) Diff() (err error) {
 	}
 
 	for _, c := range cl {
+		if !modifiedSince(c, g.opts.Since) {
+			continue
+		}
+
 		dst.change = c
 		dErr := g.perDiff(dst)
 		if dErr != nil {
@@ -120,6 +198,58 @@ func (g *Commands) Diff() (err error) {
 	return
 }
 
+// listOneSided prints the files that only exist on the sides requested by mask.
+func (g *Commands) listOneSided(cl []*Change, mask int) {
+	var localOnly, remoteOnly []string
+	for _, c := range cl {
+		if c == nil || !modifiedSince(c, g.opts.Since) {
+			continue
+		}
+
+		// Diff is resolved as a push, so Src is local and Dest is remote
+		switch {
+		case c.Src != nil && c.Dest == nil:
+			localOnly = append(localOnly, c.Path)
+		case c.Src == nil && c.Dest != nil:
+			remoteOnly = append(remoteOnly, c.Path)
+		}
+	}
+
+	if (mask & DiffLocalOnly) != 0 {
+		g.log.Logf("Only on local (%d):\n", len(localOnly))
+		for _, p := range localOnly {
+			g.log.Logf("\t\033[92m+\033[00m %s\n", p)
+		}
+	}
+
+	if (mask & DiffRemoteOnly) != 0 {
+		g.log.Logf("Only on remote (%d):\n", len(remoteOnly))
+		for _, p := range remoteOnly {
+			g.log.Logf("\t\033[91m-\033[00m %s\n", p)
+		}
+	}
+}
+
+// atRevision returns the view of r as of its revision revisionId
+// along with the URL from which that revision's content is downloaded.
+func (g *Commands) atRevision(r *File, revisionId string) (*File, string, error) {
+	rev, err := g.rem.revision(r.Id, revisionId)
+	if err != nil {
+		return nil, "", err
+	}
+	if rev.DownloadUrl == "" {
+		return nil, "", illogicalStateErr(fmt.Errorf("%s: revision %s has no downloadable content", r.Name, revisionId))
+	}
+
+	revised := *r
+	revised.Md5Checksum = rev.Md5Checksum
+	revised.Size = rev.FileSize
+	if modTime := parseTimeAndRound(rev.ModifiedDate); !modTime.IsZero() {
+		revised.ModTime = modTime
+	}
+	return &revised, rev.DownloadUrl, nil
+}
+
 func (g *Commands) perDiff(dSt diffSt) (err error) {
 	change := dSt.change
 	diffProgPath, cwd := dSt.diffProgPath, dSt.cwd
@@ -149,6 +279,15 @@ func (g *Commands) perDiff(dSt diffSt) (err error) {
 		return illogicalStateErr(fmt.Errorf("Local is a directory while remote is an ordinary file"))
 	}
 
+	// When diffing against a past revision, its content stands in for the remote's
+	var revisionURL string
+	if g.opts.DiffRevision != "" {
+		var rErr error
+		if r, revisionURL, rErr = g.atRevision(r, g.opts.DiffRevision); rErr != nil {
+			return rErr
+		}
+	}
+
 	mask := fileDifferences(r, l, g.opts.IgnoreChecksum)
 	if mask == DifferNone {
 		// No output when "no changes found"
@@ -162,8 +301,12 @@ func (g *Commands) perDiff(dSt diffSt) (err error) {
 	g.log.Logf("%s: %s\n", typeName, change.Path)
 
 	if modTimeDiffers(mask) {
+		remoteLabel := "remote:"
+		if g.opts.DiffRevision != "" {
+			remoteLabel = fmt.Sprintf("revision %s:", g.opts.DiffRevision)
+		}
 		g.log.Logf("* %-15s %-40s\n* %-15s %-40s\n",
-			"local:", toUTCString(l.ModTime), "remote:", toUTCString(r.ModTime))
+			"local:", toUTCString(l.ModTime), remoteLabel, toUTCString(r.ModTime))
 
 		if mask == DifferModTime { // No further change
 			return
@@ -215,7 +358,7 @@ func (g *Commands) perDiff(dSt diffSt) (err error) {
 		}
 	}()
 
-	blob, err = g.rem.Download(r.Id, "")
+	blob, err = g.rem.Download(r.Id, revisionURL)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,122 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	DiffStatusSame        = "same"
+	DiffStatusLocalNewer  = "local-newer"
+	DiffStatusRemoteNewer = "remote-newer"
+	DiffStatusLocalOnly   = "local-only"
+	DiffStatusRemoteOnly  = "remote-only"
+	DiffStatusDiffers     = "differs"
+)
+
+var diffReportHeader = []string{
+	"path", "status", "local size", "remote size", "local mtime", "remote mtime", "md5 match",
+}
+
+// diffStatus classifies how the local and remote sides of a file compare.
+func diffStatus(l, r *File, ignoreChecksum bool) string {
+	switch {
+	case l != nil && r == nil:
+		return DiffStatusLocalOnly
+	case l == nil && r != nil:
+		return DiffStatusRemoteOnly
+	}
+
+	mask := fileDifferences(l, r, ignoreChecksum)
+	switch {
+	case mask == DifferNone:
+		return DiffStatusSame
+	case !modTimeDiffers(mask):
+		return DiffStatusDiffers
+	case l.ModTime.After(r.ModTime):
+		return DiffStatusLocalNewer
+	default:
+		return DiffStatusRemoteNewer
+	}
+}
+
+// md5Match reports whether the checksums of both sides match, or ""
+// if that is unknown e.g for folders or files only on one side.
+func md5Match(l, r *File) string {
+	if l == nil || r == nil || l.IsDir || r.IsDir || r.Md5Checksum == "" {
+		return ""
+	}
+	return strconv.FormatBool(md5Checksum(l) == md5Checksum(r))
+}
+
+func diffReportRow(c *Change, ignoreChecksum bool) []string {
+	// Diff is resolved as a push, so Src is local and Dest is remote
+	l, r := c.Src, c.Dest
+
+	sizeOf := func(f *File) string {
+		if f == nil || f.IsDir {
+			return ""
+		}
+		return strconv.FormatInt(f.Size, 10)
+	}
+	mtimeOf := func(f *File) string {
+		if f == nil {
+			return ""
+		}
+		return f.ModTime.UTC().Format(time.RFC3339)
+	}
+
+	return []string{
+		c.Path, diffStatus(l, r, ignoreChecksum),
+		sizeOf(l), sizeOf(r), mtimeOf(l), mtimeOf(r), md5Match(l, r),
+	}
+}
+
+// writeDiffReport saves a row per change to reportPath as CSV.
+func (g *Commands) writeDiffReport(reportPath string, cl []*Change) error {
+	f, err := os.Create(reportPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	w.Write(diffReportHeader)
+
+	rows := 0
+	for _, c := range cl {
+		if c == nil || !modifiedSince(c, g.opts.Since) {
+			continue
+		}
+		if c.Src != nil && c.Dest != nil && c.Src.IsDir && c.Dest.IsDir {
+			// Comparing folders is spurious, see perDiff
+			continue
+		}
+		w.Write(diffReportRow(c, g.opts.IgnoreChecksum))
+		rows += 1
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	g.log.Logf("wrote %d row(s) to %s\n", rows, reportPath)
+	return nil
+}
@@ -0,0 +1,190 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	doctorProbeURL = "https://www.googleapis.com/discovery/v1/apis/drive/v2/rest"
+
+	// maxClockSkew is how far off the local clock can be before
+	// the tokens that it stamps risk being rejected as expired.
+	maxClockSkew = time.Minute
+
+	doctorTimeout = 15 * time.Second
+)
+
+type doctorCheck struct {
+	name string
+	hint string
+	run  func() (string, error)
+}
+
+// Doctor runs a series of checks of the setup that commands rely on,
+// reporting each as PASS or FAIL along with a hint on how to remedy it.
+func (g *Commands) Doctor() error {
+	var serverTime time.Time
+
+	checks := []doctorCheck{
+		{
+			name: "proxy",
+			hint: "fix or unset the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables",
+			run:  doctorProxy,
+		},
+		{
+			name: "network",
+			hint: "check your internet connection and that no firewall or proxy blocks www.googleapis.com",
+			run: func() (detail string, err error) {
+				detail, serverTime, err = doctorNetwork()
+				return detail, err
+			},
+		},
+		{
+			name: "clock",
+			hint: "synchronize your clock e.g with NTP, tokens are rejected if it is off",
+			run: func() (string, error) {
+				return doctorClockSkew(serverTime, time.Now())
+			},
+		},
+		{
+			name: "credentials",
+			hint: fmt.Sprintf("run `drive %s` to set up or refresh your credentials", InitKey),
+			run:  g.doctorCredentials,
+		},
+		{
+			name: "state dir",
+			hint: "make sure that you own the directory and it is not on a read-only file system",
+			run: func() (string, error) {
+				return doctorWritable(g.context.GDPath())
+			},
+		},
+		{
+			name: "context dir",
+			hint: "make sure that you own the directory and it is not on a read-only file system",
+			run: func() (string, error) {
+				return doctorWritable(g.context.AbsPathOf(""))
+			},
+		},
+	}
+
+	failures := 0
+	for _, check := range checks {
+		detail, err := check.run()
+		if err != nil {
+			failures += 1
+			g.log.Logf("FAIL %-12s %v\n     %-12s hint: %s\n", check.name, err, "", check.hint)
+			continue
+		}
+		g.log.Logf("PASS %-12s %s\n", check.name, detail)
+	}
+
+	if failures >= 1 {
+		return illogicalStateErr(fmt.Errorf("%d of %d checks failed", failures, len(checks)))
+	}
+	return nil
+}
+
+func doctorProxy() (string, error) {
+	req, err := http.NewRequest("HEAD", doctorProbeURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	proxyURL, err := http.ProxyFromEnvironment(req)
+	if err != nil {
+		return "", err
+	}
+	if proxyURL == nil {
+		return "none configured", nil
+	}
+	return fmt.Sprintf("via %s", proxyURL.Host), nil
+}
+
+// doctorNetwork checks that the API can be reached and
+// returns the time that the server reported in its reply.
+func doctorNetwork() (string, time.Time, error) {
+	client := &http.Client{Timeout: doctorTimeout}
+
+	start := time.Now()
+	res, err := client.Head(doctorProbeURL)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	res.Body.Close()
+	elapsed := time.Since(start)
+
+	serverTime, _ := http.ParseTime(res.Header.Get("Date"))
+	elapsed -= elapsed % time.Millisecond
+	return fmt.Sprintf("reached %s in %v", res.Request.URL.Host, elapsed), serverTime, nil
+}
+
+func doctorClockSkew(serverTime, now time.Time) (string, error) {
+	if serverTime.IsZero() {
+		return "", fmt.Errorf("could not get the time from the server")
+	}
+
+	skew := now.Sub(serverTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	// The Date header only has a precision of seconds
+	skew -= skew % time.Second
+
+	if skew > maxClockSkew {
+		return "", fmt.Errorf("local clock is off by %v", skew)
+	}
+	return fmt.Sprintf("off by %v", skew), nil
+}
+
+// doctorCredentials checks that credentials were set up
+// and that they are accepted, with a cheap about.get.
+func (g *Commands) doctorCredentials() (string, error) {
+	kind := "refresh token"
+	switch {
+	case g.context.GSAJWTConfig != nil:
+		kind = fmt.Sprintf("service account %s", g.context.GSAJWTConfig.Email)
+	case g.context.RefreshToken == "":
+		return "", fmt.Errorf("none found")
+	}
+
+	about, err := g.rem.About()
+	if err != nil {
+		return "", fmt.Errorf("%s was rejected: %v", kind, err)
+	}
+
+	if about.User != nil && about.User.EmailAddress != "" {
+		return fmt.Sprintf("%s valid for %s", kind, about.User.EmailAddress), nil
+	}
+	return fmt.Sprintf("%s valid", kind), nil
+}
+
+func doctorWritable(dir string) (string, error) {
+	f, err := ioutil.TempFile(dir, ".drive-doctor")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+
+	if err := os.Remove(f.Name()); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s is writable", dir), nil
+}
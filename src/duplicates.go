@@ -0,0 +1,197 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"path"
+)
+
+// dupMember is a file found while scanning for duplicates.
+type dupMember struct {
+	path string
+	file *File
+}
+
+// duplicateKey returns the key that f gets grouped by, which is its md5
+// checksum by content and its title by name, or both. Files without
+// an md5 checksum, such as Google Docs, have no key by content.
+func duplicateKey(f *File, byContent, byName bool) (string, bool) {
+	switch {
+	case byContent && f.Md5Checksum == "":
+		return "", false
+	case byContent && byName:
+		return f.Name + "\x00" + f.Md5Checksum, true
+	case byName:
+		return f.Name, true
+	default:
+		return f.Md5Checksum, true
+	}
+}
+
+// groupDuplicates returns the groups of at least two members that share a
+// key, in the order that the first member of each group was found.
+func groupDuplicates(members []*dupMember, byContent, byName bool) (groups [][]*dupMember) {
+	clusters := make(map[string][]*dupMember)
+	var discoveryOrder []string
+	for _, member := range members {
+		key, ok := duplicateKey(member.file, byContent, byName)
+		if !ok {
+			continue
+		}
+		if _, seen := clusters[key]; !seen {
+			discoveryOrder = append(discoveryOrder, key)
+		}
+		clusters[key] = append(clusters[key], member)
+	}
+
+	for _, key := range discoveryOrder {
+		if cluster := clusters[key]; len(cluster) >= 2 {
+			groups = append(groups, cluster)
+		}
+	}
+	return groups
+}
+
+// Duplicates reports the groups of files under the sources that have the
+// same content, title or both, without changing anything.
+func (g *Commands) Duplicates() (err error) {
+	byContent, byName := g.opts.DuplicatesByContent, g.opts.DuplicatesByName
+	if !byContent && !byName {
+		byContent = true
+	}
+
+	var members []*dupMember
+	for _, relToRootPath := range g.opts.Sources {
+		folder, fErr := g.rem.FindByPath(relToRootPath)
+		if fErr == nil && folder == nil {
+			fErr = ErrPathNotExists
+		}
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+			continue
+		}
+
+		found, sErr := g.scanForDuplicates(relToRootPath, folder, g.opts.Depth)
+		if sErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, sErr))
+		}
+		members = append(members, found...)
+	}
+
+	groups := groupDuplicates(members, byContent, byName)
+	if len(groups) < 1 {
+		g.log.Logf("no duplicates found among %d file(s)\n", len(members))
+		return err
+	}
+
+	dupCount, redundant := 0, int64(0)
+	for _, group := range groups {
+		first := group[0].file
+		switch {
+		case byContent && byName:
+			g.log.Logf("\n%d files titled %q with md5 %s, %s each:\n", len(group), first.Name, first.Md5Checksum, prettyBytes(first.Size))
+		case byContent:
+			g.log.Logf("\n%d files with md5 %s, %s each:\n", len(group), first.Md5Checksum, prettyBytes(first.Size))
+		default:
+			g.log.Logf("\n%d files titled %q:\n", len(group), first.Name)
+		}
+
+		for _, member := range group {
+			g.log.Logf("  %s %s\n", member.file.Id, member.path)
+		}
+
+		dupCount += len(group) - 1
+		if byContent {
+			redundant += int64(len(group)-1) * first.Size
+		}
+	}
+
+	if byContent {
+		g.log.Logf("\n%d group(s) of duplicates, %d redundant file(s) taking up %s\n", len(groups), dupCount, prettyBytes(redundant))
+	} else {
+		g.log.Logf("\n%d group(s) of duplicates, %d redundant file(s)\n", len(groups), dupCount)
+	}
+	return err
+}
+
+func (g *Commands) scanForDuplicates(relToRootPath string, f *File, depth int) (members []*dupMember, err error) {
+	if anyMatch(g.opts.Ignorer, path.Base(relToRootPath), relToRootPath) {
+		return nil, nil
+	}
+
+	if !f.IsDir {
+		if f.isShortcut() {
+			return nil, nil
+		}
+		return []*dupMember{{path: relToRootPath, file: f}}, nil
+	}
+
+	if depth == 0 {
+		return nil, nil
+	}
+
+	var subFolders []*File
+	pagePair := g.rem.FindByParentId(f.Id, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child == nil {
+				continue
+			}
+
+			childPath := remotePathJoin(relToRootPath, child.Name)
+			if anyMatch(g.opts.Ignorer, child.Name, childPath) {
+				continue
+			}
+
+			switch {
+			case child.IsDir:
+				subFolders = append(subFolders, child)
+			case !child.isShortcut():
+				members = append(members, &dupMember{path: childPath, file: child})
+			}
+		}
+	}
+
+	if listErr != nil {
+		return members, listErr
+	}
+
+	childDepth := decrementTraversalDepth(depth)
+	for _, subFolder := range subFolders {
+		subPath := remotePathJoin(relToRootPath, subFolder.Name)
+		found, sErr := g.scanForDuplicates(subPath, subFolder, childDepth)
+		if sErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", subPath, sErr))
+		}
+		members = append(members, found...)
+	}
+
+	return members, err
+}
@@ -14,6 +14,13 @@
 
 package drive
 
+import (
+	"errors"
+	"net"
+
+	"google.golang.org/api/googleapi"
+)
+
 type ErrorStatus int
 
 const (
@@ -42,6 +49,18 @@ const (
 	StatusContentTooLarge             ErrorStatus = 23
 	StatusClashesFixed                ErrorStatus = 24
 	StatusSecurityException           ErrorStatus = 25
+	StatusVerificationFailed          ErrorStatus = 26
+	StatusContextLocked               ErrorStatus = 27
+	StatusUploadLimitReached          ErrorStatus = 28
+)
+
+// The categories of errors, as returned by Category,
+// that callers might want to react to differently.
+var (
+	ErrAuth     = errors.New("authentication failed")
+	ErrNetwork  = errors.New("network failure")
+	ErrConflict = errors.New("conflict")
+	ErrNotFound = errors.New("not found")
 )
 
 type Error struct {
@@ -65,6 +84,53 @@ func (e Error) Code() int {
 	return int(e.code)
 }
 
+func (e Error) category() error {
+	switch e.code {
+	case StatusAuthenticationFailed, StatusSecurityException:
+		return ErrAuth
+	case StatusRetriesExhausted, StatusNetLookupFailed, StatusRemoteLookupFailed, StatusDownloadFailed:
+		return ErrNetwork
+	case StatusClashesDetected, StatusUnresolvedConflicts, StatusOverwriteAttempted:
+		return ErrConflict
+	case StatusNonExistantRemote, StatusNoMatchesFound:
+		return ErrNotFound
+	}
+
+	// The error of this status might be more descriptive
+	if e.err != nil {
+		return Category(e.err)
+	}
+	return nil
+}
+
+// Category returns the category of err which is one of ErrAuth, ErrNetwork,
+// ErrConflict or ErrNotFound, otherwise nil if err isn't any of those.
+func Category(err error) error {
+	switch e := err.(type) {
+	case *Error:
+		if e != nil {
+			return e.category()
+		}
+	case Error:
+		return e.category()
+	case *googleapi.Error:
+		switch {
+		case e.Code == 401:
+			return ErrAuth
+		case e.Code == 404:
+			return ErrNotFound
+		case e.Code == 409 || e.Code == 412:
+			return ErrConflict
+		case e.Code == 429 || e.Code >= 500:
+			return ErrNetwork
+		}
+	case net.Error:
+		return ErrNetwork
+	}
+
+	return nil
+}
+
 func makeError(err error, code ErrorStatus) *Error {
 	return &Error{
 		code: code,
@@ -157,3 +223,15 @@ func contentTooLargeErr(err error) *Error {
 func clashesFixedErr(err error) *Error {
 	return makeError(err, StatusClashesFixed)
 }
+
+func verificationFailedErr(err error) *Error {
+	return makeError(err, StatusVerificationFailed)
+}
+
+func contextLockedErr(err error) *Error {
+	return makeError(err, StatusContextLocked)
+}
+
+func uploadLimitReachedErr(err error) *Error {
+	return makeError(err, StatusUploadLimitReached)
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+
+	"google.golang.org/api/googleapi"
 )
 
 func TestErrors(t *testing.T) {
@@ -75,3 +77,29 @@ func TestErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestCategory(t *testing.T) {
+	testCases := [...]struct {
+		err  error
+		want error
+	}{
+		0:  {err: nil, want: nil},
+		1:  {err: fmt.Errorf("unknown"), want: nil},
+		2:  {err: ErrPathNotExists, want: ErrNotFound},
+		3:  {err: ErrClashesDetected, want: ErrConflict},
+		4:  {err: ErrNetLookup, want: ErrNetwork},
+		5:  {err: makeError(fmt.Errorf("bad token"), StatusAuthenticationFailed), want: ErrAuth},
+		6:  {err: unresolvedConflictsErr(fmt.Errorf("conflicts")), want: ErrConflict},
+		7:  {err: illogicalStateErr(fmt.Errorf("bug")), want: nil},
+		8:  {err: makeError(&googleapi.Error{Code: 401}, StatusGeneric), want: ErrAuth},
+		9:  {err: &googleapi.Error{Code: 404}, want: ErrNotFound},
+		10: {err: &googleapi.Error{Code: 503}, want: ErrNetwork},
+		11: {err: &googleapi.Error{Code: 403}, want: nil},
+	}
+
+	for i, tc := range testCases {
+		if got := Category(tc.err); got != tc.want {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
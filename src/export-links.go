@@ -0,0 +1,129 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+)
+
+// commonExportFormats are the formats, in order of preference,
+// whose names are used to label the exportLinks of a file.
+var commonExportFormats = []string{
+	"pdf", "docx", "xlsx", "pptx", "odt", "ods", "odp",
+	"rtf", "txt", "html", "csv", "tsv", "jpg", "png", "svg",
+	"zip", "json", "xml",
+}
+
+func exportFormatName(mimeType string) string {
+	for _, format := range commonExportFormats {
+		if mimeTypeFromExt(format) == mimeType {
+			return format
+		}
+	}
+
+	// Otherwise the extension registered for the mimeType, if any
+	if exts, err := mime.ExtensionsByType(mimeType); err == nil && len(exts) >= 1 {
+		return strings.TrimPrefix(exts[0], ".")
+	}
+
+	// Otherwise the mimeType is the most descriptive name
+	return mimeType
+}
+
+// exportLink returns the mimeType and the link of the export in format,
+// which is either one of the known formats or named by exportFormatName.
+func exportLink(exportLinks map[string]string, format string) (mimeType, exportURL string, ok bool) {
+	mimeType = mimeTypeFromExt(format)
+	if exportURL, ok = exportLinks[mimeType]; ok {
+		return mimeType, exportURL, true
+	}
+
+	for mimeType, exportURL := range exportLinks {
+		if exportFormatName(mimeType) == format {
+			return mimeType, exportURL, true
+		}
+	}
+	return "", "", false
+}
+
+func (g *Commands) ExportLinks(byId bool) (err error) {
+	format := ""
+	if g.opts.Meta != nil {
+		formats := (*g.opts.Meta)[ExportFormatKey]
+		if len(formats) >= 1 {
+			format = formats[0]
+		}
+	}
+
+	exportLinker := func(f *File) interface{} {
+		if f == nil {
+			return ErrPathNotExists
+		}
+		if !hasExportLinks(f) {
+			return fmt.Errorf("has no export links, it is not a Google Doc/Sheet/Slide")
+		}
+		return f.ExportLinks
+	}
+
+	kvChan := resolver(g, byId, g.opts.Sources, exportLinker)
+
+	for kv := range kvChan {
+		switch v := kv.value.(type) {
+		case error:
+			g.log.LogErrf("%s: %v\n", kv.key, v)
+			err = reComposeError(err, fmt.Sprintf("%s: %v", kv.key, v))
+		case map[string]string:
+			if fErr := g.printExportLinks(kv.key, v, format); fErr != nil {
+				err = reComposeError(err, fErr.Error())
+			}
+		}
+	}
+
+	return err
+}
+
+func (g *Commands) printExportLinks(key string, exportLinks map[string]string, format string) error {
+	if format != "" {
+		_, exportURL, ok := exportLink(exportLinks, format)
+		if !ok {
+			err := fmt.Errorf("%s: no %q export link", key, format)
+			g.log.LogErrln(err)
+			return err
+		}
+
+		g.log.Logf("%s: %s\n", key, exportURL)
+		return nil
+	}
+
+	named := make(map[string]string)
+	var names []string
+	for mimeType, exportURL := range exportLinks {
+		name := exportFormatName(mimeType)
+		named[name] = exportURL
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	g.log.Logf("%s\n", key)
+	for _, name := range names {
+		g.log.Logf("\t%-8s %s\n", name, named[name])
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// FileModeProperty is the custom property that the permission
+// bits of local files are recorded in on push, as octal.
+const FileModeProperty = "driveFileMode"
+
+// fileModeProperties returns properties along with
+// the permission bits of the local file at absPath.
+func fileModeProperties(absPath string, properties map[string]string) (map[string]string, error) {
+	fi, err := os.Stat(absPath)
+	if err != nil {
+		return properties, err
+	}
+
+	merged := make(map[string]string)
+	for key, value := range properties {
+		merged[key] = value
+	}
+
+	merged[FileModeProperty] = fmt.Sprintf("%04o", fi.Mode().Perm())
+	return merged, nil
+}
+
+// remoteFileMode returns the permission bits recorded on f, if any.
+func remoteFileMode(f *File) (os.FileMode, bool) {
+	if f == nil || f.IsDir {
+		return 0, false
+	}
+
+	for _, property := range f.Properties {
+		if property == nil || property.Key != FileModeProperty {
+			continue
+		}
+		mode, err := strconv.ParseUint(property.Value, 8, 32)
+		if err != nil || mode > uint64(os.ModePerm) {
+			return 0, false
+		}
+		return os.FileMode(mode), true
+	}
+
+	return 0, false
+}
+
+// restoreFileMode applies the permission bits recorded
+// on f on push to the local file at absPath.
+func (g *Commands) restoreFileMode(f *File, absPath string) error {
+	if !g.opts.PreserveMode {
+		return nil
+	}
+
+	mode, ok := remoteFileMode(f)
+	if !ok {
+		return nil
+	}
+	return os.Chmod(absPath, mode)
+}
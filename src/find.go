@@ -0,0 +1,145 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FindQuery holds the predicates of a search, which are compiled
+// into a query of the Drive API and all have to hold.
+type FindQuery struct {
+	// NameContains are the substrings that titles have to contain.
+	NameContains []string
+	// ModifiedAfter when set is the time after which files were last modified.
+	ModifiedAfter time.Time
+	// Owners are the email addresses that files have to be owned by.
+	Owners []string
+	// MimeTypes are the mimeTypes or their short keys e.g pdf, any of which
+	// files have to be of.
+	MimeTypes []string
+	Starred   bool
+	// Trashed when set only finds files in the trash, otherwise
+	// only files that are not in the trash are found.
+	Trashed bool
+	// Raw is a query in the API syntax to combine with the predicates.
+	Raw string
+}
+
+// String compiles the predicates into the query syntax of the Drive API.
+func (fq *FindQuery) String() string {
+	var clauses []string
+	for _, name := range fq.NameContains {
+		clauses = append(clauses, fmt.Sprintf("title contains %s", customQuote(name)))
+	}
+
+	if !fq.ModifiedAfter.IsZero() {
+		clauses = append(clauses, fmt.Sprintf("modifiedDate > %s", customQuote(fq.ModifiedAfter.UTC().Format(time.RFC3339))))
+	}
+
+	for _, owner := range fq.Owners {
+		clauses = append(clauses, fmt.Sprintf("%s in owners", customQuote(owner)))
+	}
+
+	var mimeClauses []string
+	for _, mimeType := range fq.MimeTypes {
+		// Short keys like pdf are resolved, full mimeTypes are taken as is
+		if !strings.Contains(mimeType, "/") {
+			if resolved := mimeTypeFromQuery(mimeType); resolved != "" {
+				mimeType = resolved
+			}
+		}
+		mimeClauses = append(mimeClauses, fmt.Sprintf("mimeType = %s", customQuote(mimeType)))
+	}
+	switch len(mimeClauses) {
+	case 0:
+	case 1:
+		clauses = append(clauses, mimeClauses[0])
+	default:
+		clauses = append(clauses, fmt.Sprintf("(%s)", strings.Join(mimeClauses, " or ")))
+	}
+
+	if fq.Starred {
+		clauses = append(clauses, "starred = true")
+	}
+	clauses = append(clauses, fmt.Sprintf("trashed = %v", fq.Trashed))
+
+	if raw := strings.TrimSpace(fq.Raw); raw != "" {
+		clauses = append(clauses, fmt.Sprintf("(%s)", raw))
+	}
+
+	return strings.Join(clauses, " and ")
+}
+
+func (r *Remote) findByQuery(q string, hidden bool) *paginationPair {
+	req := r.service.Files.List()
+	req.Q(q)
+	return reqDoPage(req, hidden, false)
+}
+
+// Find lists the files anywhere in the Drive that match the predicates.
+func (g *Commands) Find() error {
+	fq := g.opts.FindQuery
+	if fq == nil {
+		fq = &FindQuery{}
+	}
+
+	format, err := parseListFormat(g.opts.ListFormat)
+	if err != nil {
+		return err
+	}
+
+	q := fq.String()
+	if g.opts.Verbose {
+		g.log.Logf("query: %s\n", q)
+	}
+
+	found := 0
+	pagePair := g.rem.findByQuery(q, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	resultsChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case f, stillHasContent := <-resultsChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if f == nil {
+				continue
+			}
+			found += 1
+			f.pretty(g.log, attribute{mask: g.opts.TypeMask, format: format})
+		}
+	}
+
+	if listErr != nil {
+		return listErr
+	}
+
+	if found < 1 {
+		return noMatchesFoundErr(fmt.Errorf("no files match %s", q))
+	}
+	return nil
+}
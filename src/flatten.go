@@ -0,0 +1,223 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// flattenSeparator joins the names of a chain of single child folders.
+const flattenSeparator = "_"
+
+// flattenSidecar holds the remote paths of the chains of single
+// child folders that were pulled as one folder, by local path.
+type flattenSidecar struct {
+	sync.Once
+	sync.Mutex
+	dirs map[string]string
+	// fresh are the local paths of the chains flattened by this run.
+	fresh []string
+}
+
+func (g *Commands) flattenSidecar() *flattenSidecar {
+	g.flattened.Do(func() {
+		dirs, err := g.context.ReadFlattened()
+		if err != nil {
+			g.log.LogErrf("flattened: reading %v\n", err)
+		}
+		if dirs == nil {
+			dirs = make(map[string]string)
+		}
+		g.flattened.dirs = dirs
+	})
+	return &g.flattened
+}
+
+func (fs *flattenSidecar) add(localPath, remotePath string) {
+	fs.Lock()
+	defer fs.Unlock()
+
+	fs.dirs[localPath] = remotePath
+	fs.fresh = append(fs.fresh, localPath)
+}
+
+// unflatten returns the remote path of p, which is under a flattened folder
+// if it starts with any of its local paths, the longest one winning.
+func (fs *flattenSidecar) unflatten(p string) string {
+	fs.Lock()
+	defer fs.Unlock()
+
+	return unflattenPath(p, fs.dirs)
+}
+
+func unflattenPath(p string, dirs map[string]string) string {
+	longest := ""
+	for localPath := range dirs {
+		if len(localPath) > len(longest) && (p == localPath || strings.HasPrefix(p, localPath+"/")) {
+			longest = localPath
+		}
+	}
+
+	if longest == "" {
+		return p
+	}
+	return dirs[longest] + p[len(longest):]
+}
+
+// flattenedHeads maps the names of the remote folders under remoteParent
+// that start flattened chains to the local paths of the chains, for the
+// flattened folders whose local parent is localParent.
+func (fs *flattenSidecar) flattenedHeads(localParent, remoteParent string) map[string]string {
+	fs.Lock()
+	defer fs.Unlock()
+
+	heads := make(map[string]string)
+	prefix := strings.TrimSuffix(remoteParent, "/") + "/"
+	for localPath, remotePath := range fs.dirs {
+		if path.Dir(localPath) != localParent || !strings.HasPrefix(remotePath, prefix) {
+			continue
+		}
+		head := strings.SplitN(strings.TrimPrefix(remotePath, prefix), "/", 2)[0]
+		heads[head] = localPath
+	}
+	return heads
+}
+
+// remotePathOf translates the local relToRootPath to its remote path,
+// the same unless it is within a flattened folder.
+func (g *Commands) remotePathOf(relToRootPath string) string {
+	return g.flattenSidecar().unflatten(relToRootPath)
+}
+
+// singleChildChain follows the folders under f for as long as each contains
+// exactly one folder and nothing else, returning the names along the way
+// and the last folder.
+func (g *Commands) singleChildChain(f *File) (names []string, last *File) {
+	names, last = []string{f.Name}, f
+	for {
+		var children []*File
+		pagePair := g.rem.FindByParentId(last.Id, g.opts.Hidden)
+		errsChan := pagePair.errsChan
+		childrenChan := pagePair.filesChan
+
+		listFailed := false
+		working := true
+		for working {
+			select {
+			case pErr := <-errsChan:
+				if pErr != nil {
+					listFailed = true
+				}
+			case child, stillHasContent := <-childrenChan:
+				if !stillHasContent {
+					working = false
+					break
+				}
+				if child != nil {
+					children = append(children, child)
+				}
+			}
+		}
+
+		if listFailed || len(children) != 1 || !children[0].IsDir {
+			return names, last
+		}
+
+		last = children[0]
+		names = append(names, last.Name)
+	}
+}
+
+// flattenedView presents the flattened chains of single child folders under
+// remoteBase as one folder named after the chain and holding the content of
+// its last folder. Chains that were flattened by earlier pulls are always
+// presented so, on pull new chains are only flattened if asked for.
+func (g *Commands) flattenedView(remoteBase string, pagePair *paginationPair, push bool) *paginationPair {
+	sidecar := g.flattenSidecar()
+	flatten := g.opts.FlattenSingleChild && !push
+
+	remoteParent := sidecar.unflatten(remoteBase)
+	heads := sidecar.flattenedHeads(remoteBase, remoteParent)
+	if len(heads) < 1 && !flatten {
+		return pagePair
+	}
+
+	filesChan := make(chan *File)
+
+	go func() {
+		defer close(filesChan)
+		for f := range pagePair.filesChan {
+			if f == nil || !f.IsDir {
+				filesChan <- f
+				continue
+			}
+
+			if localPath, ok := heads[f.Name]; ok {
+				last, err := g.rem.FindByPath(sidecar.unflatten(localPath))
+				if err == nil && last != nil && last.IsDir {
+					flattened := DupFile(last)
+					flattened.Name = path.Base(localPath)
+					filesChan <- flattened
+					continue
+				}
+				g.log.LogErrf("%s: the folders flattened as %s have changed, leaving them as is\n", remotePathJoin(remoteParent, f.Name), localPath)
+			}
+
+			if !flatten {
+				filesChan <- f
+				continue
+			}
+
+			names, last := g.singleChildChain(f)
+			if len(names) < 2 {
+				filesChan <- f
+				continue
+			}
+
+			flattened := DupFile(last)
+			flattened.Name = strings.Join(names, flattenSeparator)
+			sidecar.add(remotePathJoin(remoteBase, flattened.Name), remotePathJoin(remoteParent, strings.Join(names, "/")))
+			filesChan <- flattened
+		}
+	}()
+
+	return &paginationPair{errsChan: pagePair.errsChan, filesChan: filesChan}
+}
+
+// summarizeFlattened reports the chains of folders that were just flattened
+// and records them for later pulls and pushes to map back to the remote.
+func (g *Commands) summarizeFlattened() {
+	sidecar := g.flattenSidecar()
+	sidecar.Lock()
+	defer sidecar.Unlock()
+
+	if len(sidecar.fresh) < 1 {
+		return
+	}
+
+	sort.Strings(sidecar.fresh)
+	g.log.Logf("\n%d chain(s) of single child folders will be flattened:\n", len(sidecar.fresh))
+	for _, localPath := range sidecar.fresh {
+		g.log.Logf("  %s => %s\n", sidecar.dirs[localPath], localPath)
+	}
+
+	if err := g.context.WriteFlattened(sidecar.dirs); err != nil {
+		g.log.LogErrf("flattened: writing %v\n", err)
+	}
+	sidecar.fresh = nil
+}
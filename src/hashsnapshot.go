@@ -0,0 +1,119 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"path/filepath"
+	"sync"
+
+	"github.com/odeke-em/drive/config"
+)
+
+// hashSnapshot hands out the recorded checksums of local files whose size and
+// modification time haven't changed since, so that they don't get hashed
+// again. Files that did change are hashed as usual and recorded anew.
+type hashSnapshot struct {
+	sync.Mutex
+
+	rootAbsPath string
+	recorded    map[string]*config.HashSnapshotEntry
+	// seen are the local files that were compared, whose
+	// checksums are recorded if they were computed.
+	seen map[string]*File
+}
+
+func newHashSnapshot(rootAbsPath string, recorded map[string]*config.HashSnapshotEntry) *hashSnapshot {
+	if recorded == nil {
+		recorded = make(map[string]*config.HashSnapshotEntry)
+	}
+	return &hashSnapshot{
+		rootAbsPath: rootAbsPath,
+		recorded:    recorded,
+		seen:        make(map[string]*File),
+	}
+}
+
+func (hs *hashSnapshot) key(f *File) (string, bool) {
+	if f == nil || f.IsDir || f.BlobAt == "" {
+		return "", false
+	}
+	relPath, err := filepath.Rel(hs.rootAbsPath, f.BlobAt)
+	if err != nil {
+		return "", false
+	}
+	return filepath.ToSlash(relPath), true
+}
+
+// apply sets the checksum of the local file f if it is unchanged since it was recorded.
+func (hs *hashSnapshot) apply(f *File) {
+	if hs == nil {
+		return
+	}
+	key, ok := hs.key(f)
+	if !ok {
+		return
+	}
+
+	hs.Lock()
+	defer hs.Unlock()
+
+	hs.seen[key] = f
+	entry, ok := hs.recorded[key]
+	if ok && f.Md5Checksum == "" && entry.Size == f.Size && entry.ModTime == f.ModTime.Unix() {
+		f.Md5Checksum = entry.Md5Checksum
+	}
+}
+
+// entries returns the recorded entries updated with the checksums of the files seen.
+func (hs *hashSnapshot) entries() map[string]*config.HashSnapshotEntry {
+	hs.Lock()
+	defer hs.Unlock()
+
+	for key, f := range hs.seen {
+		if f.Md5Checksum == "" {
+			continue
+		}
+		hs.recorded[key] = &config.HashSnapshotEntry{
+			Size:        f.Size,
+			ModTime:     f.ModTime.Unix(),
+			Md5Checksum: f.Md5Checksum,
+		}
+	}
+	return hs.recorded
+}
+
+// loadHashSnapshot loads the snapshot if checksums are compared and the
+// snapshot is asked for, which is to be saved once the operation is done.
+func (g *Commands) loadHashSnapshot() error {
+	if !g.opts.RemoteHashOnly || g.opts.IgnoreChecksum {
+		return nil
+	}
+
+	recorded, err := g.context.ReadHashSnapshot()
+	if err != nil {
+		return err
+	}
+	g.hashSnapshot = newHashSnapshot(g.context.AbsPathOf(""), recorded)
+	return nil
+}
+
+func (g *Commands) saveHashSnapshot() {
+	if g.hashSnapshot == nil {
+		return
+	}
+	if err := g.context.WriteHashSnapshot(g.hashSnapshot.entries()); err != nil {
+		g.log.LogErrf("hash snapshot: %v\n", err)
+	}
+}
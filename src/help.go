@@ -43,10 +43,13 @@ const (
 	ListKey                   = "list"
 	DuKey                     = "du"
 	Md5sumKey                 = "md5sum"
+	ActivityKey               = "activity"
 	MoveKey                   = "move"
 	OcrKey                    = "ocr"
 	ConvertKey                = "convert"
 	OSLinuxKey                = "linux"
+	OSWindowsKey              = "windows"
+	OSDarwinKey               = "darwin"
 	PullKey                   = "pull"
 	PipedKey                  = "piped"
 	PushKey                   = "push"
@@ -67,6 +70,19 @@ const (
 	PruneKey                  = "prune"
 	StarKey                   = "star"
 	UnStarKey                 = "unstar"
+	ExportLinksKey            = "export-links"
+	VerifyKey                 = "verify"
+	WatchKey                  = "watch"
+	DedupeKey                 = "dedupe"
+	ConfigKey                 = "config"
+	TrashOlderThanKey         = "trash-older-than"
+	DoctorKey                 = "doctor"
+	CommentsKey               = "comments"
+	DuplicatesKey             = "duplicates"
+	FindKey                   = "find"
+	ResetSessionsKey          = "reset-sessions"
+	ChangesKey                = "changes"
+	UndoKey                   = "undo"
 
 	CoercedMimeKeyKey        = "coerced-mime"
 	ExportsKey               = "export"
@@ -121,6 +137,7 @@ const (
 	TouchModTimeKey          = "time"
 	TouchTimeFmtSpecifierKey = "format"
 	TouchOffsetDurationKey   = "duration"
+	ExportFormatKey          = "format"
 )
 
 const (
@@ -158,6 +175,7 @@ const (
 	DescUnpublish             = "revokes public access to a file"
 	DescVersion               = "prints the version"
 	DescMd5sum                = "prints a list compatible with md5sum(1)"
+	DescActivity              = "show the recent edits of each file and who made them, queried from the Drive Activity API"
 	DescDu                    = "similar to util `du` gives you disk usage"
 	DescAccountTypes          = "\n\t* anyone.\n\t* user.\n\t* domain.\n\t* group"
 	DescRoles                 = "\n\t* owner.\n\t* reader.\n\t* writer.\n\t* commenter."
@@ -195,12 +213,130 @@ const (
 	DescId                           = "retrieve the fileId for the specified paths"
 	DescSkipContentCheck             = "skip diffing actual body content, show only name, time, type changes"
 	DescPushDestination              = "specify the final destination of the contents of an operation"
+	DescStripPrefix                  = "leading local folder path to remove from the paths of the sources before they are pushed under the destination"
 	DescExponentialBackoffRetryCount = "max number of retries for exponential backoff"
+	DescRetriesPerFile               = "max number of retries shared by all the requests for each file, so a failing file gives up early, 0 for no limit"
 	DescEncryptionPassword           = "encryption password"
 	DescDecryptionPassword           = "decryption password"
 	DescWithLink                     = "turn off file indexing so that only those with the link can view it"
+	DescPublishWithLink              = "publish to anyone with the link instead of publicly on the web"
+	DescPublic                       = "publish publicly on the web so that anyone can find the file, the default"
+	DescPublishManifest              = "path of a file to save the paths and URLs of the published files to, as CSV if it ends in .csv otherwise as JSON"
+	DescStream                       = "print the direct content links of audio and video, which can be streamed in a browser or player"
+	DescViewersCanCopy               = "true or false, whether readers and commenters can download, print and copy the files"
+	DescWritersCanShare              = "true or false, whether writers can share the files with others"
+	DescPublishRole                  = "role granted to anyone on published files. Possible values: reader, commenter"
 	DescAllowDesktopLinks            = "allows docs + sheets to be pulled as .desktop files or URL linked files"
+	DescLinkShortcuts                = "pull Google-native files as internet shortcuts to open them in the browser with, .url on Windows, .webloc on macOS and .desktop elsewhere, instead of exporting them"
+	DescExportsStripExtension        = "keep the original name of an exported file instead of appending the export format's extension to it"
+	DescPullAs                       = "with -id, the local name to pull the folder or file into instead of its remote title"
+	DescExportAll                    = "export Google Docs + Sheets to every format available in their export links, in place of -export"
+	DescExportLinks                  = "prints the export links of Google Docs, Sheets and Slides without downloading them"
+	DescWatch                        = "watches local paths and pushes them whenever they change"
+	DescDiffRevision                 = "id of a past remote revision to diff against instead of the current remote content"
+	DescDiffReport                   = "path of a CSV file to write the status, sizes, modification times and checksum match of each differing file to"
+	DescRenameMap                    = "file of \"<regex> => <replacement>\" rules applied to remote titles to get their local names"
+	DescSlashReplacement             = "replaces each \"/\" in remote titles when pulling, the default escapes it as %2F"
+	DescSummaryOnly                  = "suppress the per-file lines and print the totals, bytes transferred, elapsed time and throughput at the end"
+	DescUploadAsCopy                 = "uploads changes to remote files that are shared with others as new copies instead of updating them"
+	DescSkipExisting                 = "only push files that don't exist remotely, skipping those that do without comparing them"
+	DescPreserveMode                 = "record the permission bits of files in a custom property on push and restore them on pull"
+	DescOwnedOnly                    = "skip the remote files and folders that aren't owned by you, such as those only shared with you"
+	DescPreserveEmptyDirs            = "create local directories for the remote folders that are empty, for an exact mirror"
+	DescNoEmptyDirs                  = "skip the remote folders that have no files to be pulled into them, the opposite of -preserve-empty-on-pull"
+	DescExcludeGoogleDocs            = "skip all Google-native files such as Docs, Sheets and Slides i.e those with mimeTypes starting with application/vnd.google-apps."
+	DescFlattenSingleChild           = "pull chains of folders that only contain one folder e.g a/b/c as one folder a_b_c, recorded for pushes to map back"
+	DescLocalChecksumAlgo            = "compute the checksums of pulled files with this algorithm, md5 or sha256, and record them in .gd/<algo>sums"
+	DescPlan                         = "write the changes to this file as a JSON plan to review, without applying them"
+	DescApplyPlan                    = "apply exactly the changes of this JSON plan written by -plan, as they were planned"
+	DescRemoteHashOnly               = "only hash local files whose size or modification time changed since their checksums were recorded"
+	DescWaitForLock                  = "wait for another drive operation on the context to finish instead of failing right away"
+	DescProgressJSON                 = "periodically write JSON progress events to this file, or to file descriptor n with fd:<n>"
+	DescArchive                      = "pull into the .tar.gz, .tgz, .tar or .zip file at this path, picked by extension, instead of into loose local files"
+	DescMetadataOnly                 = "only index the path, id, size, md5, mtime and mimeType of remote files in .gd/metadata-index.json, without downloading them"
+	DescParentsAsLabels              = "shows the paths of all the folders that files in more than one folder are in"
+	DescBandwidthSchedule            = "comma separated start-end:rate limits by local time e.g 09:00-17:00:512K,17:00-09:00:0 where 0 is unlimited"
+	DescContinueOnError              = "record the failures of individual files and carry on with the rest, failing with their count at the end"
+	DescCreatedTime                  = "RFC3339 time e.g 2009-11-10T23:00:00Z to set as the created date of newly uploaded files"
+	DescConfig                       = "edits and prints the settings persisted in the drive context"
+	DescDedupe                       = "trashes all but the newest of the files within a folder that have the same title and content"
+	DescComments                     = "lists the comments on files along with their replies, or adds a comment with `add`"
+	DescCommentMessage               = "the content of the comment to add"
+	DescIncludeDeletedComments       = "also list the comments and replies that were deleted"
+	DescWithComments                 = "also copy the comments and replies of files, which become attributed to you"
+	DescDoctor                       = "checks the credentials, clock, network, proxy and write access that drive relies on and suggests remedies"
+	DescDryRun                       = "only report what would be done"
+	DescTrashOlderThan               = "trashes the files under folders that were last modified before a cutoff, as a retention policy"
+	DescOlderThanCutoff              = "trash files modified before this RFC3339 time or longer ago than a relative time like 90d"
+	DescRepointShortcuts             = "replace shortcuts to trashed duplicates with shortcuts to the kept files"
+	DescDuplicates                   = "lists the groups of files under folders that have the same content or title, without changing anything"
+	DescDuplicatesByContent          = "group files that have the same md5 checksum"
+	DescDuplicatesByName             = "group files that have the same title"
+	DescFind                         = "searches the drive for files matching all of the given predicates, without knowing the query syntax of the API"
+	DescFindNameContains             = "find files whose title contains this text, can be repeated"
+	DescFindModifiedAfter            = "find files modified after this RFC3339 time or relative time like 7d"
+	DescFindOwner                    = "find files owned by this email address, can be repeated"
+	DescFindMime                     = "find files of any of these comma separated mimeTypes or short keys e.g pdf,docx"
+	DescFindStarred                  = "find starred files"
+	DescFindTrashed                  = "find files in the trash instead of those not in it"
+	DescFindQuery                    = "a query in the syntax of the Drive API to combine with the other predicates"
+	DescChanges                      = "prints the remote changes since the last time they were streamed as NDJSON, without downloading anything"
+	DescSinceChangeId                = "stream the changes from this change id on instead of from the stored cursor"
+	DescFollow                       = "keep polling for changes and stream them as they come"
+	DescChangesPollInterval          = "how often to poll for changes while following them e.g 30s, 5m"
+	DescUndo                         = "reverses the last destructive operation by untrashing the files that it moved to the trash"
+	DescPollInterval                 = "instead of pushing local changes, poll for remote changes this often and pull them e.g 30s, 5m"
+	DescDebounce                     = "how long to wait for changes to settle before pushing them e.g 500ms, 5s"
+	DescVerify                       = "compares the md5 checksums of local files against their remote counterparts without transferring them"
+	DescVerifyDeep                   = "also download remote files and check their content against their stored md5 checksums"
+	DescExportFormat                 = "only print the export link for this format e.g pdf"
 	DescKeepParent                   = "ensures that when moving a file into a destination, that we also retain its original parent so that it will exist in more than one folder"
+	DescRenameFolder                 = "name to give the single folder being moved, if its destination is its current parent then only its title is changed"
+	DescCheckpointInterval           = "if set to n > 0, a progress checkpoint is saved after every n successfully transferred files"
+	DescResume                       = "skip files that were completed by a previously interrupted and checkpointed operation"
+	DescResumableStateTTL            = "age after which the checkpoint of an interrupted operation is discarded instead of resumed e.g 7d, 36h"
+	DescResetSessions                = "removes the checkpoints of all interrupted operations so that none of them get resumed"
+	DescPullQueue                    = "persist all the files to pull to a queue up front and record each as it completes, so that with -resume only the pending and failed ones are pulled"
+	DescListJSON                     = "print the listed items as a JSON array, streamed item by item, with their name, path, size, modTime, id, mimeType, md5Checksum and isDir"
+	DescChunkedList                  = "print each page of a listing as it arrives instead of buffering whole folders, in the order of the server so only server side -order-by holds, not with -concurrent-list"
+	DescOrderBy                      = "order listed items by a comma separated combination of\n\t* name.\n\t* modifiedTime.\n\t* size.\n\t* folder.\ne.g folder,name"
+	DescReverse                      = "reverse the ordering requested by -order-by"
+	DescMaxInflightBytes             = "if set to n > 0, caps the sum of the sizes in bytes of the files being concurrently uploaded"
+	DescIgnoreCase                   = "match local and remote paths case-insensitively e.g Docs/File.txt matches docs/file.txt"
+	DescUnicodeNormalization         = "the Unicode form that local and remote names are compared in, one of nfc, nfd or none"
+	DescPruneDepth                   = "if set to n > 0, only deletions at most n levels below each path are applied, deeper ones are only reported"
+	DescProperty                     = "custom key=value property to set on the pushed files, can be repeated"
+	DescIndexableText                = "text for Drive search to index the pushed files by, e.g to find images by what they show"
+	DescFollowShortcuts              = "pull the content of the files that shortcuts point to instead of skipping the shortcuts"
+	DescRenameOnCollision            = "instead of overwriting local files that weren't pulled from the same remote files, pull the incoming files in as \"name (n).ext\""
+	DescSince                        = "only diff files modified after this time, either RFC3339 e.g 2016-11-01T00:00:00Z or relative e.g 7d, 2w, 36h"
+	DescLocalOnly                    = "only list the files that exist locally but not remotely, without diffing content"
+	DescRemoteOnly                   = "only list the files that exist remotely but not locally, without diffing content"
+	DescTempDir                      = "directory in which downloads are staged before being moved into place, defaults to the system's temp directory"
+	DescAtomic                       = "download to a staging file that is only moved into place once fully received and, if checksums aren't ignored, verified"
+	DescRetryOnChecksumMismatch      = "download a file up to n more times if its content doesn't match its md5 checksum, requires -ignore-checksum=false"
+	DescMinFileSize                  = "skip files smaller than this size e.g 1K. Folders are always traversed"
+	DescMaxFileSize                  = "skip files larger than this size e.g 500M, 1.5G. Folders are always traversed"
+	DescApplyRemoteDeletes           = "delete the local copies of pulled paths that were trashed remotely and are unmodified since they were last pulled"
+	DescPinRevision                  = "pin the new revision of each file that is updated, which keeps it forever instead of letting Drive purge it"
+	DescConcurrentList               = "if set to n > 0, lists the remote children of up to n folders at once while resolving changes or listing, for wide trees"
+	DescNoRecursive                  = "only handle the direct children of folders and none of their descendants, file paths are always handled"
+	DescMirror                       = "make the destination an exact copy of the source by overwriting conflicting content and applying deletions at any depth, except for ignored paths"
+	DescPromptAll                    = "print a numbered list of the changes from which to deselect some, before confirming them all at once"
+	DescParentId                     = "id of the existing folder to push files into instead of resolving the destination by path"
+	DescListFormat                   = "text/template with which to print each item using the fields .Name, .Path, .Size, .ModTime, .Id, .Mime, .Md5 and .IsDir e.g '{{.Id}}\\t{{.Name}}'"
+	DescCompress                     = "gzip files that aren't already in a compressed format e.g jpg, mp4 on upload, as <name>.gz"
+	DescDecompress                   = "gunzip the .gz files that were compressed on push, into their original names"
+	DescCheck                        = "only validate the credentials, ignore patterns and paths that a command would run with and report them, without making any API calls"
+	DescConfigDir                    = "directory in which to keep the credentials, index database and state instead of the .gd directory of the context"
+	DescRemoteRoot                   = "path or id of the remote folder that the context maps to, instead of the root of the Drive"
+	DescMaxQPS                       = "if set to n > 0, limits the requests sent to the Drive API to n per second across all concurrent operations, e.g 10 or 0.5"
+	DescImpersonate                  = "email of the user that a service account with domain-wide delegation acts as"
+	DescInitRemote                   = "path or id of an existing remote folder to adopt, it is looked up once initialized and the context is mapped to it"
+	DescAuthPort                     = "loopback port to receive the authorization redirect on, 0 picks any free port as does a port that is taken"
+	DescAuthBindAddr                 = "address that the server receiving the authorization redirect binds to and advertises, e.g 0.0.0.0 in a container"
+	DescAuthManual                   = "print the authorization URL and paste the code it gives instead of receiving it on a loopback port, for headless machines"
+	DescAuthActivity                 = "also request read access to the Drive Activity API, which `stat -activity` needs"
 
 	DescTouchTimeStr          = "the time each file's modification time should be set to"
 	DescTouchOffsetDuration   = "the duration offset from now that each file's modification time should be set to e.g -32h\nSee https://golang.org/pkg/time/#ParseDuration"
@@ -228,12 +364,15 @@ const (
 	CLIOptionPruneIndices       = "prune"
 	CLIOptionAllIndexOperations = "all-ops"
 	CLIOptionVerboseKey         = "verbose"
+	CLIOptionVerifyDeep         = "deep"
 	CLIOptionVerboseShortKey    = "v"
 	CLIOptionOpen               = "open"
 	CLIOptionWebBrowser         = "web-browser"
 	CLIOptionFileBrowser        = "file-browser"
 	CLIOptionDirectories        = "directories"
 	CLIOptionFiles              = "files"
+	CLIOptionOnlyFolders        = "only-folders"
+	CLIOptionOnlyFiles          = "only-files"
 	CLIOptionLongFmt            = "long"
 	CLIOptionFixClashesKey      = "fix-clashes"
 	CLIOptionPiped              = "piped"
@@ -246,18 +385,172 @@ const (
 	CLIOptionFixClashesMode     = "fix-mode"
 	CLIOptionListClashes        = "list"
 	CLIOptionPushDestination    = "destination"
+	CLIOptionStripPrefix        = "strip-prefix"
 	CLIOptionRenameLocal        = "local"
 	CLIOptionRenameRemote       = "remote"
 	CLIOptionRetryCount         = "retry-count"
+	CLIOptionRetriesPerFile     = "retries-per-file"
 	CLIEncryptionPassword       = "encryption-password"
 	CLIDecryptionPassword       = "decryption-password"
 	CLIOptionWithLink           = "with-link"
+	CLIOptionPublic             = "public"
+	CLIOptionPublishManifest    = "manifest"
+	CLIOptionStream             = "stream"
+	CLIOptionViewersCanCopy     = "viewers-can-copy"
+	CLIOptionWritersCanShare    = "writers-can-share"
 	CLIOptionDesktopLinks       = "desktop-links"
+	CLIOptionLinkShortcuts      = "link-shortcuts"
 	CLIOptionKeepParent         = "keep-parent"
+	CLIOptionRenameFolder       = "rename-folder"
 
 	CLIOptionUploadChunkSize = "upload-chunk-size"
 
+	CLIOptionCheckpointInterval = "checkpoint"
+	CLIOptionResume             = "resume"
+	CLIOptionResumableStateTTL  = "resumable-state-ttl"
+	CLIOptionPullQueue          = "queue"
+
+	CLIOptionConfigDir   = "config-dir"
+	CLIOptionRemoteRoot  = "remote-root"
+	CLIOptionImpersonate = "impersonate"
+	CLIOptionMaxQPS      = "max-qps"
+	CLIOptionMaxAPIQPS   = "max-api-qps"
+	CLIOptionInitRemote  = "remote"
+	CLIOptionAuthPort    = "auth-port"
+	CLIOptionAuthManual  = "auth-manual"
+	CLIOptionBindAddr    = "bind-addr"
+
+	CLIOptionOrderBy = "order-by"
+	CLIOptionReverse = "reverse"
+
+	CLIOptionMaxInflightBytes = "max-inflight-bytes"
+
+	CLIOptionIgnoreCase = "ignore-case"
+
+	CLIOptionUnicodeNormalization = "unicode-normalization"
+
+	CLIOptionPruneDepth = "prune-depth"
+
+	CLIOptionProperty      = "property"
+	CLIOptionIndexableText = "indexable-text"
+
+	CLIOptionFollowShortcuts = "follow-shortcuts"
+
+	CLIOptionRenameOnCollision = "rename-on-collision"
+
+	CLIOptionSince = "since"
+
+	CLIOptionDiffRevision = "revision"
+	CLIOptionDiffReport   = "report"
+
+	CLIOptionLocalOnly  = "local-only"
+	CLIOptionRemoteOnly = "remote-only"
+
+	CLIOptionTempDir = "temp-dir"
+
+	CLIOptionAtomic = "atomic"
+
+	CLIOptionRetryOnChecksumMismatch = "retry-on-checksum-mismatch"
+
+	CLIOptionMinFileSize = "min-size"
+	CLIOptionMaxFileSize = "max-size"
+
+	CLIOptionParentId = "parent-id"
+
+	CLIOptionApplyRemoteDeletes = "apply-remote-deletes"
+
+	CLIOptionPromptAll = "prompt-all"
+
+	CLIOptionMirror      = "mirror"
+	CLIOptionNoRecursive = "no-recursive"
+
+	CLIOptionConcurrentList = "concurrent-list"
+	CLIOptionPinRevision    = "pin-revision"
+	CLIOptionKeepRevisions  = "keep-revisions"
+
+	CLIOptionDebounce = "debounce"
+
+	CLIOptionPollInterval = "poll"
+
+	CLIOptionListFormat  = "format"
+	CLIOptionListJSON    = "json"
+	CLIOptionChunkedList = "chunked-list"
+
+	CLIOptionCheck = "check"
+
+	CLIOptionCreatedTime = "created-time"
+
+	CLIOptionContinueOnError = "continue-on-error"
+
+	CLIOptionRenameMap        = "rename-map"
+	CLIOptionSlashReplacement = "slash-replacement"
+
+	CLIOptionBandwidthSchedule = "bwlimit-schedule"
+
+	CLIOptionParentsAsLabels = "parents-as-labels"
+
+	CLIOptionUploadAsCopy = "upload-as-copy"
+	CLIOptionSkipExisting = "skip-existing"
+	CLIOptionPreserveMode = "preserve-mode"
+	CLIOptionMetadataOnly = "metadata-only"
+	CLIOptionArchive      = "archive"
+	CLIOptionToArchive    = "to-archive"
+
+	CLIOptionExcludeGoogleDocs  = "exclude-google-docs"
+	CLIOptionOwnedOnly          = "owned-only"
+	CLIOptionExcludeShared      = "exclude-shared"
+	CLIOptionPreserveEmptyDirs  = "preserve-empty-on-pull"
+	CLIOptionNoEmptyDirs        = "no-empty-dirs"
+	CLIOptionLocalChecksumAlgo  = "local-checksum-algo"
+	CLIOptionFlattenSingleChild = "flatten-single-child"
+
+	CLIOptionPlan      = "plan"
+	CLIOptionApplyPlan = "apply-plan"
+
+	CLIOptionProgressJSON = "progress-json"
+	CLIOptionWaitForLock  = "wait"
+
+	CLIOptionRemoteHashOnly = "remote-hash-only"
+
+	CLIOptionCommentMessage         = "message"
+	CLIOptionIncludeDeletedComments = "deleted"
+	CLIOptionWithComments           = "with-comments"
+
+	// CommentsAddKey is the subcommand of comments that adds a comment.
+	CommentsAddKey = "add"
+	// CommentsListKey is the subcommand of comments that lists comments, the default.
+	CommentsListKey = "list"
+
+	CLIOptionSummaryOnly = "summary-only"
+
+	CLIOptionCutoff = "cutoff"
+
+	CLIOptionDryRun           = "dry-run"
+	CLIOptionRepointShortcuts = "repoint-shortcuts"
+
+	CLIOptionByContent = "by-content"
+	CLIOptionByName    = "by-name"
+
+	CLIOptionNameContains  = "name-contains"
+	CLIOptionModifiedAfter = "modified-after"
+	CLIOptionOwner         = "owner"
+	CLIOptionMime          = "mime"
+	CLIOptionQuery         = "query"
+
+	CLIOptionSinceChangeId = "since-change-id"
+	CLIOptionFollow        = "follow"
+
+	CLIOptionCompress   = "compress"
+	CLIOptionDecompress = "decompress"
+
 	CLIOptionExportsDumpToSameDirectory = "same-exports-dir"
+	CLIOptionExportsStripExtension      = "strip-extension"
+	CLIOptionExportsKeepOriginalName    = "keep-original-name"
+	CLIOptionExportAll                  = "export-all"
+	CLIOptionExportAllFormats           = "export-all-formats"
+
+	CLIOptionPullAs    = "as"
+	CLIOptionMapRootTo = "map-root-to"
 
 	CLIOptionTrashed = TrashedKey
 )
@@ -271,6 +564,7 @@ const (
 	GoogleApiClientSecretEnvKey = "GOOGLE_API_CLIENT_SECRET"
 	DriveGoMaxProcsKey          = "DRIVE_GOMAXPROCS"
 	GoMaxProcsKey               = "GOMAXPROCS"
+	DriveConfigDirEnvKey        = "DRIVE_CONFIG_DIR"
 )
 
 const (
@@ -293,6 +587,7 @@ var docMap = map[string][]string{
 	},
 	CopyKey: []string{
 		DescCopy,
+		fmt.Sprintf("Use `-%s` to also re-create the comments and replies of files on their copies. The re-created ones are authored by you, with the original author and time noted in their content", CLIOptionWithComments),
 	},
 	DeleteKey: []string{
 		DescDelete,
@@ -300,6 +595,8 @@ var docMap = map[string][]string{
 	DiffKey: []string{
 		DescDiff, "Accepts multiple remote paths for line by line comparison",
 		skipChecksumNote,
+		fmt.Sprintf("Use `-%s <id>` to diff against that past revision of the remote instead of its current content", CLIOptionDiffRevision),
+		fmt.Sprintf("Use `-%s <file.csv>` to write the comparison of each file to a CSV report instead", CLIOptionDiffReport),
 	},
 	EditDescriptionShortKey: []string{
 		DescEdit, "Accepts multiple remote paths as well as ids",
@@ -314,6 +611,10 @@ var docMap = map[string][]string{
 		DescInit, "Requests for access to your Google Drive",
 		"Creating a folder that contains your credentials",
 		"Note: `init` in an already initialized drive will erase the old credentials",
+		fmt.Sprintf("The consent screen redirects back to a local server on `-%s`, any free port by default", CLIOptionAuthPort),
+		fmt.Sprintf("Use `-%s <address>` to bind that server to another address than %s, e.g for a port forwarded into a container", CLIOptionBindAddr, DefaultAuthBindAddr),
+		fmt.Sprintf("Use `-%s` on headless machines to paste the authorization code instead", CLIOptionAuthManual),
+		fmt.Sprintf("Use `-%s` to also grant the read access to the Drive Activity API that `stat -%s` needs", ActivityKey, ActivityKey),
 	},
 	PullKey: []string{
 		DescPull, "Downloads content from the remote drive or modifies",
@@ -342,6 +643,12 @@ var docMap = map[string][]string{
 	},
 	PubKey: []string{
 		DescPublish, "Accepts multiple paths",
+		fmt.Sprintf("Use `-%s commenter` to let anyone comment on rather than just view published files", RoleKey),
+		fmt.Sprintf("Use `-%s` to only publish to anyone with the link instead of publicly on the web", CLIOptionWithLink),
+		"The sharing scope of each published file is printed along with its URL",
+		fmt.Sprintf("Use `-%s` to also publish all the descendants of folders", RecursiveKey),
+		fmt.Sprintf("Use `-%s <path>` to save the manifest of published paths and URLs as JSON, or as CSV for paths ending in .csv", CLIOptionPublishManifest),
+		fmt.Sprintf("Use `-%s` to publish audio and video with links that can be streamed directly", CLIOptionStream),
 	},
 	RenameKey: []string{
 		DescRename, "Accepts <src> <newName>",
@@ -380,6 +687,89 @@ var docMap = map[string][]string{
 	UrlKey: []string{
 		DescUrl, "takes multiple paths or ids",
 	},
+	ExportLinksKey: []string{
+		DescExportLinks, "takes multiple paths or ids",
+		"For each file prints the format name and its export URL",
+		fmt.Sprintf("Use `-%s <format>` to only print the URL of a single format", ExportFormatKey),
+	},
+	DedupeKey: []string{
+		DescDedupe, "takes multiple folder paths",
+		"Files are duplicates if they are in the same folder with the same title and md5 checksum",
+		"Google Docs have no md5 checksum so they are never considered duplicates",
+		fmt.Sprintf("Use `-%s` to only list the groups of duplicates that would be collapsed", CLIOptionDryRun),
+		fmt.Sprintf("Use `-%s` to repoint shortcuts found in the traversed folders to the kept files", CLIOptionRepointShortcuts),
+	},
+	TrashOlderThanKey: []string{
+		DescTrashOlderThan, "takes multiple paths",
+		fmt.Sprintf("Use `-%s <time>` to set the cutoff e.g `-%s 90d` or `-%s 2016-01-01T00:00:00Z`", CLIOptionCutoff, CLIOptionCutoff, CLIOptionCutoff),
+		fmt.Sprintf("Use `-%s <size>` to only trash files that are at least that large", CLIOptionMinFileSize),
+		fmt.Sprintf("Use `-%s` to only list the files and the size that would be reclaimed", CLIOptionDryRun),
+		"Paths matched by your .driveignore are skipped",
+	},
+	DuplicatesKey: []string{
+		DescDuplicates, "takes multiple folder paths",
+		"Subfolders are traversed and each file is printed with its full path and id",
+		fmt.Sprintf("Use `-%s`, `-%s` or both to choose what files are grouped by, by content being the default", CLIOptionByContent, CLIOptionByName),
+		"Google Docs have no md5 checksum so they are only grouped by name",
+		fmt.Sprintf("Use `-%s <n>` to limit how deep subfolders are traversed", DepthKey),
+	},
+	ResetSessionsKey: []string{
+		DescResetSessions,
+		fmt.Sprintf("Checkpoints are saved by `-%s <n>` and resumed by `-%s`", CLIOptionCheckpointInterval, CLIOptionResume),
+		fmt.Sprintf("Checkpoints older than `-%s`, 7 days by default, are already discarded instead of being resumed", CLIOptionResumableStateTTL),
+	},
+	FindKey: []string{
+		DescFind, "searches the whole drive, all of the predicates that are passed in have to hold",
+		fmt.Sprintf("Use `-%s`, `-%s`, `-%s`, `-%s`, `-%s` and `-%s` to build the query", CLIOptionNameContains, CLIOptionModifiedAfter, CLIOptionOwner, CLIOptionMime, CLIOptionStarred, CLIOptionTrashed),
+		fmt.Sprintf("Use `-%s <query>` to add a query in the raw syntax of the API", CLIOptionQuery),
+		fmt.Sprintf("Use `-%s` to print the query that the predicates were compiled into", CLIOptionVerboseKey),
+	},
+	ChangesKey: []string{
+		DescChanges,
+		"Each change is printed on a line of its own as a JSON object, for other tools to decide what to fetch",
+		fmt.Sprintf("The id of the next change is stored in the .gd directory once the changes were streamed to completion, use `-%s <id>` to start elsewhere", CLIOptionSinceChangeId),
+		fmt.Sprintf("Use `-%s` to keep polling for changes every `-%s <duration>`", CLIOptionFollow, CLIOptionPollInterval),
+	},
+	UndoKey: []string{
+		DescUndo,
+		fmt.Sprintf("The files that `%s`, `%s`, `%s`, `%s` and the deletions of `%s` remove are appended to the undo log in the .gd directory", TrashKey, DeleteKey, DedupeKey, TrashOlderThanKey, PushKey),
+		"Only the last operation in the log is undone, running undo again undoes the one before it",
+		fmt.Sprintf("Files that were permanently deleted by `%s` cannot be restored and are only reported", DeleteKey),
+	},
+	CommentsKey: []string{
+		DescComments,
+		fmt.Sprintf("`%s <paths...>` lists the comments with their authors, timestamps and replies, which is the default", CommentsListKey),
+		fmt.Sprintf("`%s -%s <comment> <paths...>` adds the comment to each of the files", CommentsAddKey, CLIOptionCommentMessage),
+		fmt.Sprintf("Use `-%s` to also list the comments and replies that were deleted", CLIOptionIncludeDeletedComments),
+		fmt.Sprintf("Use `-%s` to refer to files by id instead of path", CLIOptionId),
+	},
+	DoctorKey: []string{
+		DescDoctor,
+		"Each check is reported as PASS or FAIL, failures come with a hint on how to remedy them",
+		"Include its output when reporting an issue",
+	},
+	ConfigKey: []string{
+		DescConfig,
+		"`set mime <ext> <mimeType>` uploads files with extension ext as mimeType on push",
+		"`unset mime <ext>` removes the mimeType override of ext",
+		"`get mime [ext]` prints the mimeType override of ext or all of them",
+		fmt.Sprintf("Overrides take precedence over the mimeType inferred from the extension but not over `-%s`", CoercedMimeKeyKey),
+		"`set|unset|get max-idle-conns|max-idle-conns-per-host|idle-conn-timeout [value]` tunes the pool of connections to the API",
+		"By default the pool is scaled to the number of concurrent transfers",
+	},
+	WatchKey: []string{
+		DescWatch, "takes multiple paths, watching folders recursively",
+		"Honors .driveignore and skips hidden paths unless `-hidden` is set",
+		fmt.Sprintf("Changes are batched until none are seen for `-%s`, %v by default", CLIOptionDebounce, DefaultWatchDebounce),
+		fmt.Sprintf("Use `-%s <duration>` to instead periodically pull remote changes into the local paths", CLIOptionPollInterval),
+	},
+	VerifyKey: []string{
+		DescVerify, "takes multiple paths, traversing folders recursively",
+		"Reports each file as OK, MISMATCH, MISSING-LOCAL, MISSING-REMOTE or SKIPPED",
+		"Google Docs have no md5 checksum and are SKIPPED",
+		fmt.Sprintf("Use `-%s` to also download each remote file and report it as CORRUPT if its content doesn't match its stored md5 checksum", CLIOptionVerifyDeep),
+		"Exits with a non-zero status if any file fails verification",
+	},
 	VersionKey: []string{
 		DescVersion, fmt.Sprintf("current version is: %s", Version),
 	},
@@ -394,6 +784,7 @@ func createAndRegisterAliases() map[string][]string {
 		EditDescriptionKey: []string{EditDescriptionShortKey},
 		IdKey:              []string{"file-id"},
 		ReportIssueKey:     []string{"issue", "report"},
+		DuplicatesKey:      []string{"list-duplicates"},
 	}
 
 	for originalKey, aliasList := range aliases {
@@ -0,0 +1,77 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"fmt"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+)
+
+// impersonating returns the service account config that acts as subject,
+// a copy so that the config of the context isn't changed, or jwtConfig
+// as is if no one is to be impersonated.
+func impersonating(jwtConfig *jwt.Config, subject string) *jwt.Config {
+	if jwtConfig == nil || subject == "" {
+		return jwtConfig
+	}
+
+	impersonated := *jwtConfig
+	impersonated.Subject = subject
+	return &impersonated
+}
+
+// delegationDenied reports whether err is the token endpoint refusing
+// to issue a token for the subject, as it does for service accounts
+// that domain-wide delegation wasn't granted to.
+func delegationDenied(err error) bool {
+	rErr, ok := err.(*oauth2.RetrieveError)
+	if !ok || rErr == nil {
+		return false
+	}
+
+	if rErr.Response != nil && rErr.Response.StatusCode == 403 {
+		return true
+	}
+	return bytes.Contains(rErr.Body, []byte("unauthorized_client")) || bytes.Contains(rErr.Body, []byte("access_denied"))
+}
+
+// VerifyImpersonation checks that the service account can act as the user
+// to impersonate, if any, by retrieving a token up front so that a missing
+// delegation is reported as such rather than as failures of every request.
+func (g *Commands) VerifyImpersonation() error {
+	if g.opts == nil || g.opts.Impersonate == "" {
+		return nil
+	}
+
+	jwtConfig := g.context.GSAJWTConfig
+	if jwtConfig == nil {
+		return invalidArgumentsErr(fmt.Errorf("`%s` requires the credentials of a service account, see `drive init -%s`", CLIOptionImpersonate, ServiceAccountJSONFileKey))
+	}
+
+	_, err := impersonating(jwtConfig, g.opts.Impersonate).TokenSource(transportContext(g.context)).Token()
+	if err == nil {
+		return nil
+	}
+
+	if delegationDenied(err) {
+		err = fmt.Errorf("service account %s cannot impersonate %s, domain-wide delegation has to be granted to the service account for the scope %s in the Admin console: %v", jwtConfig.Email, g.opts.Impersonate, DriveScope, err)
+	} else {
+		err = fmt.Errorf("impersonating %s: %v", g.opts.Impersonate, err)
+	}
+	return makeError(err, StatusAuthenticationFailed)
+}
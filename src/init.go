@@ -31,7 +31,17 @@ func (g *Commands) Init() error {
 	}
 
 	ctx := context.Background()
-	refreshToken, err := RetrieveRefreshToken(ctx, g.context)
+	var refreshToken string
+	var err error
+	var extraScopes []string
+	if g.opts != nil && g.opts.AuthActivity {
+		extraScopes = append(extraScopes, DriveActivityScope)
+	}
+	if g.opts != nil && !g.opts.AuthManual {
+		refreshToken, err = RetrieveRefreshTokenViaLoopback(ctx, g.context, g.opts.AuthBindAddr, g.opts.AuthPort, extraScopes...)
+	} else {
+		refreshToken, err = RetrieveRefreshToken(ctx, g.context, extraScopes...)
+	}
 	if err != nil {
 		return err
 	}
@@ -63,6 +73,11 @@ func (g *Commands) InitWithServiceAccount(gsaFilepath string) error {
 		return err
 	}
 
+	// The user to impersonate at init is saved as the default subject
+	if g.opts != nil && g.opts.Impersonate != "" {
+		jwtConfig.Subject = g.opts.Impersonate
+	}
+
 	// Next we'll just transfer the attributes directly
 	// by means of JSON marshaling the already vetted JWTConfig
 	g.context.GSAJWTConfig = jwtConfig
@@ -139,3 +139,17 @@ func _intfer(varname, strValue string) (interface{}, error) {
 
 	return value, err
 }
+
+func _int64fer(varname, strValue string) (interface{}, error) {
+	var value int64
+	var err error
+
+	v64, vErr := strconv.ParseInt(strValue, 10, 64)
+	if vErr == nil {
+		value = v64
+	} else {
+		err = parseErrorer(varname, TInt64, value, vErr)
+	}
+
+	return value, err
+}
@@ -0,0 +1,102 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"html"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// The extensions of the internet shortcuts written by -link-shortcuts.
+const (
+	URLShortcutExtension    = "url"
+	WeblocShortcutExtension = "webloc"
+)
+
+// linkShortcutExtension returns the extension of the internet
+// shortcuts that the file manager of goos opens with a browser.
+func linkShortcutExtension(goos string) string {
+	switch goos {
+	case OSWindowsKey:
+		return URLShortcutExtension
+	case OSDarwinKey:
+		return WeblocShortcutExtension
+	default:
+		return DesktopExtension
+	}
+}
+
+// linkShortcutURL returns the link that the shortcut of f opens,
+// which is where it is viewed or edited in the browser.
+func linkShortcutURL(f *File) string {
+	if f.AlternateLink != "" {
+		return f.AlternateLink
+	}
+	return f.Url()
+}
+
+// linkShortcutContent returns the content of the internet
+// shortcut of f in the format of the extension ext.
+func linkShortcutContent(ext string, f *File) string {
+	link := linkShortcutURL(f)
+	switch ext {
+	case URLShortcutExtension:
+		return fmt.Sprintf("[InternetShortcut]\r\nURL=%s\r\n", link)
+	case WeblocShortcutExtension:
+		return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>URL</key>
+	<string>%s</string>
+</dict>
+</plist>
+`, html.EscapeString(link))
+	default:
+		icon := strings.Replace(f.MimeType, UnescapedPathSep, MimeTypeJoiner, -1)
+		return fmt.Sprintf("[Desktop Entry]\nIcon=%s\nName=%s\nType=%s\nURL=%s\n", icon, f.Name, LinkKey, link)
+	}
+}
+
+// writeLinkShortcut writes an internet shortcut to the Google-native file
+// of change in place of its content, named after it with the extension of
+// the platform, and dated like it so that it is only rewritten once the
+// remote file is modified.
+func (g *Commands) writeLinkShortcut(change *Change) error {
+	f := change.Src
+	ext := linkShortcutExtension(runtime.GOOS)
+	shortcutPath := sepJoin(".", g.context.AbsPathOf(change.Path), ext)
+
+	perm := os.FileMode(0644)
+	if ext == DesktopExtension {
+		perm = 0755
+	}
+	if err := ioutil.WriteFile(shortcutPath, []byte(linkShortcutContent(ext, f)), perm); err != nil {
+		return err
+	}
+
+	if err := os.Chtimes(shortcutPath, f.ModTime, f.ModTime); err != nil {
+		g.log.LogErrf("%s: %v\n", shortcutPath, err)
+	}
+
+	if !g.opts.SummaryOnly {
+		g.log.Logf("Linked '%s' to %s\n", shortcutPath, linkShortcutURL(f))
+	}
+	return nil
+}
@@ -15,10 +15,16 @@
 package drive
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 
 	"github.com/odeke-em/log"
+	drive "google.golang.org/api/drive/v2"
 )
 
 type attribute struct {
@@ -26,6 +32,11 @@ type attribute struct {
 	mask          int
 	parent        string
 	diskUsageOnly bool
+	format        *template.Template
+	// parentPaths are the folders of files that are in more than one.
+	parentPaths []string
+	// json when set is what the files are written to as a JSON array.
+	json *jsonListWriter
 }
 
 type traversalSt struct {
@@ -37,6 +48,136 @@ type traversalSt struct {
 	explicitNoPrompt bool
 	sorters          []string
 	matchQuery       *matchQuery
+	orderBy          *orderBySt
+	format           *template.Template
+	// chunked when set prints the files of each page as it arrives
+	// instead of once all the pages of a folder were retrieved.
+	chunked bool
+	json    *jsonListWriter
+	// prefetched when set is the listing of the children
+	// of file, that was started ahead of its turn.
+	prefetched *folderPrefetch
+}
+
+// folderPrefetch is the listing of the children of a folder that is
+// fetched while its preceding siblings are traversed, with -concurrent-list.
+type folderPrefetch struct {
+	done  chan bool
+	files []*File
+	ok    bool
+}
+
+// prefetchFolder starts listing the children that req asks for, once
+// one of the list slots is free.
+func (g *Commands) prefetchFolder(req *drive.FilesListCall) *folderPrefetch {
+	pf := &folderPrefetch{done: make(chan bool)}
+	go func() {
+		defer close(pf.done)
+
+		g.listSlots.acquire()
+		defer g.listSlots.release()
+
+		pf.ok = g.listFolderPages(req, func(file *File) {
+			pf.files = append(pf.files, file)
+		})
+	}()
+	return pf
+}
+
+// listedFile holds the fields available to list format templates.
+type listedFile struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+	Id      string    `json:"id"`
+	Mime    string    `json:"mimeType"`
+	Md5     string    `json:"md5Checksum,omitempty"`
+	IsDir   bool      `json:"isDir"`
+	// Parents are the paths of the folders that the file is in, only set
+	// for files in more than one folder when listing with parents as labels.
+	Parents []string `json:"parents,omitempty"`
+}
+
+// jsonListWriter writes the listed files as the elements of a JSON
+// array one by one, so that the array is streamed instead of buffered.
+type jsonListWriter struct {
+	sync.Mutex
+	logy  *log.Logger
+	count int
+}
+
+func (g *Commands) jsonListWriter() *jsonListWriter {
+	if !g.opts.ListJSON {
+		return nil
+	}
+	return &jsonListWriter{logy: g.log}
+}
+
+func (jw *jsonListWriter) write(lf *listedFile) error {
+	blob, err := json.Marshal(lf)
+	if err != nil {
+		return err
+	}
+
+	jw.Lock()
+	defer jw.Unlock()
+
+	sep := ",\n"
+	if jw.count < 1 {
+		sep = "[\n"
+	}
+	jw.count += 1
+	jw.logy.Logf("%s%s", sep, blob)
+	return nil
+}
+
+// close ends the array, which is empty if nothing was written.
+func (jw *jsonListWriter) close() {
+	if jw == nil {
+		return
+	}
+
+	jw.Lock()
+	defer jw.Unlock()
+
+	if jw.count < 1 {
+		jw.logy.Logln("[]")
+		return
+	}
+	jw.logy.Logln("\n]")
+}
+
+// chunkedList reports whether listings are to be printed page by page,
+// in which case only the order that the server returns files in holds.
+// Listing folders ahead buffers their whole listings, which printing page
+// by page is meant to avoid, so the two can't be combined.
+func (g *Commands) chunkedList(orderBy *orderBySt) (bool, error) {
+	if !g.opts.ChunkedList {
+		return false, nil
+	}
+	if g.opts.ConcurrentList > 0 {
+		return false, invalidArgumentsErr(fmt.Errorf("cannot use both `-%s` and `-%s`, folders listed ahead are buffered in full", CLIOptionChunkedList, CLIOptionConcurrentList))
+	}
+
+	if len(sorters(g.opts)) >= 1 || (orderBy != nil && orderBy.apiOrderBy == "") {
+		g.log.LogErrf("%s: files are listed in the order that the server returns them, the keys to sort by locally are ignored\n", CLIOptionChunkedList)
+	}
+	return true, nil
+}
+
+func parseListFormat(format string) (*template.Template, error) {
+	if format == "" {
+		return nil, nil
+	}
+
+	// Escaped tabs and newlines are expanded for convenience on the command line
+	format = strings.NewReplacer(`\t`, "\t", `\n`, "\n").Replace(format)
+	tmpl, err := template.New("list").Parse(format)
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: %v", CLIOptionListFormat, err))
+	}
+	return tmpl, nil
 }
 
 func sorters(opts *Options) []string {
@@ -66,8 +207,24 @@ func sorters(opts *Options) []string {
 }
 
 func (g *Commands) ListMatches() error {
+	orderBy, err := parseOrderBy(g.opts.OrderBy, g.opts.Reverse)
+	if err != nil {
+		return err
+	}
+
+	format, err := parseListFormat(g.opts.ListFormat)
+	if err != nil {
+		return err
+	}
 
 	inTrash := trashed(g.opts.TypeMask)
+	chunked, err := g.chunkedList(orderBy)
+	if err != nil {
+		return err
+	}
+
+	jw := g.jsonListWriter()
+	defer jw.close()
 
 	mq := g.createMatchQuery(false)
 
@@ -109,6 +266,10 @@ func (g *Commands) ListMatches() error {
 				inTrash:  g.opts.InTrash,
 				mask:     g.opts.TypeMask,
 				sorters:  sorters(g.opts),
+				orderBy:  orderBy,
+				format:   format,
+				chunked:  chunked,
+				json:     jw,
 			}
 
 			traversalCount += 1
@@ -191,12 +352,29 @@ func (g *Commands) createMatchQuery(exactMatch bool) *matchQuery {
 func (g *Commands) List(byId bool) error {
 	var kvList []*keyValue
 
+	orderBy, err := parseOrderBy(g.opts.OrderBy, g.opts.Reverse)
+	if err != nil {
+		return err
+	}
+
 	resolver := g.rem.FindByPath
 	if byId {
 		resolver = g.rem.FindById
 	}
 
+	format, err := parseListFormat(g.opts.ListFormat)
+	if err != nil {
+		return err
+	}
+
 	mq := g.createMatchQuery(true)
+	chunked, err := g.chunkedList(orderBy)
+	if err != nil {
+		return err
+	}
+
+	jw := g.jsonListWriter()
+	defer jw.close()
 
 	for i, relPath := range g.opts.Sources {
 		r, rErr := resolver(relPath)
@@ -245,6 +423,10 @@ func (g *Commands) List(byId bool) error {
 			mask:       g.opts.TypeMask,
 			sorters:    sorters(g.opts),
 			matchQuery: mq,
+			orderBy:    orderBy,
+			format:     format,
+			chunked:    chunked,
+			json:       jw,
 		}
 
 		if !g.breadthFirst(travSt, spin) {
@@ -303,6 +485,11 @@ func (g *Commands) listSharedPerPath(relToRootPath string) ([]*keyValue, error)
 }
 
 func (g *Commands) ListShared() (err error) {
+	orderBy, err := parseOrderBy(g.opts.OrderBy, g.opts.Reverse)
+	if err != nil {
+		return err
+	}
+
 	spin := g.playabler()
 	spin.play()
 	defer spin.stop()
@@ -328,6 +515,7 @@ func (g *Commands) ListShared() (err error) {
 			headPath: kv.key,
 			inTrash:  g.opts.InTrash,
 			mask:     g.opts.TypeMask,
+			orderBy:  orderBy,
 		}
 
 		if !g.breadthFirst(travSt, spin) {
@@ -341,11 +529,44 @@ func (g *Commands) ListShared() (err error) {
 func (f *File) pretty(logy *log.Logger, opt attribute) {
 	fmtdPath := sepJoin("/", opt.parent, f.Name)
 
+	if opt.format != nil || opt.json != nil {
+		lf := listedFile{
+			Name:    f.Name,
+			Path:    fmtdPath,
+			Size:    f.Size,
+			ModTime: f.ModTime,
+			Id:      f.Id,
+			Mime:    f.MimeType,
+			Md5:     f.Md5Checksum,
+			IsDir:   f.IsDir,
+			Parents: opt.parentPaths,
+		}
+
+		if opt.json != nil {
+			if err := opt.json.write(&lf); err != nil {
+				logy.LogErrf("%s: %v\n", fmtdPath, err)
+			}
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := opt.format.Execute(&buf, lf); err != nil {
+			logy.LogErrf("%s: %v\n", fmtdPath, err)
+			return
+		}
+		logy.Logln(buf.String())
+		return
+	}
+
 	if opt.diskUsageOnly {
 		logy.Logf("%-12v %s\n", f.Size, fmtdPath)
 		return
 	}
 
+	if len(opt.parentPaths) > 1 {
+		fmtdPath = fmt.Sprintf("%s [parents: %s]", fmtdPath, strings.Join(opt.parentPaths, " & "))
+	}
+
 	if opt.minimal {
 		logy.Logf("%s", fmtdPath)
 	} else {
@@ -386,6 +607,8 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 		minimal:       isMinimal(g.opts.TypeMask),
 		diskUsageOnly: diskUsageOnly(g.opts.TypeMask),
 		mask:          travSt.mask,
+		format:        travSt.format,
+		json:          travSt.json,
 	}
 
 	opt.parent = ""
@@ -395,7 +618,7 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 
 	f := travSt.file
 	if !f.IsDir {
-		f.pretty(g.log, opt)
+		f.pretty(g.log, g.withParentPaths(f, opt))
 		return true
 	}
 
@@ -412,17 +635,6 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 		travSt.depth -= 1
 	}
 
-	expr := buildExpression(f.Id, travSt.mask, travSt.inTrash)
-
-	if travSt.matchQuery != nil {
-		exprExtra := travSt.matchQuery.Stringer()
-		expr = sepJoinNonEmpty(" and ", fmt.Sprintf("(%s)", expr), exprExtra)
-	}
-
-	req := g.rem.service.Files.List()
-	req.Q(expr)
-	req.MaxResults(g.opts.PageSize)
-
 	spin.pause()
 
 	canPrompt := !travSt.explicitNoPrompt
@@ -436,45 +648,9 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 
 	iterCount := uint64(0)
 
-	var collector []*File
+	var collector, children []*File
 
-	// We shouldn't prompt in between the same page otherwise we get
-	// spurious prompts. See Issue https://github.com/odeke-em/drive/issues/724.
-	// We'll only make the prompts in between children.
-	pagePair := reqDoPage(req, g.opts.Hidden, false)
-	errsChan := pagePair.errsChan
-	filesChan := pagePair.filesChan
-
-	working := true
-	for working {
-		select {
-		case err := <-errsChan:
-			if err != nil {
-				g.log.LogErrf("%v", err)
-				return false
-			}
-		case file, stillHasContent := <-filesChan:
-			if !stillHasContent {
-				working = false
-				break
-			}
-			if file == nil {
-				return false
-			}
-
-			if !isHidden(file.Name, g.opts.Hidden) {
-				collector = append(collector, file)
-			}
-
-		}
-	}
-
-	if len(travSt.sorters) >= 1 {
-		collector = g.sort(collector, travSt.sorters...)
-	}
-
-	var children []*File
-	for _, file := range collector {
+	printFile := func(file *File) {
 		if file.IsDir {
 			children = append(children, file)
 		}
@@ -484,12 +660,42 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 		// would result in incorrect traversal since non-folders don't have children.
 		// Just don't print it, however, the folder will still be explored.
 		if onlyFiles && file.IsDir {
-			continue
+			return
 		}
-		file.pretty(g.log, opt)
+		file.pretty(g.log, g.withParentPaths(file, opt))
 		iterCount += 1
 	}
 
+	found := func(file *File) {
+		if travSt.chunked {
+			printFile(file)
+		} else {
+			collector = append(collector, file)
+		}
+	}
+
+	if pf := travSt.prefetched; pf != nil {
+		<-pf.done
+		if !pf.ok {
+			return false
+		}
+		for _, file := range pf.files {
+			found(file)
+		}
+	} else if !g.listFolderPages(g.childrenListCall(f, travSt), found) {
+		return false
+	}
+
+	if len(travSt.sorters) >= 1 {
+		collector = g.sort(collector, travSt.sorters...)
+	}
+
+	collector = travSt.orderBy.sortLocally(collector)
+
+	for _, file := range collector {
+		printFile(file)
+	}
+
 	if !travSt.inTrash && !g.opts.InTrash {
 		// We'll only prompt when traversing children to avoid
 		// spurious prompts that result from asynchronous paging
@@ -500,8 +706,9 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 			return false
 		}
 
-		for _, file := range children {
-			childSt := traversalSt{
+		childSts := make([]traversalSt, len(children))
+		for i, file := range children {
+			childSts[i] = traversalSt{
 				depth:            travSt.depth,
 				file:             file,
 				headPath:         opt.parent,
@@ -510,8 +717,19 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 				explicitNoPrompt: travSt.explicitNoPrompt,
 				sorters:          travSt.sorters,
 				matchQuery:       travSt.matchQuery,
+				orderBy:          travSt.orderBy,
+				format:           travSt.format,
+				chunked:          travSt.chunked,
+				json:             travSt.json,
+			}
+
+			// The children are still printed in order, only their listings are fetched ahead
+			if g.listSlots != nil && childSts[i].depth != 0 {
+				childSts[i].prefetched = g.prefetchFolder(g.childrenListCall(file, childSts[i]))
 			}
+		}
 
+		for _, childSt := range childSts {
 			if !g.breadthFirst(childSt, spin) {
 				return false
 			}
@@ -522,6 +740,61 @@ func (g *Commands) breadthFirst(travSt traversalSt, spin *playable) bool {
 	return iterCount >= 1
 }
 
+// childrenListCall returns the request that lists the children of f.
+func (g *Commands) childrenListCall(f *File, travSt traversalSt) *drive.FilesListCall {
+	expr := buildExpression(f.Id, travSt.mask, travSt.inTrash)
+
+	if travSt.matchQuery != nil {
+		exprExtra := travSt.matchQuery.Stringer()
+		expr = sepJoinNonEmpty(" and ", fmt.Sprintf("(%s)", expr), exprExtra)
+	}
+
+	req := g.rem.service.Files.List()
+	req.Q(expr)
+	req.MaxResults(g.opts.PageSize)
+	if travSt.orderBy != nil && travSt.orderBy.apiOrderBy != "" {
+		req.OrderBy(travSt.orderBy.apiOrderBy)
+	}
+	return req
+}
+
+// listFolderPages hands the files that req lists to found as each page
+// arrives, reporting whether the listing succeeded.
+func (g *Commands) listFolderPages(req *drive.FilesListCall, found func(*File)) bool {
+	// We shouldn't prompt in between the same page otherwise we get
+	// spurious prompts. See Issue https://github.com/odeke-em/drive/issues/724.
+	// We'll only make the prompts in between children.
+	pagePair := reqDoPage(req, g.opts.Hidden, false)
+	errsChan := pagePair.errsChan
+	filesChan := pagePair.filesChan
+
+	ok := true
+	working := true
+	for working {
+		select {
+		case err := <-errsChan:
+			if err != nil {
+				g.log.LogErrf("%v", err)
+				ok = false
+			}
+		case file, stillHasContent := <-filesChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if file == nil {
+				ok = false
+				continue
+			}
+
+			if ok && !isHidden(file.Name, g.opts.Hidden) {
+				found(file)
+			}
+		}
+	}
+	return ok
+}
+
 func diskUsageOnly(mask int) bool {
 	return (mask & DiskUsageOnly) != 0
 }
@@ -0,0 +1,84 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+)
+
+// localChecksumAlgos are the algorithms that the checksums of pulled files
+// can be recorded with. Transfers are always verified with the remote md5.
+var localChecksumAlgos = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha256": sha256.New,
+}
+
+// validateLocalChecksumAlgo checks that algo, if set, is a known algorithm.
+func validateLocalChecksumAlgo(algo string) error {
+	if _, ok := localChecksumAlgos[algo]; algo == "" || ok {
+		return nil
+	}
+
+	var known []string
+	for name := range localChecksumAlgos {
+		known = append(known, name)
+	}
+	sort.Strings(known)
+	return invalidArgumentsErr(fmt.Errorf("unknown checksum algorithm %q, expecting one of %s", algo, strings.Join(known, ", ")))
+}
+
+// localChecksumHasher returns a hash for the checksums of pulled
+// files if they should be recorded, otherwise it returns nil.
+func (g *Commands) localChecksumHasher() hash.Hash {
+	newHash, ok := localChecksumAlgos[g.opts.LocalChecksumAlgo]
+	if !ok {
+		return nil
+	}
+	return newHash()
+}
+
+// recordLocalChecksums merges the checksums of the files that
+// were just pulled into the manifest of earlier pulls.
+func (g *Commands) recordLocalChecksums() {
+	algo := g.opts.LocalChecksumAlgo
+	paths, checksums := g.localChecksums.sorted()
+	if algo == "" || len(paths) < 1 {
+		return
+	}
+
+	sums, err := g.context.ReadLocalChecksums(algo)
+	if err != nil {
+		g.log.LogErrf("%s: reading %v\n", g.context.LocalChecksumsPath(algo), err)
+		return
+	}
+
+	for _, p := range paths {
+		sums[p] = checksums[p]
+	}
+
+	if err := g.context.WriteLocalChecksums(algo, sums); err != nil {
+		g.log.LogErrf("%s: writing %v\n", g.context.LocalChecksumsPath(algo), err)
+		return
+	}
+
+	if !g.opts.SummaryOnly {
+		g.log.Logf("Recorded the %s checksums of %d file(s) in %s\n", algo, len(paths), g.context.LocalChecksumsPath(algo))
+	}
+}
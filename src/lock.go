@@ -0,0 +1,158 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const lockRetryInterval = time.Second
+
+// lockHolder describes the process that holds the lock of a context.
+type lockHolder struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+}
+
+// stale reports whether the holder crashed without releasing the lock. The
+// processes of other hosts, e.g of a context on a shared mount, can't be
+// checked so their locks are never considered stale.
+func (lh *lockHolder) stale(host string) bool {
+	return lh.Host == host && !processAlive(lh.PID)
+}
+
+// readLockHolder returns the holder of the lock at p, along with the
+// content of the lock that it was read from, even if it is unreadable.
+func readLockHolder(p string) (holder *lockHolder, data []byte, err error) {
+	data, err = ioutil.ReadFile(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	holder = &lockHolder{}
+	if err := json.Unmarshal(data, holder); err != nil {
+		return nil, data, err
+	}
+	return holder, data, nil
+}
+
+// removeStaleLock removes the lock at p if it still is the one that was
+// found stale with data, reporting whether it did. Another waiter could have
+// found it stale too and already replaced it with its own lock, which has
+// to be left in place as it is now held.
+func removeStaleLock(p string, data []byte) (removed bool, err error) {
+	current, err := ioutil.ReadFile(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return false, err
+	}
+	if !bytes.Equal(current, data) {
+		return false, nil
+	}
+
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	return true, nil
+}
+
+// tryLock creates the lock at p, with the details of the holder. The lock is
+// written to a temporary file first and then linked into place, so that it
+// is only ever observed fully written, and created atomically.
+func tryLock(p string, holder *lockHolder) (acquired bool, err error) {
+	data, err := json.Marshal(holder)
+	if err != nil {
+		return false, err
+	}
+
+	tmpPath := fmt.Sprintf("%s.%d.tmp", p, holder.PID)
+	if err := ioutil.WriteFile(tmpPath, data, 0644); err != nil {
+		return false, err
+	}
+	defer os.Remove(tmpPath)
+
+	err = os.Link(tmpPath, p)
+	if err == nil {
+		return true, nil
+	}
+	if _, sErr := os.Lstat(p); sErr == nil {
+		return false, nil
+	}
+	return false, err
+}
+
+// acquireLock takes the lock at p, waiting for it to be released if
+// wait is set, otherwise failing right away if it is already held.
+func acquireLock(p string, wait bool, logf func(string, ...interface{})) (release func(), err error) {
+	host, _ := os.Hostname()
+	holder := &lockHolder{PID: os.Getpid(), Host: host, Started: time.Now().UTC()}
+
+	waiting := false
+	for {
+		acquired, err := tryLock(p, holder)
+		if err != nil {
+			return nil, err
+		}
+		if acquired {
+			return func() { os.Remove(p) }, nil
+		}
+
+		current, data, rErr := readLockHolder(p)
+		switch {
+		case rErr != nil && os.IsNotExist(rErr):
+			// Released in the meantime
+			continue
+		case rErr != nil && data == nil:
+			return nil, rErr
+		case rErr != nil || current.stale(host):
+			// Locks are only observed fully written, so an unreadable one wasn't written by drive
+			removed, err := removeStaleLock(p, data)
+			if err != nil {
+				return nil, err
+			}
+			if removed {
+				logf("removed the stale lock %s\n", p)
+			}
+			continue
+		}
+
+		if !wait {
+			return nil, contextLockedErr(fmt.Errorf("another drive operation is in progress, by pid %d on %s since %v, pass in `-%s` to wait for it", current.PID, current.Host, current.Started.Local().Format(time.RFC3339), CLIOptionWaitForLock))
+		}
+
+		if !waiting {
+			logf("waiting for the drive operation by pid %d on %s to finish\n", current.PID, current.Host)
+			waiting = true
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// lockContext takes the lock of the context for the duration of an
+// operation that changes its state, such as a push or pull.
+func (g *Commands) lockContext() (release func(), err error) {
+	if g.context == nil {
+		return func() {}, nil
+	}
+	return acquireLock(g.context.LockPath(), g.opts.WaitForLock, g.log.Logf)
+}
@@ -0,0 +1,181 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+)
+
+func newMetadataEntry(relToRootPath string, f *File) *config.MetadataEntry {
+	return &config.MetadataEntry{
+		Path:        relToRootPath,
+		Id:          f.Id,
+		Size:        f.Size,
+		Md5Checksum: f.Md5Checksum,
+		ModTime:     f.ModTime.UTC().Format(time.RFC3339),
+		MimeType:    f.MimeType,
+	}
+}
+
+// underAnyOf reports whether p is one of prefixes or is within one of them.
+func underAnyOf(p string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if rootLike(prefix) || p == prefix || strings.HasPrefix(p, strings.TrimSuffix(prefix, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeMetadataIndex replaces the entries of index that are under any
+// of the sources with fresh, returning the result sorted by path.
+func mergeMetadataIndex(index, fresh []*config.MetadataEntry, sources []string) []*config.MetadataEntry {
+	var merged []*config.MetadataEntry
+	for _, entry := range index {
+		if entry != nil && !underAnyOf(entry.Path, sources) {
+			merged = append(merged, entry)
+		}
+	}
+	merged = append(merged, fresh...)
+
+	byPath := make(map[string]*config.MetadataEntry)
+	var paths []string
+	for _, entry := range merged {
+		if _, seen := byPath[entry.Path]; !seen {
+			paths = append(paths, entry.Path)
+		}
+		byPath[entry.Path] = entry
+	}
+	sort.Strings(paths)
+
+	sorted := make([]*config.MetadataEntry, 0, len(paths))
+	for _, p := range paths {
+		sorted = append(sorted, byPath[p])
+	}
+	return sorted
+}
+
+// pullMetadata indexes the remote files under the sources without
+// downloading any of their content, for them to be pulled on demand.
+func (g *Commands) pullMetadata() (err error) {
+	var fresh []*config.MetadataEntry
+	for _, relToRootPath := range g.opts.Sources {
+		f, fErr := g.rem.FindByPath(relToRootPath)
+		if fErr == nil && f == nil {
+			fErr = ErrPathNotExists
+		}
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+			continue
+		}
+
+		entries, iErr := g.indexMetadata(relToRootPath, f, g.opts.Depth)
+		if iErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, iErr))
+		}
+		fresh = append(fresh, entries...)
+	}
+
+	index, rErr := g.context.ReadMetadataIndex()
+	if rErr != nil {
+		return reComposeError(err, fmt.Sprintf("reading the metadata index: %v", rErr))
+	}
+
+	index = mergeMetadataIndex(index, fresh, g.opts.Sources)
+	if wErr := g.context.WriteMetadataIndex(index); wErr != nil {
+		return reComposeError(err, fmt.Sprintf("writing the metadata index: %v", wErr))
+	}
+
+	g.log.Logf("indexed %d file(s), %d in total, in %s\n", len(fresh), len(index), g.context.MetadataIndexPath())
+	return err
+}
+
+func (g *Commands) indexMetadata(relToRootPath string, f *File, depth int) (entries []*config.MetadataEntry, err error) {
+	if anyMatch(g.opts.Ignorer, path.Base(relToRootPath), relToRootPath) {
+		return nil, nil
+	}
+
+	if !f.IsDir {
+		return []*config.MetadataEntry{newMetadataEntry(relToRootPath, f)}, nil
+	}
+
+	childDepth := decrementTraversalDepth(depth)
+	if childDepth == 0 {
+		return nil, nil
+	}
+
+	var subFolders []*File
+	pagePair := g.rem.FindByParentId(f.Id, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child == nil {
+				continue
+			}
+
+			childPath := remotePathJoin(relToRootPath, child.Name)
+			if anyMatch(g.opts.Ignorer, child.Name, childPath) {
+				continue
+			}
+			if g.skipNotOwned(childPath, child) {
+				continue
+			}
+
+			if g.opts.ExcludeGoogleDocs && googleNative(child) {
+				continue
+			}
+
+			if !child.IsDir {
+				entries = append(entries, newMetadataEntry(childPath, child))
+			} else if g.opts.Recursive {
+				subFolders = append(subFolders, child)
+			}
+		}
+	}
+
+	if listErr != nil {
+		return entries, listErr
+	}
+
+	for _, subFolder := range subFolders {
+		subPath := remotePathJoin(relToRootPath, subFolder.Name)
+		found, iErr := g.indexMetadata(subPath, subFolder, childDepth)
+		if iErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", subPath, iErr))
+		}
+		entries = append(entries, found...)
+	}
+
+	return entries, err
+}
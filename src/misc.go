@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime"
+	"net/http"
 	"os"
 	"path"
 	"path/filepath"
@@ -112,12 +114,22 @@ func (cjs *changeJobSt) changeJober(g *Commands) func() (interface{}, error) {
 		ch := cjs.change
 		verb := cjs.verb
 
-		canPrintSteps := g.opts.Verbose && g.opts.canPreview()
+		canPrintSteps := g.opts.Verbose && g.opts.canPreview() && !g.opts.SummaryOnly
 		if canPrintSteps {
 			g.log.Logf("\033[01m%s::Started %s\033[00m\n", verb, ch.Path)
 		}
 
+		transfersContent := ch.Src != nil && !ch.Src.IsDir
+		if transfersContent {
+			g.progressJSON.fileStarted(ch.Path, ch.Src.Size)
+		}
+
 		err := cjs.fn(ch)
+		g.summary.record(ch, err)
+
+		if transfersContent {
+			g.progressJSON.fileDone(ch.Path)
+		}
 
 		if canPrintSteps {
 			g.log.Logf("\033[04m%s::Done %s\033[00m\n", verb, ch.Path)
@@ -160,6 +172,11 @@ func retryableErrorCheck(v interface{}) (ok, retryable bool) {
 		return
 	}
 
+	if isDailyUploadLimitErr(err) {
+		retryable = false
+		return
+	}
+
 	statusCode := err.Code
 	if statusCode >= 500 && statusCode <= 599 {
 		retryable = true
@@ -270,6 +287,39 @@ func memoizeBytes() byteDescription {
 
 var prettyBytes = memoizeBytes()
 
+var byteSizeMultipliers = map[string]float64{
+	"":  1,
+	"B": 1,
+	"K": BytesPerKB,
+	"M": BytesPerKB * BytesPerKB,
+	"G": BytesPerKB * BytesPerKB * BytesPerKB,
+	"T": BytesPerKB * BytesPerKB * BytesPerKB * BytesPerKB,
+	"P": BytesPerKB * BytesPerKB * BytesPerKB * BytesPerKB * BytesPerKB,
+}
+
+// ParseByteSize parses sizes such as 512, 100K, 1.5GB or 2GiB into bytes.
+func ParseByteSize(size string) (int64, error) {
+	str := strings.ToUpper(strings.TrimSpace(size))
+	str = strings.TrimSuffix(strings.TrimSuffix(str, "IB"), "B")
+
+	i := len(str)
+	for i > 0 && (str[i-1] < '0' || str[i-1] > '9') && str[i-1] != '.' {
+		i--
+	}
+
+	multiplier, ok := byteSizeMultipliers[str[i:]]
+	if !ok {
+		return 0, invalidArgumentsErr(fmt.Errorf("size %q has an unknown unit", size))
+	}
+
+	n, err := strconv.ParseFloat(str[:i], 64)
+	if err != nil || n < 0 {
+		return 0, invalidArgumentsErr(fmt.Errorf("size %q is not a non-negative number of bytes", size))
+	}
+
+	return int64(n * multiplier), nil
+}
+
 func sepJoin(sep string, args ...string) string {
 	return strings.Join(args, sep)
 }
@@ -512,6 +562,22 @@ func nonEmptyStrings(fn func(string) string, v ...string) (splits []string) {
 	return
 }
 
+// ExpandPath expands a leading ~ to the home directory and references to
+// environment variables e.g $HOME/x in p, for paths that the shell didn't
+// expand such as quoted ones. References to unset variables are kept as is.
+func ExpandPath(p string) string {
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, "~"+string(os.PathSeparator)) {
+		p = HomeShellEnvKey + p[1:]
+	}
+
+	return os.Expand(p, func(key string) string {
+		if value, ok := os.LookupEnv(key); ok {
+			return value
+		}
+		return "$" + key
+	})
+}
+
 func NonEmptyStrings(v ...string) (splits []string) {
 	return nonEmptyStrings(nil, v...)
 }
@@ -580,11 +646,15 @@ func regMapper(srcMaps ...map[string]string) map[*regexp.Regexp]string {
 	return regMap
 }
 
+// cacher memoizes the lookups of regMap, which are case-insensitive
+// so that e.g .JPG and .jpg resolve to the same mimeType.
 func cacher(regMap map[*regexp.Regexp]string) func(string) string {
 	var cache = make(map[string]string)
 	var cacheMu sync.Mutex
 
 	return func(ext string) string {
+		ext = strings.ToLower(ext)
+
 		cacheMu.Lock()
 		defer cacheMu.Unlock()
 
@@ -702,10 +772,13 @@ func combineIgnores(ignoresPath string) (ignorer func(string) bool, err error) {
 }
 
 var mimeTypeFromQuery = cacher(regMapper(regExtStrMap, map[string]string{
-	"docs":   "application/vnd.google-apps.document",
-	"folder": DriveFolderMimeType,
-	"form":   "application/vnd.google-apps.form",
-	"mp4":    "video/mp4",
+	"docs":                 DriveDocumentMimeType,
+	"folder":               DriveFolderMimeType,
+	"form":                 DriveFormMimeType,
+	"mp4":                  "video/mp4",
+	"drawing":              DriveDrawingMimeType,
+	"jam|jamboard":         DriveJamboardMimeType,
+	"site":                 DriveSiteMimeType,
 	"slides?|presentation": "application/vnd.google-apps.presentation",
 	"sheet":                "application/vnd.google-apps.spreadsheet",
 	"script":               "application/vnd.google-apps.script",
@@ -718,6 +791,28 @@ func guessMimeType(p string) string {
 	return resolvedMimeType
 }
 
+// sniffMimeType detects the mimeType of the file at absPath from at most
+// its first 512 bytes. It returns "" if no specific mimeType was detected.
+func sniffMimeType(absPath string) string {
+	f, err := os.Open(absPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return ""
+	}
+
+	mimeType, _, err := mime.ParseMediaType(http.DetectContentType(head[:n]))
+	if err != nil || mimeType == OctetStreamMimeType {
+		return ""
+	}
+	return mimeType
+}
+
 func CrudAtoi(ops ...string) CrudValue {
 	opValue := None
 
@@ -1101,6 +1196,7 @@ const (
 	NonFolder
 	DiskUsageOnly
 	CurrentVersion
+	ParentsAsLabels
 )
 
 func folderExplicitly(mask int) bool    { return (mask & Folder) == Folder }
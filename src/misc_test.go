@@ -15,13 +15,34 @@
 package drive
 
 import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/odeke-em/drive/config"
+	expb "github.com/odeke-em/exponential-backoff"
+	"github.com/odeke-em/log"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/jwt"
+	drive "google.golang.org/api/drive/v2"
+	driveactivity "google.golang.org/api/driveactivity/v2"
 	"google.golang.org/api/googleapi"
 )
 
@@ -461,3 +482,2351 @@ func TestCrudToAtoi(t *testing.T) {
 		}
 	}
 }
+
+func TestParseOrderBy(t *testing.T) {
+	testCases := []struct {
+		keys           []string
+		reverse        bool
+		wantErr        bool
+		wantAPIOrderBy string
+	}{
+		{keys: nil, wantAPIOrderBy: ""},
+		{keys: []string{"name"}, wantAPIOrderBy: "title"},
+		{keys: []string{"folder", "name"}, wantAPIOrderBy: "folder,title"},
+		{keys: []string{"modifiedtime"}, reverse: true, wantAPIOrderBy: "modifiedDate desc"},
+		// size isn't supported by the API so must be sorted locally
+		{keys: []string{"folder", "size"}, wantAPIOrderBy: ""},
+		{keys: []string{"md5"}, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		ob, err := parseOrderBy(tc.keys, tc.reverse)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err=%v", i, err)
+			continue
+		}
+
+		gotAPIOrderBy := ""
+		if ob != nil {
+			gotAPIOrderBy = ob.apiOrderBy
+		}
+		if gotAPIOrderBy != tc.wantAPIOrderBy {
+			t.Errorf("#%d: apiOrderBy got=%q want=%q", i, gotAPIOrderBy, tc.wantAPIOrderBy)
+		}
+	}
+}
+
+func TestParseProperties(t *testing.T) {
+	testCases := []struct {
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{pairs: []string{"env=prod"}, want: map[string]string{"env": "prod"}},
+		{pairs: []string{"env=prod", "query=a=b"}, want: map[string]string{"env": "prod", "query": "a=b"}},
+		{pairs: []string{"empty="}, want: map[string]string{"empty": ""}},
+		{pairs: []string{"env"}, wantErr: true},
+		{pairs: []string{"=prod"}, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		got, err := ParseProperties(tc.pairs...)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err=%v", i, err)
+			continue
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2016, 11, 15, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		since   string
+		want    time.Time
+		wantErr bool
+	}{
+		{since: "2016-11-01T00:00:00Z", want: time.Date(2016, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{since: "7d", want: now.Add(-7 * 24 * time.Hour)},
+		{since: "2w", want: now.Add(-14 * 24 * time.Hour)},
+		{since: "36h", want: now.Add(-36 * time.Hour)},
+		{since: "1.5d", want: now.Add(-36 * time.Hour)},
+		{since: "yesterday", wantErr: true},
+		{since: "-7d", wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		got, err := ParseSince(tc.since, now)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: expected a non-nil error", i)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: err=%v", i, err)
+			continue
+		}
+
+		if !got.Equal(tc.want) {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	testCases := []struct {
+		size    string
+		want    int64
+		wantErr bool
+	}{
+		{size: "512", want: 512},
+		{size: "10B", want: 10},
+		{size: "100K", want: 100 * 1024},
+		{size: "100kb", want: 100 * 1024},
+		{size: "1.5G", want: 1536 * 1024 * 1024},
+		{size: "2GiB", want: 2 * 1024 * 1024 * 1024},
+		{size: "", wantErr: true},
+		{size: "MB", wantErr: true},
+		{size: "10X", wantErr: true},
+		{size: "-1K", wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		got, err := ParseByteSize(tc.size)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: %q expected a non-nil error", i, tc.size)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: %q err=%v", i, tc.size, err)
+			continue
+		}
+
+		if got != tc.want {
+			t.Errorf("#%d: %q got=%d want=%d", i, tc.size, got, tc.want)
+		}
+	}
+}
+
+func TestExpandPath(t *testing.T) {
+	defer os.Setenv("HOME", os.Getenv("HOME"))
+	os.Setenv("HOME", "/home/drive")
+	os.Setenv("DRIVE_TEST_DIR", "/mnt/gd")
+	os.Unsetenv("DRIVE_TEST_UNSET")
+
+	testCases := []struct {
+		path, want string
+	}{
+		{path: "~", want: "/home/drive"},
+		{path: "~/Documents", want: "/home/drive/Documents"},
+		{path: "$HOME/Documents", want: "/home/drive/Documents"},
+		{path: "${DRIVE_TEST_DIR}/x", want: "/mnt/gd/x"},
+		{path: "$DRIVE_TEST_UNSET/x", want: "$DRIVE_TEST_UNSET/x"},
+		{path: "~other/x", want: "~other/x"},
+		{path: "a/~/b", want: "a/~/b"},
+	}
+
+	for _, tc := range testCases {
+		if got := ExpandPath(tc.path); got != tc.want {
+			t.Errorf("%q: got=%q want=%q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestTransferSummary(t *testing.T) {
+	var ts transferSummary
+	changes := []*Change{
+		{Src: &File{Name: "a", Size: 1024}},
+		{Src: &File{Name: "b", Size: 2048}, Dest: &File{Name: "b", Size: 10}},
+		{Src: &File{Name: "dir", IsDir: true}},
+	}
+	for _, c := range changes {
+		ts.record(c, nil)
+	}
+	ts.record(&Change{Src: &File{Name: "c", Size: 4096}}, fmt.Errorf("quota exceeded"))
+
+	want := "2 created, 1 updated, 0 deleted, 1 skipped, 1 failed; transferred 3.00KB in 2s (1.50KB/s)"
+	if got := ts.String(1, 2*time.Second); got != want {
+		t.Errorf("got=%q\nwant=%q", got, want)
+	}
+}
+
+func TestNativeExportFormats(t *testing.T) {
+	drawing := &File{
+		MimeType: DriveDrawingMimeType,
+		ExportLinks: map[string]string{
+			"application/pdf": "https://example.com/pdf",
+			"image/svg+xml":   "https://example.com/svg",
+			"image/jpeg":      "https://example.com/jpeg",
+			"image/png":       "https://example.com/png",
+		},
+	}
+	form := &File{MimeType: DriveFormMimeType}
+
+	if got, want := nativeExportFormats(drawing), []string{"png", "jpg", "svg", "pdf"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("drawing formats got=%v want=%v", got, want)
+	}
+	if got := nativeExportFormats(form); len(got) != 0 {
+		t.Errorf("form formats got=%v want none", got)
+	}
+
+	testCases := []struct {
+		f       *File
+		exports []string
+		want    string
+	}{
+		{f: form, want: "it is a Google Form, which has no export formats"},
+		{f: drawing, want: "it is a Google Drawing, which can only be exported as png, jpg, svg, pdf"},
+		{
+			f: drawing, exports: []string{"docx"},
+			want: "none of the export formats [docx] are available, a Google Drawing can be exported as png, jpg, svg, pdf",
+		},
+	}
+
+	for i, tc := range testCases {
+		if got := nativeSkipReason(tc.f, tc.exports); got != tc.want {
+			t.Errorf("#%d: got=%q want=%q", i, got, tc.want)
+		}
+	}
+}
+
+func TestCopyTitle(t *testing.T) {
+	at := time.Date(2016, 2, 3, 8, 12, 15, 0, time.UTC)
+	testCases := []struct {
+		title, want string
+	}{
+		{title: "report.docx", want: "report (copy 2016-02-03 08.12.15).docx"},
+		{title: "notes", want: "notes (copy 2016-02-03 08.12.15)"},
+		{title: "archive.tar.gz", want: "archive.tar (copy 2016-02-03 08.12.15).gz"},
+	}
+
+	for _, tc := range testCases {
+		if got := copyTitle(tc.title, at); got != tc.want {
+			t.Errorf("%q: got=%q want=%q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestBandwidthSchedule(t *testing.T) {
+	rules, err := ParseBandwidthSchedule("09:00-17:00:512K, 22:00-06:00:0,17:00-22:00:1M")
+	if err != nil {
+		t.Fatalf("parsing schedule err=%v", err)
+	}
+
+	testCases := []struct {
+		clock string
+		want  int64
+	}{
+		{clock: "09:00", want: 512 * 1024},
+		{clock: "16:59", want: 512 * 1024},
+		{clock: "17:00", want: 1024 * 1024},
+		{clock: "23:30", want: 0},
+		{clock: "03:00", want: 0},
+		// Times that no rule covers are unlimited
+		{clock: "07:00", want: 0},
+	}
+
+	for _, tc := range testCases {
+		now, _ := time.Parse("15:04", tc.clock)
+		if got := scheduledRate(rules, now); got != tc.want {
+			t.Errorf("%s: got=%d want=%d", tc.clock, got, tc.want)
+		}
+	}
+
+	for _, schedule := range []string{"09:00:512K", "9-17:512K", "09:00-17:00:fast", "25:00-17:00:1K"} {
+		if _, err := ParseBandwidthSchedule(schedule); err == nil {
+			t.Errorf("%q expected a non-nil error", schedule)
+		}
+	}
+}
+
+func TestParseChangeSelection(t *testing.T) {
+	testCases := []struct {
+		selection string
+		n         int
+		want      []int
+		wantErr   bool
+	}{
+		{selection: "", n: 3},
+		{selection: "2", n: 3, want: []int{2}},
+		{selection: "3,7,9", n: 10, want: []int{3, 7, 9}},
+		{selection: " 1, 3-5 ", n: 5, want: []int{1, 3, 4, 5}},
+		{selection: "0", n: 3, wantErr: true},
+		{selection: "4", n: 3, wantErr: true},
+		{selection: "3-1", n: 3, wantErr: true},
+		{selection: "a,b", n: 3, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		got, err := ParseChangeSelection(tc.selection, tc.n)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: %q expected a non-nil error", i, tc.selection)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("#%d: %q err=%v", i, tc.selection, err)
+			continue
+		}
+
+		if len(got) != len(tc.want) {
+			t.Errorf("#%d: %q got=%v want=%v", i, tc.selection, got, tc.want)
+			continue
+		}
+		for _, k := range tc.want {
+			if !got[k] {
+				t.Errorf("#%d: %q expected %d to be selected", i, tc.selection, k)
+			}
+		}
+	}
+}
+
+func TestLocalName(t *testing.T) {
+	rules, err := ParseRenameMap(strings.NewReader(`
+# Dates are written with dots
+(\d{4})-(\d{2})-(\d{2}) => $1.$2.$3
+
+\s+$ =>
+`))
+	if err != nil {
+		t.Fatalf("parsing the rename map: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		goos string
+		want string
+	}{
+		{name: "notes.txt", goos: OSLinuxKey, want: "notes.txt"},
+		{name: "2016-11-01 log.txt", goos: OSLinuxKey, want: "2016.11.01 log.txt"},
+		{name: "a/b", goos: OSLinuxKey, want: "a%2Fb"},
+		{name: "a:b", goos: OSLinuxKey, want: "a:b"},
+		{name: "a:b?", goos: OSWindowsKey, want: "a_b_"},
+		{name: "trailing  ", goos: OSLinuxKey, want: "trailing"},
+		{name: "..", goos: OSLinuxKey, want: "_.."},
+	}
+
+	for i, tc := range testCases {
+		got := localName(tc.name, append(rules, DefaultRenameRules(tc.goos, "")...))
+		if got != tc.want {
+			t.Errorf("#%d: %q on %s got=%q want=%q", i, tc.name, tc.goos, got, tc.want)
+		}
+	}
+
+	for _, name := range []string{"a/b", "a%2Fb"} {
+		if got, want := localName(name, DefaultRenameRules(OSLinuxKey, "_")), "a_b"; got != want {
+			t.Errorf("%q custom slash replacement got=%q want=%q", name, got, want)
+		}
+	}
+
+	if _, err := ParseRenameMap(strings.NewReader("no separator")); err == nil {
+		t.Errorf("expected a non-nil error for a line without a separator")
+	}
+	if _, err := ParseRenameMap(strings.NewReader("( => x")); err == nil {
+		t.Errorf("expected a non-nil error for an invalid regex")
+	}
+}
+
+func TestDiffStatus(t *testing.T) {
+	older := time.Date(2016, 2, 3, 8, 12, 15, 0, time.UTC)
+	newer := older.Add(time.Hour)
+
+	file := func(size int64, modTime time.Time, md5 string) *File {
+		return &File{Size: size, ModTime: modTime, Md5Checksum: md5}
+	}
+
+	testCases := []struct {
+		local, remote *File
+		wantStatus    string
+		wantMd5Match  string
+	}{
+		{local: file(10, older, "a"), wantStatus: DiffStatusLocalOnly},
+		{remote: file(10, older, "a"), wantStatus: DiffStatusRemoteOnly},
+		{local: file(10, older, "a"), remote: file(10, older, "a"), wantStatus: DiffStatusSame, wantMd5Match: "true"},
+		{local: file(10, newer, "a"), remote: file(10, older, "a"), wantStatus: DiffStatusLocalNewer, wantMd5Match: "true"},
+		{local: file(12, older, "b"), remote: file(10, newer, "a"), wantStatus: DiffStatusRemoteNewer, wantMd5Match: "false"},
+		{local: file(12, older, "b"), remote: file(10, older, "a"), wantStatus: DiffStatusDiffers, wantMd5Match: "false"},
+		{local: file(10, older, "a"), remote: file(10, older, ""), wantStatus: DiffStatusSame, wantMd5Match: ""},
+	}
+
+	for i, tc := range testCases {
+		row := diffReportRow(&Change{Path: "/a", Src: tc.local, Dest: tc.remote}, true)
+		if len(row) != len(diffReportHeader) {
+			t.Fatalf("#%d: got %d columns want %d", i, len(row), len(diffReportHeader))
+		}
+		if got := row[1]; got != tc.wantStatus {
+			t.Errorf("#%d: status got=%q want=%q", i, got, tc.wantStatus)
+		}
+		if got := row[6]; got != tc.wantMd5Match {
+			t.Errorf("#%d: md5 match got=%q want=%q", i, got, tc.wantMd5Match)
+		}
+	}
+}
+
+func TestFileModeRoundTrip(t *testing.T) {
+	if runtime.GOOS == OSWindowsKey {
+		t.Skip("permission bits are not supported on windows")
+	}
+
+	f, err := ioutil.TempFile("", "drive-mode")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	if err := os.Chmod(f.Name(), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	properties, err := fileModeProperties(f.Name(), map[string]string{"k": "v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := properties[FileModeProperty], "0755"; got != want {
+		t.Fatalf("recorded mode got=%q want=%q", got, want)
+	}
+
+	remote := &File{Properties: toDriveProperties(properties)}
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	g := &Commands{opts: &Options{PreserveMode: true}}
+	if err := g.restoreFileMode(remote, f.Name()); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := fi.Mode().Perm(), os.FileMode(0755); got != want {
+		t.Errorf("restored mode got=%v want=%v", got, want)
+	}
+
+	if _, ok := remoteFileMode(&File{}); ok {
+		t.Errorf("expected no mode for a file without the property")
+	}
+}
+
+func TestMergeMetadataIndex(t *testing.T) {
+	entry := func(p, id string) *config.MetadataEntry {
+		return &config.MetadataEntry{Path: p, Id: id}
+	}
+
+	index := []*config.MetadataEntry{
+		entry("/a/old.txt", "1"), entry("/ab/kept.txt", "2"), entry("/z.txt", "3"),
+	}
+	fresh := []*config.MetadataEntry{entry("/a/new.txt", "4"), entry("/z.txt", "5")}
+
+	merged := mergeMetadataIndex(index, fresh, []string{"/a", "/z.txt"})
+
+	var got []string
+	for _, e := range merged {
+		got = append(got, e.Path+"="+e.Id)
+	}
+	want := []string{"/a/new.txt=4", "/ab/kept.txt=2", "/z.txt=5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got=%v want=%v", got, want)
+	}
+
+	if merged := mergeMetadataIndex(index, fresh, []string{"/"}); len(merged) != len(fresh) {
+		t.Errorf("indexing the root should replace the whole index, got %d entries", len(merged))
+	}
+}
+
+func TestParseRestrictions(t *testing.T) {
+	if rs, err := ParseRestrictions("", " "); err != nil || rs != nil {
+		t.Errorf("unset restrictions got=%v err=%v want nil", rs, err)
+	}
+	if _, err := ParseRestrictions("maybe", ""); err == nil {
+		t.Errorf("expected a non-nil error for a value that is neither true nor false")
+	}
+
+	rs, err := ParseRestrictions("false", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := restrictionsPatch(rs)
+	if !patch.CopyRequiresWriterPermission || patch.Labels == nil || !patch.Labels.Restricted {
+		t.Errorf("viewers that can't copy should require writer permission to copy, got %+v", patch)
+	}
+	if got, want := patch.ForceSendFields, []string{"CopyRequiresWriterPermission"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("forced fields got=%v want=%v", got, want)
+	}
+
+	rs, err = ParseRestrictions("", "false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if patch := restrictionsPatch(rs); patch.WritersCanShare || patch.Labels != nil ||
+		!reflect.DeepEqual(patch.ForceSendFields, []string{"WritersCanShare"}) {
+		t.Errorf("only writersCanShare=false should be sent, got %+v", patch)
+	}
+}
+
+func TestGoogleNative(t *testing.T) {
+	testCases := []struct {
+		file *File
+		want bool
+	}{
+		{file: &File{MimeType: DriveDocumentMimeType}, want: true},
+		{file: &File{MimeType: DriveFormMimeType}, want: true},
+		{file: &File{MimeType: DriveFolderMimeType, IsDir: true}, want: false},
+		{file: &File{MimeType: "application/pdf"}, want: false},
+		{file: nil, want: false},
+	}
+
+	for i, tc := range testCases {
+		if got := googleNative(tc.file); got != tc.want {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
+
+func TestTrashTarget(t *testing.T) {
+	src, dest := &File{Id: "src"}, &File{Id: "dest"}
+	change := &Change{Src: src, Dest: dest}
+
+	testCases := []struct {
+		opt      trashOpt
+		wantName string
+		want     *File
+	}{
+		{opt: trashOpt{toTrash: true}, wantName: "trash", want: dest},
+		{opt: trashOpt{toTrash: true, permanent: true}, wantName: "delete", want: dest},
+		{opt: trashOpt{}, wantName: "untrash", want: src},
+	}
+
+	for i, tc := range testCases {
+		if got := tc.opt.name(); got != tc.wantName {
+			t.Errorf("#%d: name got=%q want=%q", i, got, tc.wantName)
+		}
+		if got := trashTarget(change, &tc.opt); got != tc.want {
+			t.Errorf("#%d: target got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
+
+func TestLocalChecksumsRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-checksums")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	context := &config.Context{ConfigDir: dir}
+	sums := map[string]string{
+		"/a/b.txt":      "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"/with space.c": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+	}
+	if err := context.WriteLocalChecksums("sha256", sums); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := context.ReadLocalChecksums("sha256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, sums) {
+		t.Errorf("got=%v want=%v", got, sums)
+	}
+
+	if err := validateLocalChecksumAlgo("sha256"); err != nil {
+		t.Errorf("sha256 should be valid, got %v", err)
+	}
+	if err := validateLocalChecksumAlgo("crc32"); err == nil {
+		t.Errorf("crc32 should be rejected")
+	}
+}
+
+func TestCommentFormatting(t *testing.T) {
+	authors := []struct {
+		user *drive.User
+		want string
+	}{
+		{user: nil, want: "unknown"},
+		{user: &drive.User{DisplayName: "Jo"}, want: "Jo"},
+		{user: &drive.User{EmailAddress: "jo@example.com"}, want: "jo@example.com"},
+		{user: &drive.User{DisplayName: "Jo", EmailAddress: "jo@example.com"}, want: "Jo <jo@example.com>"},
+	}
+	for i, tc := range authors {
+		if got := commentAuthor(tc.user); got != tc.want {
+			t.Errorf("#%d: author got=%q want=%q", i, got, tc.want)
+		}
+	}
+
+	if got, want := commentTime("not a time"), "not a time"; got != want {
+		t.Errorf("unparseable times should be kept, got=%q want=%q", got, want)
+	}
+	if got, want := indentLines("first\nsecond\n", "  "), "  first\n  second"; got != want {
+		t.Errorf("indented got=%q want=%q", got, want)
+	}
+}
+
+func TestNotAFolderErr(t *testing.T) {
+	err := notAFolderErr("/a/b", &File{Id: "0fM9", MimeType: "text/plain"})
+
+	e, ok := err.(*Error)
+	if !ok || e.code != StatusMkdirFailed {
+		t.Fatalf("expected a mkdir failure, got %#v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "/a/b") || !strings.Contains(msg, "0fM9") {
+		t.Errorf("expected the conflicting path and its id in %q", msg)
+	}
+}
+
+func TestUnflattenPath(t *testing.T) {
+	dirs := map[string]string{
+		"/a_b_c":       "/a/b/c",
+		"/a_b_c/d_e":   "/a/b/c/d/e",
+		"/shared/x_y":  "/shared/x/y",
+		"/shared/x_yz": "/shared/x/yz",
+	}
+
+	testCases := []struct {
+		p, want string
+	}{
+		{p: "/a_b_c", want: "/a/b/c"},
+		{p: "/a_b_c/file.txt", want: "/a/b/c/file.txt"},
+		{p: "/a_b_c/d_e/f", want: "/a/b/c/d/e/f"},
+		{p: "/a_b_cd", want: "/a_b_cd"},
+		{p: "/shared/x_yz/1", want: "/shared/x/yz/1"},
+		{p: "/other", want: "/other"},
+	}
+
+	for i, tc := range testCases {
+		if got := unflattenPath(tc.p, dirs); got != tc.want {
+			t.Errorf("#%d: %q got=%q want=%q", i, tc.p, got, tc.want)
+		}
+	}
+}
+
+func TestRefreshingTransportRetries401(t *testing.T) {
+	var refreshes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			refreshes += 1
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`)
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			fmt.Fprint(w, "ok")
+		}
+	}))
+	defer server.Close()
+
+	var saved *oauth2.Token
+	source := &refreshingTokenSource{
+		ctx:          oauth2.NoContext,
+		conf:         &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL + "/token"}},
+		refreshToken: "refresh",
+		token:        &oauth2.Token{AccessToken: "stale", Expiry: time.Now().Add(time.Hour)},
+		save: func(token *oauth2.Token) error {
+			saved = token
+			return nil
+		},
+	}
+
+	client := &http.Client{Transport: &refreshingTransport{source: source, base: http.DefaultTransport}}
+	res, err := client.Get(server.URL + "/files")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		t.Errorf("expected the retry with the refreshed token to succeed, got %d", res.StatusCode)
+	}
+	if refreshes != 1 {
+		t.Errorf("expected exactly one refresh, got %d", refreshes)
+	}
+	if saved == nil || saved.AccessToken != "fresh" || saved.RefreshToken != "refresh" {
+		t.Errorf("expected the refreshed token to be saved, got %#v", saved)
+	}
+}
+
+func TestRefreshingTransportReplaysBodies(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"access_token":"fresh","token_type":"Bearer","expires_in":3600}`)
+		case r.Header.Get("Authorization") != "Bearer fresh":
+			ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			io.Copy(w, r.Body)
+		}
+	}))
+	defer server.Close()
+
+	newClient := func() *http.Client {
+		source := &refreshingTokenSource{
+			ctx:          oauth2.NoContext,
+			conf:         &oauth2.Config{Endpoint: oauth2.Endpoint{TokenURL: server.URL + "/token"}},
+			refreshToken: "refresh",
+			token:        &oauth2.Token{AccessToken: "stale", Expiry: time.Now().Add(time.Hour)},
+			save:         func(*oauth2.Token) error { return nil },
+		}
+		return &http.Client{Transport: &refreshingTransport{source: source, base: http.DefaultTransport}}
+	}
+
+	res, err := newClient().Post(server.URL+"/files", "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || string(body) != "payload" {
+		t.Errorf("expected the body to be replayed with the refreshed token, got %d %q", res.StatusCode, body)
+	}
+
+	// A body that can't be gotten anew is left to the retries of the operation
+	req, err := http.NewRequest("POST", server.URL+"/files", ioutil.NopCloser(strings.NewReader("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.GetBody = nil
+	res, err = newClient().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected the 401 to be returned, got %d", res.StatusCode)
+	}
+}
+
+func TestRefreshErr(t *testing.T) {
+	revoked := refreshErr(&oauth2.RetrieveError{Body: []byte(`{"error": "invalid_grant"}`)})
+	if Category(revoked) != ErrAuth {
+		t.Errorf("a revoked refresh token should be an auth failure, got %v", revoked)
+	}
+
+	transient := &oauth2.RetrieveError{Body: []byte(`{"error": "internal_failure"}`)}
+	if got := refreshErr(transient); got != error(transient) {
+		t.Errorf("other failures should be left as they are, got %v", got)
+	}
+}
+
+func TestGroupDuplicates(t *testing.T) {
+	members := []*dupMember{
+		{path: "/a/x.jpg", file: &File{Id: "1", Name: "x.jpg", Md5Checksum: "m1"}},
+		{path: "/b/y.jpg", file: &File{Id: "2", Name: "y.jpg", Md5Checksum: "m1"}},
+		{path: "/b/x.jpg", file: &File{Id: "3", Name: "x.jpg", Md5Checksum: "m2"}},
+		{path: "/c/x.jpg", file: &File{Id: "4", Name: "x.jpg", Md5Checksum: "m1"}},
+		{path: "/a/doc", file: &File{Id: "5", Name: "doc"}},
+		{path: "/b/doc", file: &File{Id: "6", Name: "doc"}},
+	}
+
+	testCases := []struct {
+		byContent, byName bool
+		want              [][]string
+	}{
+		{byContent: true, want: [][]string{{"1", "2", "4"}}},
+		{byName: true, want: [][]string{{"1", "3", "4"}, {"5", "6"}}},
+		{byContent: true, byName: true, want: [][]string{{"1", "4"}}},
+	}
+
+	for _, tc := range testCases {
+		var got [][]string
+		for _, group := range groupDuplicates(members, tc.byContent, tc.byName) {
+			var ids []string
+			for _, member := range group {
+				ids = append(ids, member.file.Id)
+			}
+			got = append(got, ids)
+		}
+
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("byContent=%v byName=%v: got %v, want %v", tc.byContent, tc.byName, got, tc.want)
+		}
+	}
+}
+
+func TestVerifyTallyFailed(t *testing.T) {
+	testCases := []struct {
+		counts map[verifyStatus]int
+		want   int
+	}{
+		{counts: map[verifyStatus]int{VerifyOK: 3, VerifySkipped: 2}, want: 0},
+		{counts: map[verifyStatus]int{VerifyOK: 1, VerifyMismatch: 1, VerifyMissingLocal: 2}, want: 3},
+		{counts: map[verifyStatus]int{VerifyCorrupt: 2, VerifyMissingRemote: 1}, want: 3},
+	}
+
+	for i, tc := range testCases {
+		tally := &verifyTally{counts: tc.counts}
+		if got := tally.failed(); got != tc.want {
+			t.Errorf("#%d: got %d failed, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestAuthCodeHandler(t *testing.T) {
+	testCases := []struct {
+		query    string
+		wantCode string
+		wantErr  bool
+	}{
+		{query: "state=s1&code=c1", wantCode: "c1"},
+		{query: "state=s1&error=access_denied", wantErr: true},
+		{query: "state=s1", wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		results := make(chan authCodeResult, 1)
+		server := httptest.NewServer(authCodeHandler("s1", results))
+
+		// Requests with a different state, e.g for a favicon, are ignored
+		for _, query := range []string{"state=other&code=c0", tc.query, "state=s1&code=late"} {
+			res, err := http.Get(server.URL + "/?" + query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+		}
+		server.Close()
+
+		result := <-results
+		if result.code != tc.wantCode || (result.err != nil) != tc.wantErr {
+			t.Errorf("#%d: got code %q and err %v, want code %q and err %v", i, result.code, result.err, tc.wantCode, tc.wantErr)
+		}
+		if len(results) != 0 {
+			t.Errorf("#%d: expected only the first redirect to be delivered", i)
+		}
+	}
+}
+
+func TestListenLoopbackFallsBack(t *testing.T) {
+	taken, err := listenLoopback("", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+
+	port := taken.Addr().(*net.TCPAddr).Port
+	listener, err := listenLoopback("", port)
+	if err != nil {
+		t.Fatalf("expected a free port to be picked instead of the taken %d, got %v", port, err)
+	}
+	defer listener.Close()
+
+	if got := listener.Addr().(*net.TCPAddr).Port; got == port {
+		t.Errorf("expected a port other than the taken %d", port)
+	}
+}
+
+func TestStripPathPrefix(t *testing.T) {
+	testCases := []struct {
+		p, prefix string
+		want      string
+		wantErr   bool
+	}{
+		{p: "/home/me/project/src", prefix: "", want: "/home/me/project/src"},
+		{p: "/home/me/project/src", prefix: "/home/me", want: "/project/src"},
+		{p: "/home/me/project/src", prefix: "/home/me/", want: "/project/src"},
+		{p: "/home/me", prefix: "/home/me", want: "/"},
+		{p: "/home/meadows/src", prefix: "/home/me", wantErr: true},
+		{p: "/opt/src", prefix: "/home/me", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := stripPathPrefix(tc.p, tc.prefix)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q with prefix %q: got err %v, wantErr %v", tc.p, tc.prefix, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q with prefix %q: got %q, want %q", tc.p, tc.prefix, got, tc.want)
+		}
+	}
+}
+
+func TestPlanEntryChange(t *testing.T) {
+	modTime := time.Now()
+	added := &Change{Path: "/a", Src: &File{Name: "a", Size: 10, ModTime: modTime}}
+	modified := &Change{
+		Path:           "/b",
+		Src:            &File{Name: "b", Size: 20, ModTime: modTime},
+		Dest:           &File{Id: "id-b", Name: "b", Size: 15, ModTime: modTime},
+		IgnoreConflict: true,
+		IgnoreChecksum: true,
+	}
+	deleted := &Change{Path: "/d/c", Dest: &File{Id: "id-c", Name: "c", Size: 5, ModTime: modTime}}
+
+	plan := changesPlan([]*Change{added, modified, deleted}, true).Changes
+	if len(plan) != 3 || plan[0].Action != "add" || plan[0].Reason != "only local" || plan[0].RemoteSize != nil || plan[0].RemoteId != "" {
+		t.Fatalf("unexpected plan of the addition %#v", plan)
+	}
+	if plan[1].Action != "modify" || plan[1].Reason != "size differs, checksum differs" || *plan[1].RemoteSize != 15 || plan[1].RemoteId != "id-b" || !plan[1].IgnoreConflict || plan[1].Force {
+		t.Fatalf("unexpected plan of the modification %#v", plan[1])
+	}
+	if plan[2].Action != "delete" || plan[2].LocalSize != nil || plan[2].RemoteId != "id-c" {
+		t.Fatalf("unexpected plan of the deletion %#v", plan[2])
+	}
+
+	// The files have changed since, the changes are still made as planned
+	// and are only forced or ignore conflicts if they were planned so
+	testCases := []struct {
+		entry         planEntry
+		local, remote *File
+		want          Operation
+	}{
+		{entry: plan[0], local: &File{Name: "a", Size: 11, ModTime: modTime}, want: OpAdd},
+		{entry: plan[1], local: &File{Name: "b", Size: 15, ModTime: modTime.Add(time.Hour)}, remote: modified.Dest, want: OpMod},
+		{entry: plan[2], remote: deleted.Dest, want: OpDelete},
+	}
+
+	for i, tc := range testCases {
+		c := planEntryChange(tc.entry, tc.local, tc.remote, true)
+		if c.Path != tc.entry.Path || c.Src != tc.local || c.Dest != tc.remote {
+			t.Errorf("#%d: unexpected change %#v", i, c)
+		}
+		if got := c.Op(); got != tc.want {
+			t.Errorf("#%d: got op %v want %v", i, got, tc.want)
+		}
+		if c.Force != tc.entry.Force || c.IgnoreConflict != tc.entry.IgnoreConflict {
+			t.Errorf("#%d: got force %v ignoreConflict %v as planned", i, c.Force, c.IgnoreConflict)
+		}
+	}
+
+	if c := planEntryChange(plan[2], nil, deleted.Dest, false); c.Src != deleted.Dest || c.Dest != nil || c.Parent != "/d" {
+		t.Errorf("unexpected change for a pull %#v", c)
+	}
+}
+
+func TestFindQueryString(t *testing.T) {
+	modifiedAfter := time.Date(2016, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	testCases := []struct {
+		query FindQuery
+		want  string
+	}{
+		{query: FindQuery{}, want: "trashed = false"},
+		{
+			query: FindQuery{NameContains: []string{"report", `q"3`}, Starred: true},
+			want:  `title contains "report" and title contains "q\"3" and starred = true and trashed = false`,
+		},
+		{
+			query: FindQuery{ModifiedAfter: modifiedAfter, Owners: []string{"jane@example.com"}, Trashed: true},
+			want:  `modifiedDate > "2016-01-02T03:04:05Z" and "jane@example.com" in owners and trashed = true`,
+		},
+		{
+			query: FindQuery{MimeTypes: []string{"csv", "application/vnd.openxmlformats-officedocument.wordprocessingml.document"}, Raw: "fullText contains 'budget'"},
+			want:  `(mimeType = "text/csv" or mimeType = "application/vnd.openxmlformats-officedocument.wordprocessingml.document") and trashed = false and (fullText contains 'budget')`,
+		},
+	}
+
+	for i, tc := range testCases {
+		if got := tc.query.String(); got != tc.want {
+			t.Errorf("#%d: got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	testCases := []struct {
+		age     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{age: "7d", want: 7 * 24 * time.Hour},
+		{age: " 2w ", want: 14 * 24 * time.Hour},
+		{age: "36h", want: 36 * time.Hour},
+		{age: "0", want: 0},
+		{age: "-1d", wantErr: true},
+		{age: "2016-01-01T00:00:00Z", wantErr: true},
+		{age: "soon", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		got, err := ParseAge(tc.age)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("%q: got err %v, wantErr %v", tc.age, err, tc.wantErr)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("%q: got %v, want %v", tc.age, got, tc.want)
+		}
+	}
+}
+
+func TestAttributedContent(t *testing.T) {
+	author := &drive.User{DisplayName: "Emmanuel", EmailAddress: "emm.odeke@gmail.com"}
+	testCases := []struct {
+		author      *drive.User
+		createdDate string
+		content     string
+		want        string
+	}{
+		{
+			author: author, createdDate: "2016-05-27", content: "Needs a citation",
+			want: "[Originally by Emmanuel <emm.odeke@gmail.com> on 2016-05-27]\nNeeds a citation",
+		},
+		{
+			author: nil, createdDate: "2016-05-27", content: "line one\nline two",
+			want: "[Originally by unknown on 2016-05-27]\nline one\nline two",
+		},
+		{
+			// Replies that only resolve or reopen a comment have no content
+			author: &drive.User{DisplayName: "Emmanuel"}, createdDate: "2016-05-28", content: " ",
+			want: "[Originally by Emmanuel on 2016-05-28]",
+		},
+	}
+
+	for i, tc := range testCases {
+		if got := attributedContent(tc.author, tc.createdDate, tc.content); got != tc.want {
+			t.Errorf("#%d: got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestProgressJSONReporter(t *testing.T) {
+	var nilReporter *progressJSONReporter
+	nilReporter.fileStarted("a", 10)
+	if fn := nilReporter.fileProgressFn("a"); fn != nil {
+		t.Errorf("a nil reporter should not hand out progress functions")
+	}
+	nilReporter.fileDone("a")
+	nilReporter.finish()
+
+	buf := new(bytes.Buffer)
+	pr := newProgressJSONReporter(buf, 30)
+	pr.fileStarted("a", 10)
+	pr.fileStarted("b", 20)
+
+	progressA := pr.fileProgressFn("a")
+	progressA(4)
+	pr.addOverall(4)
+	pr.emit()
+
+	progressA(6)
+	pr.addOverall(6)
+	pr.fileDone("a")
+	pr.emit()
+
+	// b got cut short, its bytes are only accounted for when done
+	pr.addOverall(20)
+	pr.fileDone("b")
+	pr.finish()
+
+	want := []progressEvent{
+		{File: "a", BytesDone: 4, BytesTotal: 10, OverallDone: 4, OverallTotal: 30},
+		{File: "b", BytesDone: 0, BytesTotal: 20, OverallDone: 4, OverallTotal: 30},
+		{File: "a", BytesDone: 10, BytesTotal: 10, OverallDone: 10, OverallTotal: 30},
+		{File: "b", BytesDone: 0, BytesTotal: 20, OverallDone: 10, OverallTotal: 30},
+		{File: "b", BytesDone: 20, BytesTotal: 20, OverallDone: 30, OverallTotal: 30},
+		{File: "", OverallDone: 30, OverallTotal: 30},
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(want) {
+		t.Fatalf("got %d events, want %d: %s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		var got progressEvent
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Errorf("#%d: %q: %v", i, line, err)
+			continue
+		}
+		got.Speed = 0
+		if got != want[i] {
+			t.Errorf("#%d: got %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func TestMergeUnicodeNormalization(t *testing.T) {
+	composed, decomposed := "caf\u00e9.txt", "cafe\u0301.txt"
+
+	testCases := []struct {
+		form       string
+		wantPaired bool
+	}{
+		{form: UnicodeNFC, wantPaired: true},
+		{form: UnicodeNFD, wantPaired: true},
+		{form: UnicodeNone, wantPaired: false},
+	}
+
+	for _, tc := range testCases {
+		remotes := &paginationPair{errsChan: make(chan error), filesChan: make(chan *File, 1)}
+		remotes.filesChan <- &File{Name: composed, Id: "remote"}
+		close(remotes.filesChan)
+
+		locals := make(chan *File, 1)
+		locals <- &File{Name: decomposed}
+		close(locals)
+
+		merged, _, _, err := merge(remotes, locals, false, false, unicodeNormalizer(tc.form))
+		if err != nil {
+			t.Errorf("%s: %v", tc.form, err)
+			continue
+		}
+
+		paired := false
+		for _, list := range merged {
+			if list.remote != nil && list.local != nil {
+				paired = true
+			}
+		}
+		if paired != tc.wantPaired {
+			t.Errorf("%s: paired %v, want %v", tc.form, paired, tc.wantPaired)
+		}
+	}
+}
+
+func TestTitleVariants(t *testing.T) {
+	testCases := []struct {
+		title string
+		want  []string
+	}{
+		{title: "plain.txt", want: []string{"plain.txt"}},
+		{title: "caf\u00e9.txt", want: []string{"caf\u00e9.txt", "cafe\u0301.txt"}},
+		{title: "cafe\u0301.txt", want: []string{"cafe\u0301.txt", "caf\u00e9.txt"}},
+	}
+
+	for _, tc := range testCases {
+		if got := titleVariants(tc.title); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: got %q, want %q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestNextChangeId(t *testing.T) {
+	testCases := []struct {
+		start, largestSeen, largestChangeId int64
+		want                                int64
+	}{
+		// The first stream starts from the first change
+		{start: 0, largestSeen: 40, largestChangeId: 42, want: 43},
+		{start: 43, largestSeen: 50, largestChangeId: 50, want: 51},
+		// No changes since, the cursor doesn't move
+		{start: 51, largestSeen: 0, largestChangeId: 50, want: 51},
+		{start: 0, largestSeen: 0, largestChangeId: 0, want: 1},
+	}
+
+	for i, tc := range testCases {
+		if got := nextChangeId(tc.start, tc.largestSeen, tc.largestChangeId); got != tc.want {
+			t.Errorf("#%d: got %d, want %d", i, got, tc.want)
+		}
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	logf := func(string, ...interface{}) {}
+	lockPath := dir + "/lock"
+
+	release, err := acquireLock(lockPath, false, logf)
+	if err != nil {
+		t.Fatalf("acquiring a free lock: %v", err)
+	}
+
+	if _, err := acquireLock(lockPath, false, logf); err == nil {
+		t.Errorf("expected acquiring a held lock to fail")
+	} else if e, ok := err.(*Error); !ok || e.Code() != int(StatusContextLocked) {
+		t.Errorf("got err %v, want a locked context error", err)
+	}
+
+	release()
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("expected the lock to be removed on release, got %v", err)
+	}
+
+	// A lock left behind by a process that no longer exists is stale
+	host, _ := os.Hostname()
+	data, _ := json.Marshal(&lockHolder{PID: 1 << 30, Host: host})
+	if err := ioutil.WriteFile(lockPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err = acquireLock(lockPath, false, logf)
+	if err != nil {
+		t.Fatalf("acquiring a stale lock: %v", err)
+	}
+	release()
+}
+
+func TestHashSnapshot(t *testing.T) {
+	modTime := time.Date(2016, 5, 27, 10, 0, 0, 0, time.UTC)
+	recorded := map[string]*config.HashSnapshotEntry{
+		"docs/unchanged.txt": {Size: 10, ModTime: modTime.Unix(), Md5Checksum: "recorded-unchanged"},
+		"docs/touched.txt":   {Size: 10, ModTime: modTime.Unix(), Md5Checksum: "recorded-touched"},
+		"docs/resized.txt":   {Size: 10, ModTime: modTime.Unix(), Md5Checksum: "recorded-resized"},
+	}
+	hs := newHashSnapshot("/ctx", recorded)
+
+	unchanged := &File{BlobAt: "/ctx/docs/unchanged.txt", Size: 10, ModTime: modTime}
+	touched := &File{BlobAt: "/ctx/docs/touched.txt", Size: 10, ModTime: modTime.Add(time.Hour)}
+	resized := &File{BlobAt: "/ctx/docs/resized.txt", Size: 11, ModTime: modTime}
+	unrecorded := &File{BlobAt: "/ctx/docs/new.txt", Size: 3, ModTime: modTime}
+	for _, f := range []*File{unchanged, touched, resized, unrecorded, nil, {IsDir: true, BlobAt: "/ctx/docs"}} {
+		hs.apply(f)
+	}
+
+	if unchanged.Md5Checksum != "recorded-unchanged" {
+		t.Errorf("unchanged: got checksum %q, want the recorded one", unchanged.Md5Checksum)
+	}
+	for _, f := range []*File{touched, resized, unrecorded} {
+		if f.Md5Checksum != "" {
+			t.Errorf("%s: got checksum %q, want it to be hashed again", f.BlobAt, f.Md5Checksum)
+		}
+	}
+
+	// As computed during the comparisons
+	touched.Md5Checksum = "rehashed-touched"
+	unrecorded.Md5Checksum = "hashed-new"
+
+	entries := hs.entries()
+	want := map[string]string{
+		"docs/unchanged.txt": "recorded-unchanged",
+		"docs/touched.txt":   "rehashed-touched",
+		"docs/resized.txt":   "recorded-resized",
+		"docs/new.txt":       "hashed-new",
+	}
+	if len(entries) != len(want) {
+		t.Errorf("got %d entries, want %d", len(entries), len(want))
+	}
+	for key, wantMd5 := range want {
+		if entry, ok := entries[key]; !ok || entry.Md5Checksum != wantMd5 {
+			t.Errorf("%s: got %+v, want checksum %q", key, entry, wantMd5)
+		}
+	}
+	if entry := entries["docs/touched.txt"]; entry != nil && entry.ModTime != touched.ModTime.Unix() {
+		t.Errorf("touched: got mtime %d, want the new one %d", entry.ModTime, touched.ModTime.Unix())
+	}
+}
+
+func TestIsDailyUploadLimitErr(t *testing.T) {
+	limitErr := func(code int, reason, message string) *googleapi.Error {
+		return &googleapi.Error{
+			Code:    code,
+			Message: message,
+			Errors:  []googleapi.ErrorItem{{Reason: reason, Message: message}},
+		}
+	}
+
+	cases := []struct {
+		desc string
+		err  error
+		want bool
+	}{
+		{"daily user rate limit", limitErr(403, "userRateLimitExceeded", "User rate limit exceeded."), true},
+		{"per second user rate limit", limitErr(403, "userRateLimitExceeded", "User Rate Limit Exceeded"), false},
+		{"upload limit", limitErr(403, "uploadLimitExceeded", "Upload limit exceeded"), true},
+		{"storage quota", limitErr(403, "storageQuotaExceeded", "The user's Drive storage quota has been exceeded."), true},
+		{"rate limit", limitErr(403, "rateLimitExceeded", "Rate Limit Exceeded"), false},
+		{"other code", limitErr(429, "uploadLimitExceeded", "Upload limit exceeded"), false},
+		{"wrapped", makeError(limitErr(403, "dailyLimitExceeded", "Daily Limit Exceeded"), StatusGeneric), true},
+		{"no items", &googleapi.Error{Code: 403, Message: "User rate limit exceeded."}, false},
+		{"not an api error", fmt.Errorf("User rate limit exceeded."), false},
+		{"nil", nil, false},
+	}
+
+	for _, tc := range cases {
+		if got := isDailyUploadLimitErr(tc.err); got != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.desc, got, tc.want)
+		}
+		if apiErr, ok := tc.err.(*googleapi.Error); ok {
+			if _, retryable := retryableErrorCheck(&tuple{last: apiErr}); tc.want && retryable {
+				t.Errorf("%s: is retried", tc.desc)
+			}
+		}
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	// Not every system's mime.types has it
+	if err := mime.AddExtensionType(".epub", "application/epub+zip"); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := &File{
+		MimeType: DriveDocumentMimeType,
+		ExportLinks: map[string]string{
+			"application/pdf": "https://example.com/pdf",
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document": "https://example.com/docx",
+			"application/epub+zip": "https://example.com/epub",
+		},
+	}
+	blob := &File{MimeType: "text/plain", BlobAt: "https://example.com/blob"}
+
+	testCases := []struct {
+		exportAll bool
+		f         *File
+		exports   []string
+		want      []string
+	}{
+		{f: doc, exports: []string{"pdf"}, want: []string{"pdf"}},
+		{exportAll: true, f: doc, exports: []string{"pdf"}, want: []string{"docx", "pdf", "epub"}},
+		{exportAll: true, f: doc, want: []string{"docx", "pdf", "epub"}},
+		{exportAll: true, f: blob, want: nil},
+	}
+
+	for i, tc := range testCases {
+		g := &Commands{opts: &Options{ExportAll: tc.exportAll}}
+		if got := g.exportFormats(tc.f, tc.exports); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+
+	if mimeType, exportURL, ok := exportLink(doc.ExportLinks, "epub"); !ok || mimeType != "application/epub+zip" || exportURL != "https://example.com/epub" {
+		t.Errorf("epub export: got mimeType=%q url=%q ok=%v", mimeType, exportURL, ok)
+	}
+	if _, _, ok := exportLink(doc.ExportLinks, "odt"); ok {
+		t.Errorf("expected no odt export")
+	}
+}
+
+func TestPullAsName(t *testing.T) {
+	testCases := []struct {
+		title, as   string
+		sourceCount int
+		want        string
+		wantErr     bool
+	}{
+		{title: "Q3 Dataset (final) v2", sourceCount: 1, want: "Q3 Dataset (final) v2"},
+		{title: "Q3 Dataset (final) v2", as: "my-project", sourceCount: 1, want: "my-project"},
+		{title: "a", sourceCount: 3, want: "a"},
+		{title: "a", as: "my-project", sourceCount: 2, wantErr: true},
+		{title: "a", as: "nested/my-project", sourceCount: 1, wantErr: true},
+		{title: "a", as: "..", sourceCount: 1, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		got, err := pullAsName(tc.title, tc.as, tc.sourceCount)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: got %q, want an error", i, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("#%d: got (%q, %v) want %q", i, got, err, tc.want)
+		}
+	}
+}
+
+func TestJSONListWriter(t *testing.T) {
+	testCases := []struct {
+		files []listedFile
+		want  string
+	}{
+		{want: "[]\n"},
+		{
+			files: []listedFile{
+				{Name: "a.txt", Path: "/docs/a.txt", Size: 3, Id: "id-a", Mime: "text/plain", Md5: "md5-a"},
+				{Name: "b", Path: "/docs/b", Id: "id-b", Mime: "application/vnd.google-apps.folder", IsDir: true},
+			},
+			want: "[\n" +
+				`{"name":"a.txt","path":"/docs/a.txt","size":3,"modTime":"0001-01-01T00:00:00Z","id":"id-a","mimeType":"text/plain","md5Checksum":"md5-a","isDir":false},` + "\n" +
+				`{"name":"b","path":"/docs/b","size":0,"modTime":"0001-01-01T00:00:00Z","id":"id-b","mimeType":"application/vnd.google-apps.folder","isDir":true}` + "\n]\n",
+		},
+	}
+
+	for i, tc := range testCases {
+		var stdout bytes.Buffer
+		jw := &jsonListWriter{logy: log.New(nil, &stdout, &stdout)}
+		for j := range tc.files {
+			if err := jw.write(&tc.files[j]); err != nil {
+				t.Fatalf("#%d: write %v", i, err)
+			}
+		}
+		jw.close()
+
+		if got := stdout.String(); got != tc.want {
+			t.Errorf("#%d: got=%q\nwant=%q", i, got, tc.want)
+		}
+
+		var decoded []listedFile
+		if err := json.Unmarshal(stdout.Bytes(), &decoded); err != nil || len(decoded) != len(tc.files) {
+			t.Errorf("#%d: got %d decoded items, err %v, want %d", i, len(decoded), err, len(tc.files))
+		}
+	}
+}
+
+func TestImpersonating(t *testing.T) {
+	jwtConfig := &jwt.Config{Email: "backup@project.iam.gserviceaccount.com", Scopes: []string{DriveScope}}
+
+	if got := impersonating(jwtConfig, ""); got != jwtConfig {
+		t.Errorf("without a subject got %+v, want the config as is", got)
+	}
+
+	got := impersonating(jwtConfig, "alice@example.com")
+	if got.Subject != "alice@example.com" || got.Email != jwtConfig.Email {
+		t.Errorf("got subject %q of %q, want alice@example.com of %q", got.Subject, got.Email, jwtConfig.Email)
+	}
+	if jwtConfig.Subject != "" {
+		t.Errorf("the config of the context was changed to subject %q", jwtConfig.Subject)
+	}
+
+	testCases := []struct {
+		err  error
+		want bool
+	}{
+		{err: &oauth2.RetrieveError{Body: []byte(`{"error":"unauthorized_client","error_description":"Client is unauthorized to retrieve access tokens using this method"}`)}, want: true},
+		{err: &oauth2.RetrieveError{Response: &http.Response{StatusCode: 403}}, want: true},
+		{err: &oauth2.RetrieveError{Response: &http.Response{StatusCode: 400}, Body: []byte(`{"error":"invalid_grant"}`)}, want: false},
+		{err: fmt.Errorf("unauthorized_client"), want: false},
+		{err: nil, want: false},
+	}
+
+	for i, tc := range testCases {
+		if got := delegationDenied(tc.err); got != tc.want {
+			t.Errorf("#%d: got %v want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestNoRecursive(t *testing.T) {
+	testCases := []struct {
+		opts      Options
+		wantDepth int
+		wantErr   bool
+	}{
+		{opts: Options{Depth: InfiniteDepth}, wantDepth: InfiniteDepth},
+		{opts: Options{NoRecursive: true, Depth: InfiniteDepth}, wantDepth: DirectChildrenDepth},
+		{opts: Options{NoRecursive: true, Depth: 5}, wantDepth: DirectChildrenDepth},
+		{opts: Options{NoRecursive: true, Depth: 1}, wantDepth: 1},
+		{opts: Options{NoRecursive: true, Mirror: true}, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		opts := tc.opts
+		err := opts.noRecursive()
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("#%d: want an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: err %v", i, err)
+			continue
+		}
+		if opts.Depth != tc.wantDepth {
+			t.Errorf("#%d: got depth %d want %d", i, opts.Depth, tc.wantDepth)
+		}
+		if opts.NoRecursive && !opts.Recursive {
+			t.Errorf("#%d: children are not traversed", i)
+		}
+	}
+}
+
+func TestLastUndoOp(t *testing.T) {
+	entry := func(op, id string) *config.UndoEntry {
+		return &config.UndoEntry{Op: op, Id: id, Action: UndoActionTrash}
+	}
+	ids := func(entries []*config.UndoEntry) string {
+		var joined []string
+		for _, entry := range entries {
+			joined = append(joined, entry.Id)
+		}
+		return strings.Join(joined, ",")
+	}
+
+	testCases := []struct {
+		entries  []*config.UndoEntry
+		wantLast string
+		wantRest string
+	}{
+		{},
+		{entries: []*config.UndoEntry{entry("1", "a")}, wantLast: "a"},
+		{
+			entries:  []*config.UndoEntry{entry("1", "a"), entry("1", "b"), entry("2", "c"), entry("2", "d")},
+			wantLast: "c,d", wantRest: "a,b",
+		},
+		{
+			entries:  []*config.UndoEntry{entry("1", "a"), entry("2", "b"), entry("1", "c")},
+			wantLast: "a,c", wantRest: "b",
+		},
+	}
+
+	for i, tc := range testCases {
+		last, rest := lastUndoOp(tc.entries)
+		if got := ids(last); got != tc.wantLast {
+			t.Errorf("#%d: last: got %q want %q", i, got, tc.wantLast)
+		}
+		if got := ids(rest); got != tc.wantRest {
+			t.Errorf("#%d: rest: got %q want %q", i, got, tc.wantRest)
+		}
+	}
+}
+
+func TestCaseInsensitiveExtensions(t *testing.T) {
+	testCases := []struct {
+		ext, want string
+	}{
+		{ext: ".jpg", want: "image/jpeg"},
+		{ext: ".JPG", want: "image/jpeg"},
+		{ext: ".Jpeg", want: "image/jpeg"},
+		{ext: ".PDF", want: "application/pdf"},
+		{ext: ".DOCX", want: "application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{ext: ".zzz"},
+	}
+
+	for i, tc := range testCases {
+		if got := guessMimeType(tc.ext); got != tc.want {
+			t.Errorf("#%d: guessMimeType(%q): got %q want %q", i, tc.ext, got, tc.want)
+		}
+		if got := mimeTypeFromQuery(tc.ext); got != tc.want {
+			t.Errorf("#%d: mimeTypeFromQuery(%q): got %q want %q", i, tc.ext, got, tc.want)
+		}
+	}
+
+	for _, format := range []string{"pdf", "PDF", ".Pdf", " pdf "} {
+		if got := exportExtension(format); got != "pdf" {
+			t.Errorf("exportExtension(%q): got %q want %q", format, got, "pdf")
+		}
+	}
+}
+
+func TestAuthRedirectURL(t *testing.T) {
+	testCases := []struct {
+		bindAddr string
+		addr     net.Addr
+		want     string
+	}{
+		{addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8085}, want: "http://127.0.0.1:8085/"},
+		{bindAddr: "127.0.0.1", addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8085}, want: "http://127.0.0.1:8085/"},
+		{bindAddr: "0.0.0.0", addr: &net.TCPAddr{IP: net.ParseIP("0.0.0.0"), Port: 8085}, want: "http://127.0.0.1:8085/"},
+		{bindAddr: "::", addr: &net.TCPAddr{IP: net.ParseIP("::"), Port: 9000}, want: "http://127.0.0.1:9000/"},
+		{bindAddr: "localhost", addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: 8085}, want: "http://localhost:8085/"},
+		{bindAddr: "::1", addr: &net.TCPAddr{IP: net.ParseIP("::1"), Port: 8085}, want: "http://[::1]:8085/"},
+		{bindAddr: "192.168.1.5", addr: &net.TCPAddr{IP: net.ParseIP("192.168.1.5"), Port: 8085}, want: "http://192.168.1.5:8085/"},
+	}
+
+	for i, tc := range testCases {
+		if got := authRedirectURL(tc.bindAddr, tc.addr); got != tc.want {
+			t.Errorf("#%d: got %q want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestChangeSliceSize(t *testing.T) {
+	testCases := []struct {
+		n, workers, want int
+	}{
+		{n: 0, workers: 0, want: defaultChangeSliceSize},
+		{n: 1000, workers: 0, want: defaultChangeSliceSize},
+		{n: 0, workers: 4, want: 1},
+		{n: 3, workers: 8, want: 1},
+		{n: 8, workers: 8, want: 1},
+		{n: 9, workers: 8, want: 2},
+		{n: 100, workers: 8, want: 13},
+		{n: 10000, workers: 8, want: defaultChangeSliceSize},
+	}
+
+	for i, tc := range testCases {
+		got := changeSliceSize(tc.n, tc.workers)
+		if got != tc.want {
+			t.Errorf("#%d: changeSliceSize(%d, %d): got %d want %d", i, tc.n, tc.workers, got, tc.want)
+		}
+		if tc.workers > 0 && tc.n > 0 && got < defaultChangeSliceSize {
+			if chunks := (tc.n + got - 1) / got; chunks > tc.workers {
+				t.Errorf("#%d: %d chunks for %d workers", i, chunks, tc.workers)
+			}
+		}
+	}
+}
+
+func TestListSlotsBound(t *testing.T) {
+	var nilSlots listSlots
+	nilSlots.acquire()
+	nilSlots.release()
+
+	slots := newListSlots(2)
+	slots.acquire()
+	slots.acquire()
+
+	acquired := make(chan bool)
+	go func() {
+		slots.acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected the third acquire to block until a slot is released")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	slots.release()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("expected the third acquire to go through once a slot was released")
+	}
+}
+
+func TestLinkShortcutContent(t *testing.T) {
+	f := &File{
+		Name:          "Budget",
+		MimeType:      DriveDocumentMimeType,
+		AlternateLink: "https://docs.google.com/document/d/abc/edit?usp=drivesdk&x=1",
+	}
+
+	testCases := []struct {
+		goos string
+		ext  string
+		want []string
+	}{
+		{goos: OSWindowsKey, ext: URLShortcutExtension, want: []string{"[InternetShortcut]\r\n", "URL=" + f.AlternateLink + "\r\n"}},
+		{goos: OSDarwinKey, ext: WeblocShortcutExtension, want: []string{"<plist", "<string>https://docs.google.com/document/d/abc/edit?usp=drivesdk&amp;x=1</string>"}},
+		{goos: OSLinuxKey, ext: DesktopExtension, want: []string{"[Desktop Entry]\n", "Name=Budget\n", "URL=" + f.AlternateLink + "\n"}},
+		{goos: "freebsd", ext: DesktopExtension},
+	}
+
+	for i, tc := range testCases {
+		ext := linkShortcutExtension(tc.goos)
+		if ext != tc.ext {
+			t.Errorf("#%d: %s: got extension %q want %q", i, tc.goos, ext, tc.ext)
+		}
+
+		content := linkShortcutContent(ext, f)
+		for _, want := range tc.want {
+			if !strings.Contains(content, want) {
+				t.Errorf("#%d: %q does not contain %q", i, content, want)
+			}
+		}
+	}
+
+	byId := &File{Id: "abc", Name: "Form", MimeType: DriveFormMimeType}
+	if got, want := linkShortcutURL(byId), byId.Url(); got != want || got == "" {
+		t.Errorf("expected the link of a file without an alternateLink to fall back to %q, got %q", want, got)
+	}
+}
+
+func TestQPSLimiterReserve(t *testing.T) {
+	if limiter := newQPSLimiter(0); limiter != nil {
+		t.Fatalf("expected no limiter for a qps of 0, got %v", limiter)
+	}
+
+	limiter := newQPSLimiter(4)
+	if limiter.interval != 250*time.Millisecond {
+		t.Fatalf("got an interval of %v want %v", limiter.interval, 250*time.Millisecond)
+	}
+
+	now := time.Now()
+	testCases := []struct {
+		at   time.Duration
+		want time.Duration
+	}{
+		{at: 0, want: 0},
+		{at: 0, want: 250 * time.Millisecond},
+		{at: 100 * time.Millisecond, want: 400 * time.Millisecond},
+		// After an idle spell, requests go through right away again
+		{at: 2 * time.Second, want: 0},
+		{at: 2*time.Second + 300*time.Millisecond, want: 0},
+		{at: 2*time.Second + 300*time.Millisecond, want: 250 * time.Millisecond},
+	}
+
+	for i, tc := range testCases {
+		if got := limiter.reserve(now.Add(tc.at)); got != tc.want {
+			t.Errorf("#%d: got a delay of %v want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestOwnedByMe(t *testing.T) {
+	me := &drive.User{EmailAddress: "me@example.com", IsAuthenticatedUser: true}
+	other := &drive.User{EmailAddress: "other@example.com"}
+
+	testCases := []struct {
+		owners []*drive.User
+		want   bool
+	}{
+		{},
+		{owners: []*drive.User{nil}},
+		{owners: []*drive.User{other}},
+		{owners: []*drive.User{me}, want: true},
+		{owners: []*drive.User{other, me}, want: true},
+	}
+
+	for i, tc := range testCases {
+		if got := ownedByMe(tc.owners); got != tc.want {
+			t.Errorf("#%d: got %v want %v", i, got, tc.want)
+		}
+		if got := NewRemoteFile(&drive.File{Title: "a", Owners: tc.owners}).OwnedByMe; got != tc.want {
+			t.Errorf("#%d: NewRemoteFile: got %v want %v", i, got, tc.want)
+		}
+	}
+}
+
+func TestChunkSizerAdapts(t *testing.T) {
+	const configured = 2 * 1024 * 1024
+	cs := &chunkSizer{}
+
+	if got := cs.current(configured); got != configured {
+		t.Fatalf("got %d want the configured %d", got, configured)
+	}
+	if _, grown := cs.succeeded(configured); grown {
+		t.Fatalf("expected no growth beyond the configured size")
+	}
+
+	for _, want := range []int{1024 * 1024, 512 * 1024, 256 * 1024} {
+		got, downgraded := cs.failed(configured)
+		if !downgraded || got != want {
+			t.Fatalf("got (%d, %v) want (%d, true)", got, downgraded, want)
+		}
+	}
+	if got, downgraded := cs.failed(configured); downgraded || got != googleapi.MinUploadChunkSize {
+		t.Fatalf("expected the chunk size to stay at the floor, got (%d, %v)", got, downgraded)
+	}
+
+	for i := 1; i < chunkGrowAfter; i++ {
+		if _, grown := cs.succeeded(configured); grown {
+			t.Fatalf("#%d: grew before %d successes", i, chunkGrowAfter)
+		}
+	}
+	if got, grown := cs.succeeded(configured); !grown || got != 512*1024 {
+		t.Fatalf("got (%d, %v) want (%d, true)", got, grown, 512*1024)
+	}
+
+	// A failure resets the streak of successes
+	for i := 1; i < chunkGrowAfter; i++ {
+		cs.succeeded(configured)
+	}
+	cs.failed(configured)
+	if got := cs.current(configured); got != 256*1024 {
+		t.Fatalf("got %d want %d", got, 256*1024)
+	}
+
+	for n := 0; n < 3*chunkGrowAfter; n++ {
+		cs.succeeded(configured)
+	}
+	if got := cs.current(configured); got != configured {
+		t.Fatalf("got %d want the configured %d once grown back", got, configured)
+	}
+
+	if got := configuredChunkSize(0); got != googleapi.DefaultUploadChunkSize {
+		t.Errorf("got %d want the default %d", got, googleapi.DefaultUploadChunkSize)
+	}
+}
+
+func TestAdaptChunkSizeOnNetworkFailures(t *testing.T) {
+	const configured = 2 * 1024 * 1024
+	var logged []string
+	r := &Remote{}
+	r.chunks.logf = func(format string, args ...interface{}) {
+		logged = append(logged, fmt.Sprintf(format, args...))
+	}
+	args := &upsertOpt{uploadChunkSize: configured, src: &File{Name: "big", Size: 4 * configured}}
+
+	for _, err := range []error{
+		&googleapi.Error{Code: http.StatusForbidden, Message: "quota exceeded"},
+		&googleapi.Error{Code: http.StatusNotFound},
+	} {
+		r.adaptChunkSize(args, err)
+	}
+	if got := r.chunks.current(configured); got != configured || len(logged) != 0 {
+		t.Fatalf("expected no downgrade for API errors, got %d and logs %v", got, logged)
+	}
+
+	r.adaptChunkSize(args, &googleapi.Error{Code: http.StatusServiceUnavailable})
+	r.adaptChunkSize(args, io.ErrUnexpectedEOF)
+	if got, want := r.chunks.current(configured), configured/4; got != want || len(logged) != 2 {
+		t.Fatalf("got %d and logs %v, want %d after two network failures", got, logged, want)
+	}
+
+	// Small uploads fit in a single chunk so they say nothing about the network
+	r.adaptChunkSize(&upsertOpt{uploadChunkSize: configured, src: &File{Name: "small", Size: 10}}, io.ErrUnexpectedEOF)
+	if got, want := r.chunks.current(configured), configured/4; got != want {
+		t.Errorf("got %d want %d", got, want)
+	}
+}
+
+func TestEmptyDirAdds(t *testing.T) {
+	dir := &File{IsDir: true}
+	file := &File{}
+
+	cl := []*Change{
+		{Path: "/empty", Src: dir},
+		{Path: "/chain", Src: dir},
+		{Path: "/chain/empty", Src: dir},
+		{Path: "/docs", Src: dir},
+		{Path: "/docs/notes", Src: dir},
+		{Path: "/docs/notes/todo.txt", Src: file},
+		{Path: "/existing", Src: dir, Dest: dir},
+	}
+
+	got := emptyDirAdds(cl)
+	want := map[string]bool{"/empty": true, "/chain": true, "/chain/empty": true}
+	if len(got) != len(want) {
+		t.Fatalf("got %v want %v", got, want)
+	}
+	for p := range want {
+		if !got[p] {
+			t.Errorf("%s: expected to be reported empty, got %v", p, got)
+		}
+	}
+}
+
+func TestPrettyUser(t *testing.T) {
+	tests := []struct {
+		name, email, want string
+	}{
+		{name: "", email: "", want: ""},
+		{name: "Ada", email: "", want: "Ada"},
+		{name: "", email: "ada@example.com", want: "ada@example.com"},
+		{name: "Ada", email: "ada@example.com", want: "Ada <ada@example.com>"},
+	}
+
+	for i, tt := range tests {
+		if got := prettyUser(tt.name, tt.email); got != tt.want {
+			t.Errorf("#%d: got %q want %q", i, got, tt.want)
+		}
+	}
+}
+
+func TestActivityActors(t *testing.T) {
+	tests := []struct {
+		act  *driveactivity.DriveActivity
+		want string
+	}{
+		{act: &driveactivity.DriveActivity{}, want: "unknown"},
+		{
+			act: &driveactivity.DriveActivity{Actors: []*driveactivity.Actor{
+				{User: &driveactivity.User{KnownUser: &driveactivity.KnownUser{IsCurrentUser: true}}},
+			}},
+			want: "you",
+		},
+		{
+			act: &driveactivity.DriveActivity{Actors: []*driveactivity.Actor{
+				{User: &driveactivity.User{KnownUser: &driveactivity.KnownUser{PersonName: "people/1234"}}},
+				{User: &driveactivity.User{DeletedUser: &driveactivity.DeletedUser{}}},
+				{Anonymous: &driveactivity.AnonymousUser{}},
+			}},
+			want: "people/1234 & a deleted user & anonymous",
+		},
+	}
+
+	for i, tt := range tests {
+		if got := activityActors(tt.act); got != tt.want {
+			t.Errorf("#%d: got %q want %q", i, got, tt.want)
+		}
+	}
+
+	ranged := &driveactivity.DriveActivity{TimeRange: &driveactivity.TimeRange{EndTime: "2016-05-04T03:02:01Z"}}
+	if got := activityTime(ranged); got != "2016-05-04T03:02:01Z" {
+		t.Errorf("got %q want the end of the time range", got)
+	}
+}
+
+func TestRetryBudgetBound(t *testing.T) {
+	if rb := newRetryBudget(0); rb != nil || !rb.take() {
+		t.Fatalf("expected no limit without a budget, got %v", rb)
+	}
+
+	failure := &googleapi.Error{Code: 503}
+	calls := 0
+	newRetrier := func() *expb.ExponentialBacker {
+		return retryableChangeOp(func() (interface{}, error) {
+			calls += 1
+			return &tuple{last: failure}, failure
+		}, false, 10)
+	}
+
+	// attempt makes the attempts that retrier would, without backing off
+	attempt := func(retrier *expb.ExponentialBacker) {
+		for i := 0; i <= int(retrier.RetryCount); i++ {
+			v, _ := retrier.Do()
+			if ok, retryable := retrier.StatusCheck(v); ok || !retryable {
+				return
+			}
+		}
+	}
+
+	rb := newRetryBudget(3)
+	attempt(rb.bound(newRetrier()))
+	if calls != 4 {
+		t.Fatalf("got %d calls want the first one and 3 retries", calls)
+	}
+
+	// The budget is shared with the requests that follow for the same file
+	calls = 0
+	attempt(rb.bound(newRetrier()))
+	if calls != 1 {
+		t.Fatalf("got %d calls want no retries once the budget was used up", calls)
+	}
+
+	calls = 0
+	attempt(newRetrier())
+	if calls != 11 {
+		t.Fatalf("got %d calls want all of the retry count without a budget", calls)
+	}
+}
+
+func TestArchiveWriters(t *testing.T) {
+	formats := map[string]string{
+		"backup.tar.gz": ArchiveTarGz,
+		"BACKUP.TGZ":    ArchiveTarGz,
+		"backup.tar":    ArchiveTar,
+		"backup.zip":    ArchiveZip,
+	}
+	for p, want := range formats {
+		if got, err := archiveFormat(p); err != nil || got != want {
+			t.Errorf("%s: got (%q, %v) want %q", p, got, err, want)
+		}
+	}
+	if _, err := archiveFormat("backup.rar"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+
+	modTime := time.Date(2016, 5, 4, 3, 2, 0, 0, time.UTC)
+	want := map[string]string{
+		"docs/":           "",
+		"docs/notes.txt":  "some notes",
+		"docs/report.pdf": "an export of unknown size",
+	}
+
+	for _, format := range []string{ArchiveTarGz, ArchiveZip} {
+		buf := new(bytes.Buffer)
+		aw := newArchiveWriter(buf, format)
+		if err := aw.addDir("docs", modTime); err != nil {
+			t.Fatalf("%s: addDir: %v", format, err)
+		}
+		if err := aw.addFile("docs/notes.txt", modTime, 10, strings.NewReader("some notes")); err != nil {
+			t.Fatalf("%s: addFile: %v", format, err)
+		}
+		if err := aw.addFile("docs/report.pdf", modTime, -1, strings.NewReader("an export of unknown size")); err != nil {
+			t.Fatalf("%s: addFile: %v", format, err)
+		}
+		if err := aw.Close(); err != nil {
+			t.Fatalf("%s: close: %v", format, err)
+		}
+
+		got := make(map[string]string)
+		if format == ArchiveZip {
+			zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			for _, zf := range zr.File {
+				rc, err := zf.Open()
+				if err != nil {
+					t.Fatalf("%s: %s: %v", format, zf.Name, err)
+				}
+				blob, _ := ioutil.ReadAll(rc)
+				rc.Close()
+				got[zf.Name] = string(blob)
+			}
+		} else {
+			gzr, err := gzip.NewReader(buf)
+			if err != nil {
+				t.Fatalf("%s: %v", format, err)
+			}
+			tr := tar.NewReader(gzr)
+			for {
+				hdr, err := tr.Next()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", format, err)
+				}
+				if !hdr.ModTime.Equal(modTime) {
+					t.Errorf("%s: %s: got modTime %v want %v", format, hdr.Name, hdr.ModTime, modTime)
+				}
+				blob, _ := ioutil.ReadAll(tr)
+				got[hdr.Name] = string(blob)
+			}
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v want %v", format, got, want)
+		}
+	}
+}
+
+func TestSniffMimeTypeOfExtensionlessPNG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-sniff")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pngMagic := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+	p := filepath.Join(dir, "screenshot")
+	if err := ioutil.WriteFile(p, pngMagic, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if ext := filepath.Ext(p); ext != "" {
+		t.Fatalf("expected no extension, got %q", ext)
+	}
+	if got := guessMimeType(p); got != "" && got != OctetStreamMimeType {
+		t.Fatalf("expected no specific mimeType from the extension, got %q", got)
+	}
+	if got, want := sniffMimeType(p), "image/png"; got != want {
+		t.Errorf("got %q want %q", got, want)
+	}
+
+	blob := filepath.Join(dir, "notes")
+	if err := ioutil.WriteFile(blob, []byte{0x00, 0x01, 0x02, 0xff}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := sniffMimeType(blob); got != "" {
+		t.Errorf("expected no mimeType for binary content, got %q", got)
+	}
+}
+
+func TestNewTransportAttemptsHTTP2(t *testing.T) {
+	rt := newTransport(&config.Context{MaxIdleConnsPerHost: 3})
+	if cst, ok := rt.(*connStatsTransport); ok {
+		rt = cst.RoundTripper
+	}
+
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected an *http.Transport, got %T", rt)
+	}
+	if !transport.ForceAttemptHTTP2 {
+		t.Errorf("expected HTTP/2 to be attempted")
+	}
+	if got, want := transport.MaxIdleConnsPerHost, 3; got != want {
+		t.Errorf("maxIdleConnsPerHost: got %d want %d", got, want)
+	}
+}
+
+func TestRenamedTitlesRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-titles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var stdout bytes.Buffer
+	context := &config.Context{ConfigDir: dir}
+	listing := func(g *Commands, push bool, titles ...string) []string {
+		filesChan := make(chan *File)
+		errsChan := make(chan error)
+		go func() {
+			defer close(filesChan)
+			defer close(errsChan)
+			for _, title := range titles {
+				filesChan <- NewRemoteFile(&drive.File{Title: title})
+			}
+		}()
+
+		var names []string
+		for f := range g.renamedView("/", &paginationPair{errsChan: errsChan, filesChan: filesChan}, push).filesChan {
+			names = append(names, f.Name)
+		}
+		return names
+	}
+
+	// Pull
+	puller := &Commands{context: context, opts: &Options{SlashReplacement: "_"}, log: log.New(nil, &stdout, &stdout)}
+	if got, want := listing(puller, false, "a/b", "plain"), []string{"a_b", "plain"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("pull names: got=%v want=%v", got, want)
+	}
+	puller.summarizeRenamedTitles()
+
+	// Push, with the titles recorded by the pull
+	pusher := &Commands{context: context, opts: &Options{}, log: log.New(nil, &stdout, &stdout)}
+	if title, ok := pusher.originalTitle("/a_b"); !ok || title != "a/b" {
+		t.Errorf("original title: got=%q ok=%v want=%q", title, ok, "a/b")
+	}
+	if _, ok := pusher.originalTitle("/plain"); ok {
+		t.Errorf("expected no original title for a file that wasn't renamed")
+	}
+	if got, want := listing(pusher, true, "a/b", "plain"), []string{"a_b", "plain"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("push names: got=%v want=%v", got, want)
+	}
+}
+
+func TestStreamable(t *testing.T) {
+	testCases := []struct {
+		f    *File
+		want bool
+	}{
+		{f: nil, want: false},
+		{f: &File{MimeType: "video/mp4", WebContentLink: "https://example.com/v"}, want: true},
+		{f: &File{MimeType: "audio/mpeg", WebContentLink: "https://example.com/a"}, want: true},
+		{f: &File{MimeType: "video/mp4"}, want: false},
+		{f: &File{MimeType: "image/png", WebContentLink: "https://example.com/i"}, want: false},
+		{f: &File{MimeType: DriveFolderMimeType, IsDir: true, WebContentLink: "https://example.com/d"}, want: false},
+	}
+
+	for i, tc := range testCases {
+		if got := streamable(tc.f); got != tc.want {
+			t.Errorf("#%d: got=%v want=%v", i, got, tc.want)
+		}
+	}
+}
+
+func TestCheckRangeRequests(t *testing.T) {
+	ranged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		http.ServeContent(w, r, "v.mp4", time.Time{}, strings.NewReader("content"))
+	}))
+	defer ranged.Close()
+
+	unranged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "content")
+	}))
+	defer unranged.Close()
+
+	client := oauth2.NewClient(oauth2.NoContext, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "token"}))
+	if err := checkRangeRequests(client, ranged.URL); err != nil {
+		t.Errorf("expected ranges to be honored with credentials, got %v", err)
+	}
+	if err := checkRangeRequests(http.DefaultClient, ranged.URL); err == nil {
+		t.Errorf("expected a non-nil error without credentials")
+	}
+	if err := checkRangeRequests(client, unranged.URL); err == nil {
+		t.Errorf("expected a non-nil error when ranges are not honored")
+	}
+}
+
+func TestAwaitAuthCode(t *testing.T) {
+	results := make(chan authCodeResult, 1)
+	results <- authCodeResult{code: "c1"}
+	if code, err := awaitAuthCode(context.Background(), results, time.Minute); err != nil || code != "c1" {
+		t.Errorf("got code=%q err=%v want %q", code, err, "c1")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := awaitAuthCode(ctx, make(chan authCodeResult), time.Minute); err != context.Canceled {
+		t.Errorf("expected the canceled context's error, got %v", err)
+	}
+
+	if _, err := awaitAuthCode(context.Background(), make(chan authCodeResult), 10*time.Millisecond); err == nil {
+		t.Errorf("expected a non-nil error after the timeout")
+	}
+}
+
+func TestApplyPlanOfOtherDirection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A remote only file that a pull plans to add would be
+	// deleted from the remote if the plan was applied by push
+	onlyRemote := &Change{Path: "/a", Src: &File{Id: "id-a", Name: "a", Size: 10, ModTime: time.Now()}}
+	var stdout bytes.Buffer
+	planPath := filepath.Join(dir, "plan.json")
+	puller := &Commands{opts: &Options{PlanPath: planPath}, log: log.New(nil, &stdout, &stdout)}
+	if planned, err := puller.planChanges([]*Change{onlyRemote}, false); !planned || err != nil {
+		t.Fatalf("planning the pull: planned %v err %v", planned, err)
+	}
+
+	blob, err := ioutil.ReadFile(planPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var plan changePlan
+	if err := json.Unmarshal(blob, &plan); err != nil || plan.Direction != PlanDirectionPull || len(plan.Changes) != 1 {
+		t.Fatalf("unexpected plan %s err %v", blob, err)
+	}
+
+	pusher := &Commands{opts: &Options{ApplyPlanPath: planPath}, log: log.New(nil, &stdout, &stdout)}
+	changes, err := pusher.appliedPlanChanges(true)
+	if err == nil || len(changes) != 0 {
+		t.Fatalf("applying a pull plan through push: got changes %v err %v, want an error", changes, err)
+	}
+	if !strings.Contains(err.Error(), "drive pull") {
+		t.Errorf("got %v, want it to point at drive pull", err)
+	}
+}
+
+func TestRetriedUploadSendsSmallerChunks(t *testing.T) {
+	const configured = 2 * 1024 * 1024
+	r := &Remote{}
+	args := &upsertOpt{uploadChunkSize: configured, src: &File{Name: "big", Size: 4 * configured}}
+
+	var content bytes.Reader
+	var chunkSizes []int
+	emitter := r.uploadEmitter(args, &content, func() (*File, bool, error) {
+		chunkSizes = append(chunkSizes, r.uploadChunkSize(args))
+		if len(chunkSizes) < 3 {
+			return nil, true, io.ErrUnexpectedEOF
+		}
+		return args.src, true, nil
+	})
+
+	// Make the attempts that the retrier would, without backing off
+	retrier := retryableChangeOp(emitter, false, 5)
+	for i := 0; i <= int(retrier.RetryCount); i++ {
+		v, _ := retrier.Do()
+		if ok, retryable := retrier.StatusCheck(v); ok || !retryable {
+			break
+		}
+	}
+
+	if want := []int{configured, configured / 2, configured / 4}; !reflect.DeepEqual(chunkSizes, want) {
+		t.Errorf("got chunk sizes %v want %v, each retry in smaller chunks", chunkSizes, want)
+	}
+}
+
+func TestPullEmptyRemoteFolder(t *testing.T) {
+	for _, noEmptyDirs := range []bool{false, true} {
+		dir, err := ioutil.TempDir("", "drive-empty-dirs")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		var stdout bytes.Buffer
+		g := &Commands{
+			context: &config.Context{AbsPath: dir},
+			opts:    &Options{NoEmptyDirs: noEmptyDirs},
+			log:     log.New(nil, &stdout, &stdout),
+		}
+		cl := []*Change{{Path: "/empty", Parent: "/", Src: &File{Name: "empty", IsDir: true, ModTime: time.Now()}}}
+
+		for _, c := range g.pullableChanges(cl) {
+			if err := localOpToChangerTranslator(g, c)(c, nil); err != nil {
+				t.Fatalf("noEmptyDirs %v: pulling %s: %v", noEmptyDirs, c.Path, err)
+			}
+		}
+
+		fi, err := os.Stat(filepath.Join(dir, "empty"))
+		if noEmptyDirs {
+			if !os.IsNotExist(err) {
+				t.Errorf("expected -no-empty-dirs to skip the empty folder, got %v %v", fi, err)
+			}
+			continue
+		}
+		if err != nil || !fi.IsDir() {
+			t.Errorf("expected an empty local directory for the empty remote folder, got %v %v", fi, err)
+		}
+	}
+}
+
+func TestResetRun(t *testing.T) {
+	g := &Commands{}
+	g.summary.record(&Change{Src: &File{Name: "a", Size: 10}}, nil)
+	g.summary.record(&Change{Src: &File{Name: "b", Size: 10}}, fmt.Errorf("failed"))
+	g.skippedNatives.add("/doc", "no export formats")
+	g.failures.add("/b", "failed")
+	if changed, failed := g.summary.changed(); changed != 1 || failed != 1 {
+		t.Fatalf("got %d changed and %d failed, want 1 and 1", changed, failed)
+	}
+
+	// The next poll only reports its own files
+	g.resetRun()
+	g.summary.record(&Change{Src: &File{Name: "c", Size: 10}, Dest: &File{Name: "c", Size: 5}}, nil)
+	if changed, failed := g.summary.changed(); changed != 1 || failed != 0 {
+		t.Errorf("got %d changed and %d failed after the reset, want 1 and 0", changed, failed)
+	}
+	if paths, _ := g.skippedNatives.sorted(); len(paths) != 0 {
+		t.Errorf("got skipped natives %v from the last run", paths)
+	}
+	if paths, _ := g.failures.sorted(); len(paths) != 0 {
+		t.Errorf("got failures %v from the last run", paths)
+	}
+}
+
+func TestRemoveStaleLockKeepsReplacedLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "drive-lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	host, _ := os.Hostname()
+	lockPath := dir + "/lock"
+	stale, _ := json.Marshal(&lockHolder{PID: 1 << 30, Host: host})
+	if err := ioutil.WriteFile(lockPath, stale, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Another waiter removed the stale lock and took the lock in the meantime
+	fresh, _ := json.Marshal(&lockHolder{PID: os.Getpid(), Host: host, Started: time.Now().UTC()})
+	if err := ioutil.WriteFile(lockPath, fresh, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if removed, err := removeStaleLock(lockPath, stale); removed || err != nil {
+		t.Fatalf("got removed %v err %v, want the fresh lock kept", removed, err)
+	}
+	if _, data, err := readLockHolder(lockPath); err != nil || !bytes.Equal(data, fresh) {
+		t.Fatalf("got lock %s err %v, want the fresh lock", data, err)
+	}
+
+	if removed, err := removeStaleLock(lockPath, fresh); !removed || err != nil {
+		t.Errorf("got removed %v err %v, want the lock that was found stale removed", removed, err)
+	}
+}
+
+func TestPathMapSortedCopies(t *testing.T) {
+	var pm pathMap
+	pm.add("/b", "2")
+	pm.add("/a", "1")
+
+	paths, values := pm.sorted()
+	pm.add("/c", "3")
+	pm.add("/a", "changed")
+
+	if want := []string{"/a", "/b"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("got paths %v want %v", paths, want)
+	}
+	if want := map[string]string{"/a": "1", "/b": "2"}; !reflect.DeepEqual(values, want) {
+		t.Errorf("got values %v want %v, unchanged by later additions", values, want)
+	}
+}
+
+func TestChunkedListOptions(t *testing.T) {
+	testCases := []struct {
+		opts    Options
+		want    bool
+		wantErr bool
+	}{
+		{opts: Options{}},
+		{opts: Options{ChunkedList: true}, want: true},
+		{opts: Options{ConcurrentList: 8}},
+		{opts: Options{ChunkedList: true, ConcurrentList: 8}, wantErr: true},
+	}
+
+	for i, tc := range testCases {
+		var stdout bytes.Buffer
+		opts := tc.opts
+		g := &Commands{opts: &opts, log: log.New(nil, &stdout, &stdout)}
+		got, err := g.chunkedList(nil)
+		if got != tc.want || (err != nil) != tc.wantErr {
+			t.Errorf("#%d: got %v err %v, want %v and an error %v", i, got, err, tc.want, tc.wantErr)
+		}
+	}
+}
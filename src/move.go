@@ -25,6 +25,7 @@ type moveOpt struct {
 	dest       string
 	byId       bool
 	keepParent bool
+	newName    string
 }
 
 type RenameMode uint
@@ -54,6 +55,9 @@ func (g *Commands) Move(byId, keepParent bool) error {
 	}
 
 	rest, dest := g.opts.Sources[:argc-1], g.opts.Sources[argc-1]
+	if g.opts.RenameFolder != "" && len(rest) != 1 {
+		return invalidArgumentsErr(fmt.Errorf("move: `%s` expects exactly one folder to move, instead got: %v", CLIOptionRenameFolder, rest))
+	}
 
 	var composedError error = nil
 
@@ -70,6 +74,7 @@ func (g *Commands) Move(byId, keepParent bool) error {
 			dest:       dest,
 			byId:       byId,
 			keepParent: keepParent,
+			newName:    g.opts.RenameFolder,
 		}
 
 		if err := g.move(&opt); err != nil {
@@ -97,6 +102,10 @@ func (g *Commands) move(opt *moveOpt) (err error) {
 		return nonExistantRemoteErr(fmt.Errorf("src: '%s' could not be found", opt.src))
 	}
 
+	if opt.newName != "" && !remSrc.IsDir {
+		return invalidArgumentsErr(fmt.Errorf("src: '%s' is not a folder, use `%s` to rename files", opt.src, RenameKey))
+	}
+
 	if newParent, err = g.rem.FindByPath(opt.dest); err != nil {
 		return remoteLookupErr(fmt.Errorf("dest: '%s' %v", opt.dest, err))
 	}
@@ -105,21 +114,33 @@ func (g *Commands) move(opt *moveOpt) (err error) {
 		return illogicalStateErr(fmt.Errorf("dest: '%s' must be an existent folder", opt.dest))
 	}
 
+	var oldParent *File
 	if !opt.byId {
 		parentPath := g.parentPather(opt.src)
-		oldParent, parErr := g.rem.FindByPath(parentPath)
+		var parErr error
+		oldParent, parErr = g.rem.FindByPath(parentPath)
 		if parErr != nil && parErr != ErrPathNotExists {
 			return parErr
 		}
 
 		// TODO: If oldParent is not found, retry since it may have been moved temporarily at least
-		if oldParent != nil && oldParent.Id == newParent.Id {
-			return illogicalStateErr(fmt.Errorf("src and dest are the same srcParentId %s destParentId %s",
-				customQuote(oldParent.Id), customQuote(newParent.Id)))
+		if oldParent == nil && !opt.keepParent {
+			return illogicalStateErr(fmt.Errorf("non existent parent '%s' for src", parentPath))
 		}
 	}
 
-	newFullPath := filepath.Join(opt.dest, remSrc.Name)
+	// Moving within the same parent is only meaningful as a rename
+	sameParent := oldParent != nil && oldParent.Id == newParent.Id
+	if sameParent && opt.newName == "" {
+		return illogicalStateErr(fmt.Errorf("src and dest are the same srcParentId %s destParentId %s",
+			customQuote(oldParent.Id), customQuote(newParent.Id)))
+	}
+
+	newName := remSrc.Name
+	if opt.newName != "" {
+		newName = opt.newName
+	}
+	newFullPath := filepath.Join(opt.dest, urlToPath(newName, true))
 
 	// Check for a duplicate
 	var dupCheck *File
@@ -142,31 +163,63 @@ func (g *Commands) move(opt *moveOpt) (err error) {
 		return illogicalStateErr(fmt.Errorf("move: cannot move '%s' to itself", opt.src))
 	}
 
-	if err = g.rem.insertParent(remSrc.Id, newParent.Id); err != nil {
-		return err
+	if remSrc.IsDir {
+		cyclic, cErr := g.hasAncestor(newParent, remSrc.Id)
+		if cErr != nil {
+			return cErr
+		}
+		if cyclic {
+			return illogicalStateErr(fmt.Errorf("move: cannot move '%s' into its own descendant '%s'", opt.src, opt.dest))
+		}
 	}
 
-	if opt.byId { // TODO: Also take out this current parent
-		return nil
+	if sameParent {
+		_, err = g.rem.rename(remSrc.Id, newName)
+		return err
 	}
 
-	if !opt.keepParent {
-		err = g.removeParent(remSrc.Id, opt.src)
+	// Only the parents of src have to be changed since its
+	// contents move along with it. TODO: by id, also take out the current parent
+	removeParentId := ""
+	if !opt.byId && !opt.keepParent {
+		removeParentId = oldParent.Id
 	}
 
+	_, err = g.rem.reparent(remSrc.Id, newParent.Id, removeParentId, opt.newName)
 	return err
 }
 
-func (g *Commands) removeParent(fileId, relToRootPath string) error {
-	parentPath := g.parentPather(relToRootPath)
-	parent, pErr := g.rem.FindByPath(parentPath)
-	if pErr != nil {
-		return pErr
-	}
-	if parent == nil {
-		return illogicalStateErr(fmt.Errorf("non existent parent '%s' for src", parentPath))
+// hasAncestor reports whether the folder with id ancestorId is either
+// f itself or any of the folders that f is nested in.
+func (g *Commands) hasAncestor(f *File, ancestorId string) (bool, error) {
+	visited := make(map[string]bool)
+	queue := []*File{f}
+
+	for len(queue) >= 1 {
+		cur := queue[0]
+		queue = queue[1:]
+
+		if cur.Id == ancestorId {
+			return true, nil
+		}
+
+		for _, parent := range cur.Parents {
+			if parent == nil || parent.IsRoot || visited[parent.Id] {
+				continue
+			}
+			visited[parent.Id] = true
+
+			parentFile, err := g.rem.FindById(parent.Id)
+			if err != nil {
+				return false, err
+			}
+			if parentFile != nil {
+				queue = append(queue, parentFile)
+			}
+		}
 	}
-	return g.rem.removeParent(fileId, parent.Id)
+
+	return false, nil
 }
 
 func (g *Commands) renameLocal(oldRelToRootPath, newRelToRootPath string) error {
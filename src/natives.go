@@ -0,0 +1,105 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	DriveDocumentMimeType     = "application/vnd.google-apps.document"
+	DriveSpreadsheetMimeType  = "application/vnd.google-apps.spreadsheet"
+	DrivePresentationMimeType = "application/vnd.google-apps.presentation"
+	DriveDrawingMimeType      = "application/vnd.google-apps.drawing"
+	DriveFormMimeType         = "application/vnd.google-apps.form"
+	DriveJamboardMimeType     = "application/vnd.google-apps.jam"
+	DriveSiteMimeType         = "application/vnd.google-apps.site"
+	DriveMapMimeType          = "application/vnd.google-apps.map"
+)
+
+// GoogleAppsMimeTypePrefix is the prefix of the mimeTypes of Google-native files.
+const GoogleAppsMimeTypePrefix = "application/vnd.google-apps."
+
+// googleNative reports whether f is a Google-native file such as a Doc
+// or Sheet. Folders have such a mimeType too but are not considered native.
+func googleNative(f *File) bool {
+	return f != nil && !f.IsDir && strings.HasPrefix(f.MimeType, GoogleAppsMimeTypePrefix)
+}
+
+// nativeType describes a Google-native type and the
+// formats that its files can be exported as, if any.
+type nativeType struct {
+	name    string
+	exports []string
+}
+
+var nativeTypes = map[string]nativeType{
+	DriveDocumentMimeType:     {name: "Google Doc", exports: []string{"docx", "odt", "rtf", "pdf", "txt", "html"}},
+	DriveSpreadsheetMimeType:  {name: "Google Sheet", exports: []string{"xlsx", "ods", "csv", "tsv", "pdf"}},
+	DrivePresentationMimeType: {name: "Google Slides", exports: []string{"pptx", "odp", "pdf", "txt"}},
+	DriveDrawingMimeType:      {name: "Google Drawing", exports: []string{"png", "jpg", "svg", "pdf"}},
+	DriveJamboardMimeType:     {name: "Jamboard", exports: []string{"pdf"}},
+	DriveFormMimeType:         {name: "Google Form"},
+	DriveSiteMimeType:         {name: "Google Site"},
+	DriveMapMimeType:          {name: "Google My Map"},
+}
+
+// nativeExportFormats returns the formats that f can be exported as. They are
+// derived from its exportLinks with the formats known for its type first.
+func nativeExportFormats(f *File) (formats []string) {
+	if f == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, format := range nativeTypes[f.MimeType].exports {
+		if _, ok := f.ExportLinks[mimeTypeFromExt(format)]; ok {
+			formats = append(formats, format)
+			seen[format] = true
+		}
+	}
+
+	var others []string
+	for mimeType := range f.ExportLinks {
+		if format := exportFormatName(mimeType); !seen[format] && format != mimeType {
+			others = append(others, format)
+			seen[format] = true
+		}
+	}
+
+	sort.Strings(others)
+	return append(formats, others...)
+}
+
+// nativeSkipReason explains why the Google-native file f, that has no
+// downloadable content, was skipped given the requested exports.
+func nativeSkipReason(f *File, exports []string) string {
+	name := "a Google-native file"
+	if nt, ok := nativeTypes[f.MimeType]; ok {
+		name = "a " + nt.name
+	}
+
+	formats := nativeExportFormats(f)
+	switch {
+	case len(formats) < 1:
+		return fmt.Sprintf("it is %s, which has no export formats", name)
+	case len(exports) < 1:
+		return fmt.Sprintf("it is %s, which can only be exported as %s", name, strings.Join(formats, ", "))
+	default:
+		return fmt.Sprintf("none of the export formats %v are available, %s can be exported as %s", exports, name, strings.Join(formats, ", "))
+	}
+}
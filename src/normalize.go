@@ -0,0 +1,76 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// The Unicode normalization forms that names are compared in. macOS
+// for example stores names decomposed in NFD, while Linux keeps them
+// as they were typed, which is mostly NFC.
+const (
+	UnicodeNFC  = "nfc"
+	UnicodeNFD  = "nfd"
+	UnicodeNone = "none"
+)
+
+// ParseUnicodeNormalization validates form case-insensitively,
+// an empty form is the default of NFC.
+func ParseUnicodeNormalization(form string) (string, error) {
+	form = strings.ToLower(strings.TrimSpace(form))
+	switch form {
+	case "":
+		return UnicodeNFC, nil
+	case UnicodeNFC, UnicodeNFD, UnicodeNone:
+		return form, nil
+	}
+	return "", invalidArgumentsErr(fmt.Errorf("unknown unicode normalization %q, expecting one of %s, %s or %s", form, UnicodeNFC, UnicodeNFD, UnicodeNone))
+}
+
+// unicodeNormalizer returns the function that normalizes names to form,
+// nil if names are compared as they are. Unknown forms are taken as NFC.
+func unicodeNormalizer(form string) func(string) string {
+	switch strings.ToLower(strings.TrimSpace(form)) {
+	case UnicodeNone:
+		return nil
+	case UnicodeNFD:
+		return norm.NFD.String
+	default:
+		return norm.NFC.String
+	}
+}
+
+// titleVariants returns title along with its composed and decomposed
+// forms, without repeats, for remote titles stored in either form to match.
+func titleVariants(title string) []string {
+	variants := []string{title}
+	for _, variant := range []string{norm.NFC.String(title), norm.NFD.String(title)} {
+		seen := false
+		for _, prev := range variants {
+			if prev == variant {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			variants = append(variants, variant)
+		}
+	}
+	return variants
+}
@@ -0,0 +1,44 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	drive "google.golang.org/api/drive/v2"
+)
+
+// ownedByMe reports whether the authenticated user is one of the owners.
+// Files in shared drives have no owners so they are never owned.
+func ownedByMe(owners []*drive.User) bool {
+	for _, owner := range owners {
+		if owner != nil && owner.IsAuthenticatedUser {
+			return true
+		}
+	}
+	return false
+}
+
+// skipNotOwned reports whether the remote file at relToRootPath is to be
+// skipped for not being owned by the user while OwnedOnly is set. Skipped
+// folders are not traversed either, so shared folders are noted only once.
+func (g *Commands) skipNotOwned(relToRootPath string, r *File) bool {
+	if !g.opts.OwnedOnly || r == nil || r.OwnedByMe || rootLike(relToRootPath) {
+		return false
+	}
+
+	if !g.opts.SummaryOnly {
+		g.log.LogErrf("%s: skipping, it is shared with you but not owned by you\n", relToRootPath)
+	}
+	return true
+}
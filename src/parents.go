@@ -0,0 +1,91 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"sort"
+	"strings"
+)
+
+func parentsAsLabels(mask int) bool {
+	return (mask & ParentsAsLabels) != 0
+}
+
+func multiParented(f *File) bool {
+	return f != nil && len(f.Parents) > 1
+}
+
+// parentFolderPaths returns the paths of all the folders that f is in.
+// Files created before Drive moved to single parents can be in several.
+func (g *Commands) parentFolderPaths(f *File) (paths []string) {
+	seen := make(map[string]bool)
+	for _, parent := range f.Parents {
+		if parent == nil {
+			continue
+		}
+
+		var backPaths []string
+		if parent.IsRoot {
+			backPaths = []string{RemoteSeparator}
+		} else if parentPaths, err := g.rem.FindBackPaths(parent.Id); err != nil {
+			// The folder might not be visible to us, so refer to it by id
+			backPaths = []string{parent.Id}
+		} else {
+			backPaths = parentPaths
+		}
+
+		for _, p := range backPaths {
+			if p != parent.Id {
+				p = remotePathJoin(p)
+			}
+			if !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths
+}
+
+// withParentPaths returns opt with the folder paths of f
+// if it is in several and parents are listed as labels.
+func (g *Commands) withParentPaths(f *File, opt attribute) attribute {
+	if parentsAsLabels(g.opts.TypeMask) && multiParented(f) {
+		opt.parentPaths = g.parentFolderPaths(f)
+	}
+	return opt
+}
+
+// summarizeMultiParented reports the remote files that are in several folders,
+// since pulling them only puts them at the one path that they were reached by.
+func (g *Commands) summarizeMultiParented(cl []*Change) {
+	var multiParentedChanges []*Change
+	for _, change := range cl {
+		if change != nil && multiParented(change.Src) {
+			multiParentedChanges = append(multiParentedChanges, change)
+		}
+	}
+
+	if len(multiParentedChanges) < 1 {
+		return
+	}
+
+	g.log.Logf("\n%d remote file(s) are in more than one folder and are only pulled at one path:\n", len(multiParentedChanges))
+	for _, change := range multiParentedChanges {
+		g.log.Logf("\t%s is in %s\n", change.Path, strings.Join(g.parentFolderPaths(change.Src), " & "))
+	}
+}
@@ -0,0 +1,263 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+)
+
+var planActions = map[Operation]string{
+	OpAdd:           "add",
+	OpDelete:        "delete",
+	OpMod:           "modify",
+	OpModConflict:   "modify-conflict",
+	OpIndexAddition: "index",
+}
+
+// planEntry is a change of a plan as written by -plan, sizes
+// are null for the side that the file doesn't exist on.
+type planEntry struct {
+	Action     string `json:"action"`
+	Path       string `json:"path"`
+	Reason     string `json:"reason"`
+	LocalSize  *int64 `json:"localSize"`
+	RemoteSize *int64 `json:"remoteSize"`
+	// RemoteId is the id of the remote file, if it exists, to
+	// get at it when the plan is applied.
+	RemoteId string `json:"remoteId,omitempty"`
+	// Force and IgnoreConflict are set if the change was planned
+	// with them set, so that it is applied the same way.
+	Force          bool `json:"force,omitempty"`
+	IgnoreConflict bool `json:"ignoreConflict,omitempty"`
+}
+
+// The directions of a plan, the command that it was written by.
+const (
+	PlanDirectionPush = "push"
+	PlanDirectionPull = "pull"
+)
+
+// changePlan is a plan as written by -plan. It can only be applied by
+// the command of its direction since the source of its changes, and
+// so what each of its actions means, depends on the direction.
+type changePlan struct {
+	Direction string      `json:"direction"`
+	Changes   []planEntry `json:"changes"`
+}
+
+func planDirection(push bool) string {
+	if push {
+		return PlanDirectionPush
+	}
+	return PlanDirectionPull
+}
+
+func planSize(f *File) *int64 {
+	if f == nil {
+		return nil
+	}
+	size := f.Size
+	return &size
+}
+
+// changeReason explains why c is needed, in terms of the
+// side that the source of a push or pull is on.
+func changeReason(c *Change, push bool) string {
+	srcSide, destSide := "remote", "local"
+	if push {
+		srcSide, destSide = destSide, srcSide
+	}
+
+	op := c.Op()
+	switch {
+	case op == OpIndexAddition:
+		return "not indexed"
+	case op == OpAdd && c.Dest == nil:
+		return "only " + srcSide
+	case op == OpDelete:
+		return "only " + destSide
+	case c.Src == nil || c.Dest == nil:
+		return ""
+	}
+
+	mask := fileDifferences(c.Src, c.Dest, c.IgnoreChecksum)
+	var reasons []string
+	for _, difference := range []struct {
+		mask   int
+		reason string
+	}{
+		{DifferDirType, "type differs"},
+		{DifferSize, "size differs"},
+		{DifferMd5Checksum, "checksum differs"},
+		{DifferModTime, "modtime differs"},
+	} {
+		if mask&difference.mask != 0 {
+			reasons = append(reasons, difference.reason)
+		}
+	}
+
+	if len(reasons) < 1 && c.Force {
+		return "forced"
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func changePlanEntry(c *Change, push bool) planEntry {
+	local, remote := c.Dest, c.Src
+	if push {
+		local, remote = c.Src, c.Dest
+	}
+
+	entry := planEntry{
+		Action:     planActions[c.Op()],
+		Path:       c.Path,
+		Reason:     changeReason(c, push),
+		LocalSize:  planSize(local),
+		RemoteSize: planSize(remote),
+
+		Force:          c.Force,
+		IgnoreConflict: c.IgnoreConflict,
+	}
+	if remote != nil {
+		entry.RemoteId = remote.Id
+	}
+	return entry
+}
+
+func changesPlan(changes []*Change, push bool) *changePlan {
+	plan := &changePlan{Direction: planDirection(push), Changes: []planEntry{}}
+	for _, c := range changes {
+		if c.Op() != OpNone {
+			plan.Changes = append(plan.Changes, changePlanEntry(c, push))
+		}
+	}
+	return plan
+}
+
+// planEntryChange returns the change that entry planned, between the local
+// and remote files that it was planned for, forced or ignoring conflicts only
+// if it was planned so.
+func planEntryChange(entry planEntry, local, remote *File, push bool) *Change {
+	src, dest := remote, local
+	if push {
+		src, dest = local, remote
+	}
+
+	c := &Change{
+		Path:           entry.Path,
+		Parent:         path.Dir(entry.Path),
+		Src:            src,
+		Dest:           dest,
+		Force:          entry.Force,
+		IgnoreConflict: entry.IgnoreConflict,
+	}
+	return c
+}
+
+// plannedFiles looks up the local and remote files of entry, for
+// the sides that the files existed on when it was planned.
+func (g *Commands) plannedFiles(entry planEntry) (local, remote *File, err error) {
+	if entry.LocalSize != nil {
+		absPath := g.context.AbsPathOf(entry.Path)
+		fi, err := os.Lstat(absPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		local = NewLocalFile(absPath, fi)
+	}
+
+	if entry.RemoteSize != nil {
+		if entry.RemoteId == "" {
+			return nil, nil, fmt.Errorf("the plan has no id of the remote file")
+		}
+		if remote, err = g.rem.FindById(entry.RemoteId); err == nil && remote == nil {
+			err = ErrPathNotExists
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return local, remote, nil
+}
+
+// planChanges writes the changes as a plan to review if asked to, in
+// which case done is set since nothing is to be applied.
+func (g *Commands) planChanges(changes []*Change, push bool) (done bool, err error) {
+	planPath := g.opts.PlanPath
+	if planPath == "" {
+		return false, nil
+	}
+
+	plan := changesPlan(changes, push)
+	blob, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return true, err
+	}
+	if err := ioutil.WriteFile(planPath, append(blob, '\n'), 0644); err != nil {
+		return true, err
+	}
+
+	g.log.Logf("Wrote the plan of %d change(s) to %s, nothing was changed\n", len(plan.Changes), planPath)
+	return true, nil
+}
+
+// appliedPlanChanges returns the changes of the plan to apply, made from its
+// entries as they were planned instead of being computed again. Entries whose
+// files can no longer be found are reported and left out. A plan written by the
+// other command is refused since its sources are on the other side.
+func (g *Commands) appliedPlanChanges(push bool) ([]*Change, error) {
+	applyPlanPath := g.opts.ApplyPlanPath
+	if g.opts.PlanPath != "" {
+		return nil, invalidArgumentsErr(fmt.Errorf("cannot both write and apply a plan"))
+	}
+
+	blob, err := ioutil.ReadFile(applyPlanPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var plan changePlan
+	if err := json.Unmarshal(blob, &plan); err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: %v", applyPlanPath, err))
+	}
+	switch plan.Direction {
+	case planDirection(push):
+	case PlanDirectionPush, PlanDirectionPull:
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: is a plan to %s, it can only be applied by `drive %s`", applyPlanPath, plan.Direction, plan.Direction))
+	default:
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: unknown direction %q of the plan, expecting %q or %q", applyPlanPath, plan.Direction, PlanDirectionPush, PlanDirectionPull))
+	}
+
+	var changes []*Change
+	for _, entry := range plan.Changes {
+		local, remote, err := g.plannedFiles(entry)
+		if err != nil {
+			g.log.LogErrf("%s: planned to %s it but %v, skipping\n", entry.Path, entry.Action, err)
+			continue
+		}
+
+		c := planEntryChange(entry, local, remote, push)
+		c.IgnoreChecksum = g.opts.IgnoreChecksum
+		c.g = g
+		changes = append(changes, c)
+	}
+	return changes, nil
+}
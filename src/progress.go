@@ -0,0 +1,253 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const progressJSONInterval = 500 * time.Millisecond
+
+// progressEvent is a line of the -progress-json stream. File is empty
+// when no file is in flight, and speed is the overall bytes per second.
+type progressEvent struct {
+	File         string  `json:"file"`
+	BytesDone    int64   `json:"bytesDone"`
+	BytesTotal   int64   `json:"bytesTotal"`
+	OverallDone  int64   `json:"overallDone"`
+	OverallTotal int64   `json:"overallTotal"`
+	Speed        float64 `json:"speed"`
+}
+
+type fileProgress struct {
+	done, total int64
+}
+
+// progressJSONReporter periodically writes a progressEvent for each file
+// in flight. All of its methods are no-ops on a nil reporter so that the
+// transfers don't have to check whether the stream was asked for.
+type progressJSONReporter struct {
+	sync.Mutex
+
+	w            io.Writer
+	close        func() error
+	start        time.Time
+	overallDone  int64
+	overallTotal int64
+	// inFlight are the files being transferred, in the order they started.
+	inFlight []string
+	files    map[string]*fileProgress
+
+	stop    chan bool
+	stopped chan bool
+}
+
+// openProgressJSON opens the destination of the stream, which is either
+// a path or a file descriptor inherited from the parent as fd:<n>.
+func openProgressJSON(dest string) (io.Writer, func() error, error) {
+	if !strings.HasPrefix(dest, "fd:") {
+		f, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+		if err != nil {
+			return nil, nil, err
+		}
+		return f, f.Close, nil
+	}
+
+	fd, err := strconv.Atoi(strings.TrimPrefix(dest, "fd:"))
+	if err != nil || fd < 1 {
+		return nil, nil, invalidArgumentsErr(fmt.Errorf("%q is not a valid file descriptor", dest))
+	}
+
+	f := os.NewFile(uintptr(fd), dest)
+	if fd == 1 || fd == 2 {
+		// Leave stdout and stderr open for the rest of the output
+		return f, func() error { return nil }, nil
+	}
+	return f, f.Close, nil
+}
+
+func newProgressJSONReporter(w io.Writer, overallTotal int64) *progressJSONReporter {
+	return &progressJSONReporter{
+		w:            w,
+		close:        func() error { return nil },
+		start:        time.Now(),
+		overallTotal: overallTotal,
+		files:        make(map[string]*fileProgress),
+	}
+}
+
+// startProgressJSON starts the stream if asked for, which is
+// to be finished once all the changes have been applied.
+func (g *Commands) startProgressJSON(overallTotal int64) (*progressJSONReporter, error) {
+	if g.opts.ProgressJSON == "" {
+		return nil, nil
+	}
+
+	w, closer, err := openProgressJSON(g.opts.ProgressJSON)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := newProgressJSONReporter(w, overallTotal)
+	pr.close = closer
+	pr.stop, pr.stopped = make(chan bool), make(chan bool)
+
+	go func() {
+		defer close(pr.stopped)
+		tick := time.NewTicker(progressJSONInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				pr.emit()
+			case <-pr.stop:
+				return
+			}
+		}
+	}()
+
+	g.progressJSON = pr
+	return pr, nil
+}
+
+func (pr *progressJSONReporter) fileStarted(p string, total int64) {
+	if pr == nil {
+		return
+	}
+
+	pr.Lock()
+	defer pr.Unlock()
+	if _, ok := pr.files[p]; !ok {
+		pr.inFlight = append(pr.inFlight, p)
+	}
+	pr.files[p] = &fileProgress{total: total}
+}
+
+// fileProgressFn returns the function that the bytes transferred
+// of the file at p are to be reported to, if any.
+func (pr *progressJSONReporter) fileProgressFn(p string) func(int) {
+	if pr == nil {
+		return nil
+	}
+
+	return func(n int) {
+		pr.Lock()
+		defer pr.Unlock()
+		if fp, ok := pr.files[p]; ok {
+			fp.done += int64(n)
+		}
+	}
+}
+
+// fileDone reports the file at p as done with its final progress
+// so that files shorter than the interval still show up.
+func (pr *progressJSONReporter) fileDone(p string) {
+	if pr == nil {
+		return
+	}
+
+	pr.Lock()
+	defer pr.Unlock()
+	fp, ok := pr.files[p]
+	if !ok {
+		return
+	}
+
+	if fp.done < fp.total {
+		fp.done = fp.total
+	}
+	pr.writeEvent(p, fp)
+	delete(pr.files, p)
+	for i, inFlight := range pr.inFlight {
+		if inFlight == p {
+			pr.inFlight = append(pr.inFlight[:i], pr.inFlight[i+1:]...)
+			break
+		}
+	}
+}
+
+func (pr *progressJSONReporter) addOverall(n int64) {
+	if pr == nil {
+		return
+	}
+
+	pr.Lock()
+	defer pr.Unlock()
+	pr.overallDone += n
+}
+
+func (pr *progressJSONReporter) emit() {
+	pr.Lock()
+	defer pr.Unlock()
+
+	if len(pr.inFlight) < 1 {
+		pr.writeEvent("", &fileProgress{})
+		return
+	}
+	for _, p := range pr.inFlight {
+		pr.writeEvent(p, pr.files[p])
+	}
+}
+
+// writeEvent writes the event of a file, the lock has to be held.
+func (pr *progressJSONReporter) writeEvent(p string, fp *fileProgress) {
+	speed := float64(0)
+	if elapsed := time.Since(pr.start).Seconds(); elapsed > 0 {
+		speed = float64(pr.overallDone) / elapsed
+	}
+
+	blob, err := json.Marshal(&progressEvent{
+		File:         p,
+		BytesDone:    fp.done,
+		BytesTotal:   fp.total,
+		OverallDone:  pr.overallDone,
+		OverallTotal: pr.overallTotal,
+		Speed:        speed,
+	})
+	if err != nil {
+		return
+	}
+
+	if _, err := pr.w.Write(append(blob, '\n')); err != nil {
+		DebugPrintf("progress-json: %v", err)
+	}
+}
+
+// finish writes the final overall event and closes the stream.
+func (pr *progressJSONReporter) finish() {
+	if pr == nil {
+		return
+	}
+
+	if pr.stop != nil {
+		close(pr.stop)
+		<-pr.stopped
+	}
+
+	pr.Lock()
+	pr.writeEvent("", &fileProgress{})
+	pr.Unlock()
+
+	if err := pr.close(); err != nil {
+		DebugPrintf("progress-json: closing %v", err)
+	}
+}
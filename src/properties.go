@@ -0,0 +1,74 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// PublicPropertyVisibility makes custom properties visible to all apps
+// and not only to the one that set them.
+const PublicPropertyVisibility = "PUBLIC"
+
+// ParseProperties parses key=value pairs into custom file properties.
+func ParseProperties(pairs ...string) (map[string]string, error) {
+	properties := make(map[string]string)
+	for _, pair := range pairs {
+		splits := strings.SplitN(pair, "=", 2)
+		if len(splits) != 2 || strings.TrimSpace(splits[0]) == "" {
+			return nil, invalidArgumentsErr(fmt.Errorf("property %q must be of the form key=value", pair))
+		}
+
+		properties[strings.TrimSpace(splits[0])] = splits[1]
+	}
+
+	return properties, nil
+}
+
+func toDriveProperties(properties map[string]string) (driveProperties []*drive.Property) {
+	var keys []string
+	for key := range properties {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		driveProperties = append(driveProperties, &drive.Property{
+			Key:        key,
+			Value:      properties[key],
+			Visibility: PublicPropertyVisibility,
+		})
+	}
+
+	return driveProperties
+}
+
+func prettyProperties(properties []*drive.Property) string {
+	var pairs []string
+	for _, property := range properties {
+		if property == nil {
+			continue
+		}
+		pairs = append(pairs, fmt.Sprintf("%s=%s", property.Key, property.Value))
+	}
+
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
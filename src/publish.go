@@ -15,16 +15,106 @@
 package drive
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
+// publishedFile is an entry of the manifest of published files.
+type publishedFile struct {
+	Path  string `json:"path"`
+	Url   string `json:"url"`
+	IsDir bool   `json:"isDir,omitempty"`
+}
+
 func (c *Commands) Publish(byId bool) error {
+	role, err := c.publishRole()
+	if err != nil {
+		return err
+	}
+	withLink := (c.opts.TypeMask & WithLink) == WithLink
+
+	var manifest []*publishedFile
 	for _, relToRoot := range c.opts.Sources {
-		if pubErr := c.pub(relToRoot, byId); pubErr != nil {
+		published, pubErr := c.pub(relToRoot, byId, role, withLink)
+		manifest = append(manifest, published...)
+		if pubErr != nil {
 			c.log.LogErrf("\033[91mPub\033[00m %s:  %v\n", relToRoot, pubErr)
 		}
 	}
-	return nil
+
+	if c.opts.PublishManifest == "" {
+		return nil
+	}
+	return writePublishManifest(c.opts.PublishManifest, manifest)
+}
+
+// writePublishManifest saves manifest to manifestPath as CSV
+// if it has a ".csv" extension, otherwise as JSON.
+func writePublishManifest(manifestPath string, manifest []*publishedFile) error {
+	f, err := os.Create(manifestPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if strings.ToLower(filepath.Ext(manifestPath)) != ".csv" {
+		if manifest == nil {
+			manifest = []*publishedFile{}
+		}
+		data, jErr := json.MarshalIndent(manifest, "", "  ")
+		if jErr != nil {
+			return jErr
+		}
+		_, err = f.Write(append(data, '\n'))
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Write([]string{"path", "url"})
+	for _, published := range manifest {
+		w.Write([]string{published.Path, published.Url})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// publishRole returns the role that anyone is granted on published
+// files, which is reader unless another role was requested.
+func (c *Commands) publishRole() (Role, error) {
+	if c.opts.Meta == nil {
+		return Reader, nil
+	}
+
+	roles := (*c.opts.Meta)[RoleKey]
+	switch len(roles) {
+	case 0:
+		return Reader, nil
+	case 1:
+		role := reverseRoleResolve(roles[0])
+		if strings.ToLower(roles[0]) == role.String() && (role == Reader || role == Commenter) {
+			return role, nil
+		}
+	}
+
+	return UnknownRole, invalidArgumentsErr(fmt.Errorf("publishing can only grant one of the roles: reader, commenter"))
+}
+
+// publishScope describes how exposed a file published with role is.
+func publishScope(role Role, withLink bool) string {
+	ability := "view"
+	if role == Commenter {
+		ability = "comment on"
+	}
+
+	if withLink {
+		return fmt.Sprintf("anyone with the link can %s it", ability)
+	}
+	return fmt.Sprintf("public on the web, anyone can find and %s it", ability)
 }
 
 func (c *Commands) remFileResolve(relToRoot string, byId bool) (*File, error) {
@@ -36,25 +126,119 @@ func (c *Commands) remFileResolve(relToRoot string, byId bool) (*File, error) {
 	return resolver(relToRoot)
 }
 
-func (c *Commands) pub(relToRoot string, byId bool) error {
-	file, err := c.remFileResolve(relToRoot, byId)
-	if err != nil || file == nil {
+// publishedUrl returns the link to view f, folders get a folder view link.
+func publishedUrl(f *File) string {
+	if f.IsDir {
+		return fmt.Sprintf("%s/drive/folders/%s", DriveResourceEntryURL, f.Id)
+	}
+	return f.Url()
+}
+
+// streamable reports whether f is audio or video whose
+// content can be played directly from its content link.
+func streamable(f *File) bool {
+	if f == nil || f.IsDir || f.WebContentLink == "" {
+		return false
+	}
+	return strings.HasPrefix(f.MimeType, "video/") || strings.HasPrefix(f.MimeType, "audio/")
+}
+
+// checkRangeRequests reports an error if the content at url is
+// not served in ranges, since players then can't seek through it.
+// The content is only reachable with credentials, hence client.
+func checkRangeRequests(client *http.Client, url string) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
 		return err
 	}
+	req.Header.Set("Range", "bytes=0-0")
 
-	link, err := c.rem.Publish(file.Id)
+	res, err := client.Do(req)
 	if err != nil {
 		return err
 	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("range requests are not honored, got %q", res.Status)
+	}
+	return nil
+}
 
-	link = file.Url()
+func (c *Commands) pub(relToRoot string, byId bool, role Role, withLink bool) (published []*publishedFile, err error) {
+	file, err := c.remFileResolve(relToRoot, byId)
+	if err != nil || file == nil {
+		return nil, err
+	}
 
+	// Files published by id are listed in the manifest by their names
+	label := relToRoot
 	if byId {
-		relToRoot = fmt.Sprintf("%s aka %s", relToRoot, file.Name)
+		label = fmt.Sprintf("%s aka %s", relToRoot, file.Name)
+		relToRoot = file.Name
 	}
 
-	c.log.Logf("%s published on %s\n", relToRoot, link)
-	return nil
+	root := &publishedFile{Path: relToRoot}
+	queue := []*publishedFile{root}
+	files := []*File{file}
+
+	for len(files) >= 1 {
+		file, entry := files[0], queue[0]
+		files, queue = files[1:], queue[1:]
+
+		if _, pErr := c.rem.Publish(file.Id, role, withLink); pErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", entry.Path, pErr))
+			continue
+		}
+
+		entry.Url = publishedUrl(file)
+		if c.opts.Stream && streamable(file) {
+			entry.Url = file.WebContentLink
+			if rErr := checkRangeRequests(c.rem.client, entry.Url); rErr != nil {
+				c.log.LogErrf("%s might not be streamable: %v\n", entry.Path, rErr)
+			}
+		}
+		entry.IsDir = file.IsDir
+		published = append(published, entry)
+		if rErr := c.applyRestrictions([]*File{file}); rErr != nil {
+			err = reComposeError(err, rErr.Error())
+		}
+		logPath := entry.Path
+		if entry == root {
+			logPath = label
+		}
+		c.log.Logf("%s published on %s (%s)\n", logPath, entry.Url, publishScope(role, withLink))
+
+		if !c.opts.Recursive || !file.IsDir {
+			continue
+		}
+
+		pagePair := c.rem.FindByParentId(file.Id, c.opts.Hidden)
+		errsChan := pagePair.errsChan
+		childrenChan := pagePair.filesChan
+
+		working := true
+		for working {
+			select {
+			case pageErr := <-errsChan:
+				if pageErr != nil {
+					err = reComposeError(err, fmt.Sprintf("%s: %v", entry.Path, pageErr))
+				}
+			case child, stillHasContent := <-childrenChan:
+				if !stillHasContent {
+					working = false
+					break
+				}
+				if child == nil {
+					continue
+				}
+				files = append(files, child)
+				queue = append(queue, &publishedFile{Path: sepJoin(RemoteSeparator, entry.Path, child.Name)})
+			}
+		}
+	}
+
+	return published, err
 }
 
 func (c *Commands) Unpublish(byId bool) error {
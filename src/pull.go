@@ -15,6 +15,7 @@
 package drive
 
 import (
+	"crypto/md5"
 	"errors"
 	"fmt"
 	"io"
@@ -23,6 +24,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/odeke-em/drive/config"
@@ -56,6 +59,55 @@ type downloadArg struct {
 	path            string
 	exportURL       string
 	ackByteProgress bool
+	// md5Checksum when set is verified against the downloaded
+	// content before an atomic download is moved into place.
+	md5Checksum string
+	// decompress when set gunzips the downloaded content.
+	decompress bool
+	// relToRootPath when set is the path that the local checksum
+	// of the downloaded content is recorded under, if asked for.
+	relToRootPath string
+	// progress when set is also reported the bytes downloaded.
+	progress func(int)
+}
+
+// pathMap records a value, such as why a file was skipped or its checksum,
+// for each of the paths of files that an operation went through. It is safe
+// for concurrent use.
+type pathMap struct {
+	sync.Mutex
+	values map[string]string
+}
+
+func (pm *pathMap) add(relToRootPath, value string) {
+	pm.Lock()
+	defer pm.Unlock()
+
+	if pm.values == nil {
+		pm.values = make(map[string]string)
+	}
+	pm.values[relToRootPath] = value
+}
+
+func (pm *pathMap) reset() {
+	pm.Lock()
+	defer pm.Unlock()
+	pm.values = nil
+}
+
+// sorted returns the recorded paths in order along with a copy
+// of their values, which later additions leave unchanged.
+func (pm *pathMap) sorted() (paths []string, values map[string]string) {
+	pm.Lock()
+	defer pm.Unlock()
+
+	values = make(map[string]string, len(pm.values))
+	for p, value := range pm.values {
+		paths = append(paths, p)
+		values[p] = value
+	}
+	sort.Strings(paths)
+	return paths, values
 }
 
 type renameOp struct {
@@ -91,8 +143,68 @@ func (g *Commands) PullMatchLike() error {
 }
 
 func pull(g *Commands, pt pullType) error {
+	if err := g.opts.mirror(); err != nil {
+		return err
+	}
+	if err := g.opts.noRecursive(); err != nil {
+		return err
+	}
+
+	if g.opts.PullAs != "" && !typeById(pt) {
+		return invalidArgumentsErr(fmt.Errorf("`%s` only renames what is pulled by `-%s`", CLIOptionPullAs, CLIOptionId))
+	}
+
+	release, err := g.lockContext()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := g.loadHashSnapshot(); err != nil {
+		return err
+	}
+	defer g.saveHashSnapshot()
+
 	g.rem.encrypter = g.opts.Encrypter
 	g.rem.decrypter = g.opts.Decrypter
+	g.rem.bandwidth = newBandwidthLimiter(g.opts.BandwidthSchedule, g.log.Logf)
+
+	if err := validateLocalChecksumAlgo(g.opts.LocalChecksumAlgo); err != nil {
+		return err
+	}
+
+	if g.opts.MetadataOnly {
+		if pt != TypeAll {
+			return invalidArgumentsErr(fmt.Errorf("`%s` only indexes paths", CLIOptionMetadataOnly))
+		}
+		return g.pullMetadata()
+	}
+
+	if g.opts.Archive != "" {
+		if pt != TypeAll {
+			return invalidArgumentsErr(fmt.Errorf("`%s` only archives paths", CLIOptionArchive))
+		}
+		return g.pullToArchive()
+	}
+
+	if g.opts.Atomic {
+		if err := g.validateStagingDir(); err != nil {
+			return err
+		}
+	}
+
+	if g.opts.ApplyPlanPath != "" {
+		changes, err := g.appliedPlanChanges(false)
+		if err != nil {
+			return err
+		}
+		nonConflictsPtr, conflictsPtr := g.resolveConflicts(changes, false)
+		if conflictsPtr != nil {
+			warnConflictsPersist(g.log, *conflictsPtr)
+			return unresolvedConflictsErr(fmt.Errorf("conflicts have prevented applying the plan"))
+		}
+		return g.pullChanges(*nonConflictsPtr)
+	}
 
 	cl, clashes, err := pullLikeResolve(g, pt)
 
@@ -114,28 +226,56 @@ func pull(g *Commands, pt pullType) error {
 		return err
 	}
 
+	g.summarizeRenamedTitles()
+	g.summarizeFlattened()
+	g.summarizeMultiParented(cl)
+
 	nonConflictsPtr, conflictsPtr := g.resolveConflicts(cl, false)
 	if conflictsPtr != nil {
 		warnConflictsPersist(g.log, *conflictsPtr)
 		return unresolvedConflictsErr(fmt.Errorf("conflicts have prevented a pull operation"))
 	}
 
-	nonConflicts := *nonConflictsPtr
+	nonConflicts := g.pullableChanges(*nonConflictsPtr)
+	if planned, err := g.planChanges(nonConflicts, false); planned {
+		return err
+	}
+
+	return g.pullChanges(nonConflicts)
+}
 
+// pullChanges lists the changes for approval then makes the approved ones.
+// pullableChanges drops the changes that the options leave out of a pull,
+// such as those of unfollowable shortcuts or of empty folders.
+func (g *Commands) pullableChanges(cl []*Change) []*Change {
+	cl = g.filterBySize(g.skipShortcuts(cl))
+	if g.opts.NoEmptyDirs {
+		cl = g.skipEmptyDirs(cl)
+	}
+	if g.opts.RenameOnCollision {
+		cl = g.renameCollisions(cl)
+	}
+	return cl
+}
+
+func (g *Commands) pullChanges(nonConflicts []*Change) error {
 	clArg := &changeListArg{
 		logy:       g.log,
 		changes:    nonConflicts,
 		noPrompt:   !g.opts.canPrompt(),
 		noClobber:  g.opts.NoClobber,
 		canPreview: g.opts.canPreview(),
+		selectable: g.opts.PromptAll,
+
+		summaryOnly: g.opts.SummaryOnly,
 	}
 
 	status, opMap := printChangeList(clArg)
 	if !accepted(status) {
-		return status.Error()
+		return g.failuresErr(status.Error())
 	}
 
-	return g.playPullChanges(nonConflicts, g.opts.Exports, opMap)
+	return g.playPullChanges(clArg.changes, g.opts.Exports, opMap)
 }
 
 func typeById(pt pullType) bool {
@@ -301,6 +441,7 @@ func (g *Commands) pullLikeMatchesResolver(pt pullType) (cl, clashes []*Change,
 func (g *Commands) PullPiped(byId bool) (err error) {
 	g.rem.encrypter = g.opts.Encrypter
 	g.rem.decrypter = g.opts.Decrypter
+	g.rem.bandwidth = newBandwidthLimiter(g.opts.BandwidthSchedule, g.log.Logf)
 
 	resolver := g.rem.FindByPathM
 	if byId {
@@ -352,6 +493,22 @@ func (g *Commands) pullPipedPerResolver(arg string, resolver func(string) *pagin
 	return nil
 }
 
+// pullAsName returns the local name that a file titled title is pulled
+// into, which is as if set. Only a single name can be mapped to, and to
+// stay in the pulled into directory, it cannot be a path.
+func pullAsName(title, as string, sourceCount int) (string, error) {
+	if as == "" {
+		return title, nil
+	}
+	if sourceCount > 1 {
+		return "", invalidArgumentsErr(fmt.Errorf("`%s` can only name one of the %d ids pulled", CLIOptionPullAs, sourceCount))
+	}
+	if as == "." || as == ".." || strings.ContainsAny(as, "/\\") {
+		return "", invalidArgumentsErr(fmt.Errorf("`%s` %q has to be a name, not a path", CLIOptionPullAs, as))
+	}
+	return as, nil
+}
+
 func (g *Commands) pullById() (cl, clashes []*Change, err error) {
 	for _, srcId := range g.opts.Sources {
 		rem, remErr := g.rem.FindById(srcId)
@@ -364,9 +521,14 @@ func (g *Commands) pullById() (cl, clashes []*Change, err error) {
 			continue
 		}
 
-		relToRootPath := filepath.Join(g.opts.Path, rem.Name)
+		name, nameErr := pullAsName(rem.Name, g.opts.PullAs, len(g.opts.Sources))
+		if nameErr != nil {
+			return cl, clashes, nameErr
+		}
+
+		relToRootPath := filepath.Join(g.opts.Path, name)
 		curAbsPath := g.context.AbsPathOf(relToRootPath)
-		local, resErr := g.resolveToLocalFile(rem.Name, curAbsPath)
+		local, resErr := g.resolveToLocalFile(name, curAbsPath)
 		if resErr != nil {
 			return cl, clashes, resErr
 		}
@@ -393,6 +555,9 @@ func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
 	for _, relToRootPath := range g.opts.Sources {
 		fsPath := g.context.AbsPathOf(relToRootPath)
 		ccl, cclashes, cErr := g.changeListResolve(relToRootPath, fsPath, false)
+		if len(ccl) < 1 && cErr == nil && g.opts.ApplyRemoteDeletes {
+			ccl, cErr = g.remotelyTrashedChanges(relToRootPath)
+		}
 		if len(cclashes) > 0 {
 			clashes = append(clashes, cclashes...)
 		}
@@ -400,6 +565,10 @@ func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
 			cl = append(cl, ccl...)
 		}
 		if cErr != nil && cErr != ErrClashesDetected {
+			if g.continueOnError(relToRootPath, cErr) {
+				g.log.LogErrf("%s: %v\n", relToRootPath, cErr)
+				continue
+			}
 			err = combineErrors(err, cErr)
 		}
 	}
@@ -411,6 +580,52 @@ func (g *Commands) pullByPath() (cl, clashes []*Change, err error) {
 	return cl, clashes, err
 }
 
+// remotelyTrashedChanges returns the deletion of the local copy of relToRootPath
+// if that path was trashed remotely and is unmodified since it was last pulled.
+// Trashed descendants of folders that still exist remotely are already
+// deleted by ordinary pulls since they are no longer listed.
+func (g *Commands) remotelyTrashedChanges(relToRootPath string) (cl []*Change, err error) {
+	fsPath := g.context.AbsPathOf(relToRootPath)
+	local, err := g.resolveToLocalFile(relToRootPath, fsPath)
+	if err != nil || local == nil {
+		return nil, err
+	}
+
+	if rem, rErr := g.rem.FindByPath(relToRootPath); rem != nil || (rErr != nil && rErr != ErrPathNotExists) {
+		return nil, rErr
+	}
+
+	trashed, err := g.rem.FindByPathTrashed(relToRootPath)
+	if err == ErrPathNotExists || trashed == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if trashed.IsDir != local.IsDir {
+		return nil, nil
+	}
+
+	index := g.deserializeIndex(trashed.Id)
+	if index == nil {
+		g.log.LogErrf("%s: trashed remotely but was never pulled, keeping the local copy\n", relToRootPath)
+		return nil, nil
+	}
+
+	if !local.IsDir && index.ModTime != local.ModTime.Unix() {
+		g.log.LogErrf("%s: trashed remotely but modified locally since it was last pulled, keeping the local copy\n", relToRootPath)
+		return nil, nil
+	}
+
+	g.log.Logf("%s: trashed remotely, deleting the local copy\n", relToRootPath)
+
+	// The id ensures that the index of the trashed file is also removed.
+	local.Id = trashed.Id
+	change := &Change{Path: relToRootPath, Dest: local, Parent: path.Dir(relToRootPath), g: g}
+	return []*Change{change}, nil
+}
+
 func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File, piped bool) error {
 	if hasExportLinks(rem) {
 		return googleDocNonExportErr(
@@ -426,7 +641,7 @@ func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File
 		return nil
 	}
 
-	_, err := io.Copy(fh, blobHandle)
+	_, err := io.Copy(fh, g.rem.throttle(blobHandle))
 	blobHandle.Close()
 	if err == nil {
 		return nil
@@ -435,6 +650,12 @@ func (g *Commands) pullAndDownload(relToRootPath string, fh io.Writer, rem *File
 }
 
 func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Operation]sizeCounter) (err error) {
+	var checkpoint *checkpointer
+	checkpoint, cl, opMap = g.resumeFromCheckpoint(PullKey, cl, opMap)
+
+	var queue *pullQueue
+	queue, cl, opMap = g.resumeFromPullQueue(cl, opMap)
+
 	if opMap == nil {
 		result := opChangeCount(cl)
 		opMap = &result
@@ -447,6 +668,13 @@ func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Op
 		totalSize += counter.sizeByOperation(op)
 	}
 
+	progressJSON, err := g.startProgressJSON(totalSize)
+	if err != nil {
+		return err
+	}
+	defer progressJSON.finish()
+
+	g.summary.begin()
 	g.taskStart(totalSize)
 
 	defer close(g.rem.progressChan)
@@ -501,11 +729,35 @@ func (g *Commands) playPullChanges(cl []*Change, exports []string, opMap *map[Op
 		if rErr != nil {
 			msg := fmt.Sprintf("%v err: %v\n", res, rErr)
 			err = reComposeError(err, msg)
+			g.continueOnError(fmt.Sprintf("%v", res), rErr)
+		} else {
+			checkpoint.done(res)
 		}
+		queue.record(res, rErr)
 	}
 
+	checkpoint.finish(err)
+	queue.finish(err)
 	g.taskFinish()
-	return err
+	g.summarizeSkippedNatives()
+	g.recordLocalChecksums()
+	g.reportSummary()
+	return g.failuresErr(err)
+}
+
+// summarizeSkippedNatives reports the Google-native files that were
+// skipped since they had no content to download and weren't exported.
+func (g *Commands) summarizeSkippedNatives() {
+	paths, reasons := g.skippedNatives.sorted()
+	if len(paths) < 1 {
+		return
+	}
+
+	g.log.LogErrf("\n%d Google-native file(s) were skipped:\n", len(paths))
+	for _, p := range paths {
+		g.log.LogErrf("\t%s: %s\n", p, reasons[p])
+	}
+	g.log.LogErrf("To get their content, export them with `-%s` e.g `-%s pdf,docx,xlsx`\n", ExportsKey, ExportsKey)
 }
 
 func (g *Commands) localAddIndex(change *Change, conform []string) (err error) {
@@ -535,6 +787,7 @@ func (g *Commands) localMod(change *Change, exports []string) (err error) {
 	}()
 
 	destAbsPath := g.context.AbsPathOf(change.Path)
+	exports = g.exportFormats(change.Src, exports)
 
 	downloadPerformed := false
 
@@ -554,6 +807,9 @@ func (g *Commands) localMod(change *Change, exports []string) (err error) {
 	}
 
 	err = os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime)
+	if err == nil {
+		err = g.restoreFileMode(change.Src, destAbsPath)
+	}
 
 	// Update progress for the case in which you are only Chtime-ing
 	// since progress for downloaded files is already handled separately
@@ -598,12 +854,15 @@ func (g *Commands) localAdd(change *Change, exports []string) (err error) {
 			return dErr
 		}
 	} else {
-		if cErr := os.Mkdir(destAbsPath, os.ModeDir|0755); !os.IsExist(cErr) {
+		if cErr := os.Mkdir(destAbsPath, os.ModeDir|0755); cErr != nil && !os.IsExist(cErr) {
 			return cErr
 		}
 	}
 
-	return os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime)
+	if err := os.Chtimes(destAbsPath, change.Src.ModTime, change.Src.ModTime); err != nil {
+		return err
+	}
+	return g.restoreFileMode(change.Src, destAbsPath)
 }
 
 func (g *Commands) localDelete(change *Change, conform []string) (err error) {
@@ -643,6 +902,21 @@ func touchFile(path string) (err error) {
 	return
 }
 
+// exportFormats returns the formats that f is to be exported as, which
+// with ExportAll are all of those that its export links are available in.
+func (g *Commands) exportFormats(f *File, exports []string) []string {
+	if !g.opts.ExportAll || !hasExportLinks(f) {
+		return exports
+	}
+	return nativeExportFormats(f)
+}
+
+// exportExtension returns the extension that the exports in format are
+// saved with, which is lowercase whatever the case that it was asked in.
+func exportExtension(format string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), "."))
+}
+
 func (g *Commands) makeExportsDir(segments ...string) string {
 	if !g.opts.ExportsDumpToSameDirectory {
 		segments = append(segments, "exports")
@@ -668,10 +942,16 @@ func (g *Commands) export(f *File, destAbsPath string, exports []string) (manife
 	var mimeType, exportURL string
 
 	waitables := []*urlMimeTypeExt{}
+	seen := make(map[string]bool)
+
+	for _, format := range exports {
+		ext := exportExtension(format)
+		if seen[ext] {
+			continue
+		}
+		seen[ext] = true
 
-	for _, ext := range exports {
-		mimeType = mimeTypeFromExt(ext)
-		exportURL, ok = f.ExportLinks[mimeType]
+		mimeType, exportURL, ok = exportLink(f.ExportLinks, ext)
 		if !ok {
 			continue
 		}
@@ -684,6 +964,10 @@ func (g *Commands) export(f *File, destAbsPath string, exports []string) (manife
 	}
 
 	n := len(waitables)
+	if g.opts.ExportsStripExtension && n > 1 {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: cannot strip the extensions of %d exports as they would overwrite each other", f.Name, n))
+	}
+
 	errsChan := make(chan error, n)
 
 	basePath := filepath.Base(f.Name)
@@ -698,6 +982,9 @@ func (g *Commands) export(f *File, destAbsPath string, exports []string) (manife
 			}()
 
 			exportPath := sepJoin(".", baseDirPath, urlMExt.ext)
+			if g.opts.ExportsStripExtension {
+				exportPath = baseDirPath
+			}
 
 			// TODO: Decide if users should get to make *.desktop users even for exports
 			if runtime.GOOS == OSLinuxKey && false {
@@ -743,22 +1030,183 @@ func (g *Commands) shouldCreateURLLinkedFiles() bool {
 	return g.opts.AllowURLLinkedFiles && runtime.GOOS == OSLinuxKey
 }
 
+// emptyDirAdds returns the paths of the new folders that no file is to
+// be added under, which are the remote folders that would be left empty.
+func emptyDirAdds(cl []*Change) map[string]bool {
+	empty := make(map[string]bool)
+	for _, c := range cl {
+		if c != nil && c.Src != nil && c.Src.IsDir && c.Dest == nil && c.Op() == OpAdd {
+			empty[c.Path] = true
+		}
+	}
+
+	for _, c := range cl {
+		if c == nil || c.Src == nil || c.Src.IsDir || c.Op() != OpAdd {
+			continue
+		}
+		for dir := c.Path; ; {
+			parent := path.Dir(dir)
+			if parent == dir {
+				break
+			}
+			delete(empty, parent)
+			dir = parent
+		}
+	}
+
+	return empty
+}
+
+// skipEmptyDirs drops the additions of the remote folders that have
+// no files to be pulled into them, leaving them out of the local tree.
+func (g *Commands) skipEmptyDirs(cl []*Change) (kept []*Change) {
+	empty := emptyDirAdds(cl)
+	for _, c := range cl {
+		if c != nil && empty[c.Path] {
+			g.log.Logf("%s: skipping empty folder\n", c.Path)
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	return kept
+}
+
+// skipShortcuts drops the additions and modifications of shortcuts that
+// cannot be followed since otherwise they'd be pulled as useless stubs.
+func (g *Commands) skipShortcuts(cl []*Change) (kept []*Change) {
+	for _, c := range cl {
+		if c == nil || !c.Src.isShortcut() {
+			kept = append(kept, c)
+			continue
+		}
+
+		if op := c.Op(); op != OpAdd && op != OpMod {
+			kept = append(kept, c)
+			continue
+		}
+
+		if !g.opts.FollowShortcuts {
+			g.log.Logf("%s: skipping shortcut, use -%s to pull the file it points to\n", c.Path, CLIOptionFollowShortcuts)
+			continue
+		}
+
+		if c.Src.ShortcutTargetMimeType == DriveFolderMimeType {
+			g.log.Logf("%s: skipping shortcut, following shortcuts to folders is not supported\n", c.Path)
+			continue
+		}
+
+		kept = append(kept, c)
+	}
+
+	return kept
+}
+
+// renameCollisions makes the incoming files that would overwrite local files
+// which aren't tracked as their remote counterparts, get pulled in under
+// a new name of the form `name (n).ext` instead.
+func (g *Commands) renameCollisions(cl []*Change) []*Change {
+	reserved := make(map[string]bool)
+	for _, c := range cl {
+		if c != nil {
+			reserved[c.Path] = true
+		}
+	}
+
+	for _, c := range cl {
+		if c == nil || c.Src == nil || c.Dest == nil || c.Src.IsDir || c.Dest.IsDir {
+			continue
+		}
+
+		if op := c.Op(); op != OpMod && op != OpModConflict {
+			continue
+		}
+
+		// Files are tracked if their local modTime still matches
+		// that recorded in the index since they were last pulled.
+		index := g.deserializeIndex(c.Src.Id)
+		if index != nil && c.Dest.ModTime.Unix() == index.ModTime {
+			continue
+		}
+
+		renamedPath := g.nonCollidingPath(c.Path, reserved)
+		reserved[renamedPath] = true
+
+		g.log.Logf("%s: an untracked local file exists, pulling it in as %s\n", c.Path, renamedPath)
+
+		c.Path = renamedPath
+		c.Dest = nil
+	}
+
+	return cl
+}
+
+func (g *Commands) nonCollidingPath(relToRootPath string, reserved map[string]bool) string {
+	dir, base := path.Dir(relToRootPath), path.Base(relToRootPath)
+	ext := path.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	for i := 1; ; i++ {
+		candidate := path.Join(dir, fmt.Sprintf("%s (%d)%s", stem, i, ext))
+		if reserved[candidate] {
+			continue
+		}
+		if _, err := os.Lstat(g.context.AbsPathOf(candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// downloadShortcutTarget downloads the content of the file
+// that the shortcut being pulled points to.
+func (g *Commands) downloadShortcutTarget(change *Change, exports []string) error {
+	target, err := g.rem.FindById(change.Src.ShortcutTargetId)
+	if err != nil {
+		return fmt.Errorf("shortcut %s: resolving target %q: %v", change.Path, change.Src.ShortcutTargetId, err)
+	}
+
+	if target.isShortcut() {
+		return illogicalStateErr(fmt.Errorf("shortcut %s: points to another shortcut", change.Path))
+	}
+
+	targetChange := *change
+	targetChange.Src = target
+	return g.download(&targetChange, exports)
+}
+
 func (g *Commands) download(change *Change, exports []string) error {
 	if change.Src == nil {
 		return illogicalStateErr(fmt.Errorf("tried to download nil change.Src"))
 	}
 
+	if change.Src.isShortcut() {
+		return g.downloadShortcutTarget(change, exports)
+	}
+
+	exports = g.exportFormats(change.Src, exports)
 	destAbsPath := g.context.AbsPathOf(change.Path)
 	if change.Src.BlobAt != "" {
 		dlArg := downloadArg{
 			path:            destAbsPath,
 			id:              change.Src.Id,
 			ackByteProgress: true,
+			decompress:      g.opts.Decompress && compressedOnRemote(change.Src),
+			relToRootPath:   change.Path,
+			progress:        g.progressJSON.fileProgressFn(change.Path),
+		}
+
+		// Decrypted content cannot match the checksum of its encrypted remote.
+		if !g.opts.IgnoreChecksum && g.opts.Decrypter == nil {
+			dlArg.md5Checksum = change.Src.Md5Checksum
 		}
 
 		return g.singleDownload(&dlArg)
 	}
 
+	if g.opts.LinkShortcuts && googleNative(change.Src) {
+		return g.writeLinkShortcut(change)
+	}
+
 	// We need to touch the empty file to
 	// ensure consistency during a push.
 	if err := touchFile(destAbsPath); err != nil {
@@ -785,6 +1233,9 @@ func (g *Commands) download(change *Change, exports []string) error {
 
 	canExport := len(exports) >= 1 && hasExportLinks(change.Src)
 	if !canExport {
+		reason := nativeSkipReason(change.Src, exports)
+		g.log.LogErrf("%s: skipping, %s\n", change.Path, reason)
+		g.skippedNatives.add(change.Path, reason)
 		return nil
 	}
 
@@ -801,16 +1252,71 @@ func (g *Commands) download(change *Change, exports []string) error {
 
 	if exportErr == nil {
 		for _, exportPath := range manifest {
-			g.log.Logf("Exported '%s' to '%s'\n", destAbsPath, exportPath)
+			if !g.opts.SummaryOnly {
+				g.log.Logf("Exported '%s' to '%s'\n", destAbsPath, exportPath)
+			}
+		}
+
+		if len(manifest) < 1 {
+			reason := nativeSkipReason(change.Src, exports)
+			g.log.LogErrf("%s: skipping, %s\n", change.Path, reason)
+			g.skippedNatives.add(change.Path, reason)
 		}
 	}
 
 	return exportErr
 }
 
-func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
+// singleDownload downloads the content, which is downloaded again if it
+// doesn't match its checksum, for as many times as asked for and as
+// the retries allowed per file permit.
+func (g *Commands) singleDownload(dlArg *downloadArg) error {
+	mismatched, err := g.downloadAttempt(dlArg)
+
+	budget := newRetryBudget(g.opts.RetriesPerFile)
+	retries := 0
+	for ; mismatched && retries < g.opts.ChecksumMismatchRetries && budget.take(); retries++ {
+		g.log.LogErrf("%v, downloading it again (%d/%d)\n", err, retries+1, g.opts.ChecksumMismatchRetries)
+
+		// The bytes of the first attempt were already counted towards the progress
+		retryArg := *dlArg
+		retryArg.ackByteProgress = false
+		mismatched, err = g.downloadAttempt(&retryArg)
+	}
+
+	if retries >= 1 && err == nil {
+		g.log.Logf("%s: matched its checksum after %d re-download(s)\n", dlArg.path, retries)
+	}
+	return err
+}
+
+// downloadAttempt downloads the content once, mismatched is set if
+// the downloaded content didn't match the checksum in dlArg.
+func (g *Commands) downloadAttempt(dlArg *downloadArg) (mismatched bool, err error) {
+	// Atomic downloads are written to the staging directory and only moved
+	// into place once the content has been fully received and verified so
+	// that partial content is never observed at dlArg.path.
+	atomic := g.opts.Atomic
+	hasher := md5.New()
+	writers := []io.Writer{hasher}
+
+	if localHasher := g.localChecksumHasher(); localHasher != nil && dlArg.relToRootPath != "" {
+		writers = append(writers, localHasher)
+		// Deferred first to only record the checksum
+		// once the download is fully in place.
+		defer func() {
+			if err == nil {
+				g.localChecksums.add(dlArg.relToRootPath, fmt.Sprintf("%x", localHasher.Sum(nil)))
+			}
+		}()
+	}
+
 	var fo *os.File
-	fo, err = os.Create(dlArg.path)
+	if atomic {
+		fo, err = g.createStagingFile(dlArg.path)
+	} else {
+		fo, err = os.Create(dlArg.path)
+	}
 	if err != nil {
 		g.log.LogErrf("create: %s %v\n", dlArg.path, err)
 		return
@@ -823,8 +1329,36 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 			g.log.LogErrf("fErr", fErr)
 			err = fErr
 		}
+
+		// Content downloaded in place is only verified if a
+		// mismatch is to be fixed by downloading it again.
+		verify := atomic || g.opts.ChecksumMismatchRetries > 0
+		if err == nil && verify && dlArg.md5Checksum != "" {
+			if gotMd5 := fmt.Sprintf("%x", hasher.Sum(nil)); gotMd5 != dlArg.md5Checksum {
+				mismatched = true
+				err = downloadFailedErr(fmt.Errorf("%s: md5 checksum mismatch, got %s expected %s", dlArg.path, gotMd5, dlArg.md5Checksum))
+			}
+		}
+
+		if !atomic {
+			return
+		}
+
+		if err == nil {
+			err = moveFile(fo.Name(), dlArg.path)
+		}
+
+		if err != nil {
+			os.Remove(fo.Name())
+		}
 	}()
 
+	if atomic {
+		if err = fo.Chmod(downloadFileMode(dlArg.path)); err != nil {
+			return false, err
+		}
+	}
+
 	var blob io.ReadCloser
 	defer func() {
 		if blob != nil {
@@ -834,7 +1368,13 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 
 	blob, err = g.rem.Download(dlArg.id, dlArg.exportURL)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	if dlArg.decompress {
+		if blob, err = gunzipReader(blob); err != nil {
+			return false, err
+		}
 	}
 
 	ws := statos.NewWriter(fo)
@@ -844,6 +1384,9 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 		if dlArg.ackByteProgress {
 			for n := range commChan {
 				g.rem.progressChan <- n
+				if dlArg.progress != nil {
+					dlArg.progress(n)
+				}
 			}
 		} else { // Just drain the progress channel
 			for _ = range commChan {
@@ -852,7 +1395,7 @@ func (g *Commands) singleDownload(dlArg *downloadArg) (err error) {
 		}
 	}()
 
-	_, err = io.Copy(ws, blob)
+	_, err = io.Copy(io.MultiWriter(append(writers, ws)...), g.rem.throttle(blob))
 
 	return
 }
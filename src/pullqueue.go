@@ -0,0 +1,160 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"sync"
+
+	"github.com/odeke-em/drive/config"
+)
+
+const (
+	QueuePending = "pending"
+	QueueDone    = "done"
+	QueueFailed  = "failed"
+)
+
+// pullQueue persists every change of a pull up front and records each as it
+// completes, so that a pull killed at any point can be resumed with what
+// was still pending.
+type pullQueue struct {
+	sync.Mutex
+
+	g       *Commands
+	items   map[string]*config.QueueItem
+	changes map[string]*Change
+}
+
+// resumeFromPullQueue sets up the pull queue if enabled. If resuming and a
+// queue was persisted, only its changes that weren't done are kept,
+// otherwise the queue is populated afresh with all the changes.
+func (g *Commands) resumeFromPullQueue(cl []*Change, opMap *map[Operation]sizeCounter) (*pullQueue, []*Change, *map[Operation]sizeCounter) {
+	if !g.opts.PullQueue {
+		return nil, cl, opMap
+	}
+
+	pq := &pullQueue{
+		g:       g,
+		items:   make(map[string]*config.QueueItem),
+		changes: make(map[string]*Change),
+	}
+
+	for _, c := range cl {
+		if c != nil {
+			pq.changes[c.Path] = c
+		}
+	}
+
+	if g.opts.Resume {
+		prevItems, err := g.context.ReadPullQueue()
+		if err != nil && !os.IsNotExist(err) {
+			g.log.LogErrf("pull queue: reading %v\n", err)
+		}
+
+		for _, item := range prevItems {
+			pq.items[item.Path] = item
+		}
+	}
+
+	if len(pq.items) >= 1 {
+		var remaining []*Change
+		for _, c := range cl {
+			if c == nil {
+				continue
+			}
+			if item, ok := pq.items[c.Path]; ok && item.State != QueueDone {
+				remaining = append(remaining, c)
+			}
+		}
+
+		g.log.Logf("pull queue: resuming %d of %d queued changes\n", len(remaining), len(pq.items))
+		result := opChangeCount(remaining)
+		return pq, remaining, &result
+	}
+
+	items := make([]*config.QueueItem, 0, len(cl))
+	for _, c := range cl {
+		if c == nil {
+			continue
+		}
+
+		item := &config.QueueItem{Path: c.Path, State: QueuePending}
+		if c.Src != nil {
+			item.Id = c.Src.Id
+			item.Md5Checksum = c.Src.Md5Checksum
+			item.Size = c.Src.Size
+		}
+		pq.items[c.Path] = item
+		items = append(items, item)
+	}
+
+	if err := g.context.WritePullQueue(items); err != nil {
+		g.log.LogErrf("pull queue: writing %v\n", err)
+	}
+
+	return pq, cl, opMap
+}
+
+// verifies tells whether the content downloaded by change gets
+// its checksum verified before being moved into place.
+func (pq *pullQueue) verifies(c *Change) bool {
+	opts := pq.g.opts
+	return opts.Atomic && !opts.IgnoreChecksum && opts.Decrypter == nil &&
+		c.Src != nil && c.Src.Md5Checksum != ""
+}
+
+func (pq *pullQueue) record(v interface{}, err error) {
+	p, ok := v.(string)
+	if pq == nil || !ok {
+		return
+	}
+
+	pq.Lock()
+	defer pq.Unlock()
+
+	item, ok := pq.items[p]
+	if !ok {
+		return
+	}
+
+	update := *item
+	if err != nil {
+		update.State = QueueFailed
+		update.Error = err.Error()
+	} else {
+		update.State = QueueDone
+		update.Error = ""
+		c := pq.changes[p]
+		update.Verified = c != nil && pq.verifies(c)
+	}
+	pq.items[p] = &update
+
+	if aErr := pq.g.context.AppendPullQueue(&update); aErr != nil {
+		pq.g.log.LogErrf("pull queue: recording %q %v\n", p, aErr)
+	}
+}
+
+// finish removes the queue once every change was pulled, otherwise
+// it is kept so that the failed and pending changes can be resumed.
+func (pq *pullQueue) finish(opErr error) {
+	if pq == nil || opErr != nil {
+		return
+	}
+
+	if err := pq.g.context.RemovePullQueue(); err != nil {
+		pq.g.log.LogErrf("pull queue: removing %v\n", err)
+	}
+}
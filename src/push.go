@@ -26,17 +26,53 @@ import (
 	"time"
 
 	"github.com/odeke-em/drive/config"
+	expb "github.com/odeke-em/exponential-backoff"
 	"github.com/odeke-em/semalim"
 )
 
 var mkdirAllMu = sync.Mutex{}
 
+// stripPathPrefix removes the leading folders of prefix from p, which has to
+// be within them. Both are relative to the root of the context.
+func stripPathPrefix(p, prefix string) (string, error) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	switch {
+	case prefix == "":
+		return p, nil
+	case p == prefix:
+		return "/", nil
+	case strings.HasPrefix(p, prefix+"/"):
+		return p[len(prefix):], nil
+	default:
+		return "", invalidArgumentsErr(fmt.Errorf("%s is not within the prefix %s to strip", p, prefix))
+	}
+}
+
 // Pushes to remote if local path exists and in a gd context. If path is a
 // directory, it recursively pushes to the remote if there are local changes.
 // It doesn't check if there are local changes if isForce is set.
 func (g *Commands) Push() error {
+	if err := g.opts.mirror(); err != nil {
+		return err
+	}
+	if err := g.opts.noRecursive(); err != nil {
+		return err
+	}
+
+	release, err := g.lockContext()
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if err := g.loadHashSnapshot(); err != nil {
+		return err
+	}
+	defer g.saveHashSnapshot()
+
 	g.rem.encrypter = g.opts.Encrypter
 	g.rem.decrypter = g.opts.Decrypter
+	g.rem.bandwidth = newBandwidthLimiter(g.opts.BandwidthSchedule, g.log.Logf)
 
 	defer g.clearMountPoints()
 
@@ -59,6 +95,25 @@ func (g *Commands) Push() error {
 
 	clashes := []*Change{}
 
+	if err := g.rootAtParentId(); err != nil {
+		spin.stop()
+		return err
+	}
+
+	if g.opts.ApplyPlanPath != "" {
+		spin.stop()
+		changes, err := g.appliedPlanChanges(true)
+		if err != nil {
+			return err
+		}
+		nonConflictsPtr, conflictsPtr := g.resolveConflicts(changes, true)
+		if conflictsPtr != nil {
+			warnConflictsPersist(g.log, *conflictsPtr)
+			return unresolvedConflictsErr(fmt.Errorf("conflicts have prevented applying the plan"))
+		}
+		return g.pushChanges(changes, *nonConflictsPtr)
+	}
+
 	rootAbsPath := g.context.AbsPathOf("")
 	destAbsPath := g.context.AbsPathOf(g.opts.Destination)
 	remoteDestRelPath, err := filepath.Rel(rootAbsPath, destAbsPath)
@@ -67,14 +122,25 @@ func (g *Commands) Push() error {
 	}
 	for _, relToRootPath := range g.opts.Sources {
 		fsAbsPath := g.context.AbsPathOf(relToRootPath)
+		strippedPath, sErr := stripPathPrefix(relToRootPath, g.opts.StripPrefix)
+		if sErr != nil {
+			spin.stop()
+			return sErr
+		}
 		// Join this relative path to that of the remote relative path of the destination.
-		relToDestPath := remotePathJoin(remoteDestRelPath, relToRootPath)
+		relToDestPath := remotePathJoin(remoteDestRelPath, strippedPath)
+		if g.opts.ParentId != "" {
+			relToDestPath = remotePathJoin(filepath.Base(relToRootPath))
+		}
 		ccl, cclashes, cErr := g.changeListResolve(relToDestPath, fsAbsPath, true)
 
 		clashes = append(clashes, cclashes...)
 		if cErr != nil && cErr != ErrClashesDetected {
-			spin.stop()
-			return cErr
+			if !g.continueOnError(relToRootPath, cErr) {
+				spin.stop()
+				return cErr
+			}
+			g.log.LogErrf("%s: %v\n", relToRootPath, cErr)
 		}
 		if len(ccl) > 0 {
 			cl = append(cl, ccl...)
@@ -114,8 +180,17 @@ func (g *Commands) Push() error {
 		return unresolvedConflictsErr(fmt.Errorf("conflicts have prevented a push operation"))
 	}
 
-	nonConflicts := *nonConflictsPtr
+	nonConflicts := g.filterBySize(*nonConflictsPtr)
+	if planned, err := g.planChanges(nonConflicts, true); planned {
+		return err
+	}
+
+	return g.pushChanges(cl, nonConflicts)
+}
 
+// pushChanges lists the changes for approval then makes the
+// approved ones, warning if the changes in cl exceed the quota.
+func (g *Commands) pushChanges(cl, nonConflicts []*Change) error {
 	pushSize, modSize := reduceToSize(cl, SelectDest|SelectSrc)
 
 	// Compensate for deletions and modifications
@@ -154,23 +229,35 @@ func (g *Commands) Push() error {
 		noPrompt:   !g.opts.canPrompt(),
 		noClobber:  g.opts.NoClobber,
 		canPreview: g.opts.canPreview(),
+		selectable: g.opts.PromptAll,
+
+		summaryOnly: g.opts.SummaryOnly,
 	}
 
 	status, opMap := printChangeList(&clArg)
 	if !accepted(status) {
-		return status.Error()
+		return g.failuresErr(status.Error())
 	}
 
-	return g.playPushChanges(nonConflicts, opMap)
+	return g.playPushChanges(clArg.changes, opMap)
 }
 
 func (g *Commands) PushPiped() error {
 	g.rem.encrypter = g.opts.Encrypter
 	g.rem.decrypter = g.opts.Decrypter
+	g.rem.bandwidth = newBandwidthLimiter(g.opts.BandwidthSchedule, g.log.Logf)
+
+	if err := g.rootAtParentId(); err != nil {
+		return err
+	}
 
 	// Cannot push asynchronously because the push order must be maintained
 	for _, relToRootPath := range g.opts.Sources {
-		rem, resErr := g.rem.FindByPath(relToRootPath)
+		if g.opts.ParentId != "" {
+			relToRootPath = remotePathJoin(filepath.Base(relToRootPath))
+		}
+
+		rem, resErr := g.rem.FindByPath(g.remotePathOf(relToRootPath))
 		if resErr != nil && resErr != ErrPathNotExists {
 			return resErr
 		}
@@ -189,7 +276,7 @@ func (g *Commands) PushPiped() error {
 		}
 
 		parentPath := g.parentPather(relToRootPath)
-		parent, pErr := g.rem.FindByPath(parentPath)
+		parent, pErr := g.rem.FindByPath(g.remotePathOf(parentPath))
 		if pErr != nil {
 			spin := g.playabler()
 			spin.play()
@@ -199,6 +286,8 @@ func (g *Commands) PushPiped() error {
 				g.log.LogErrf("%s: %v\n", relToRootPath, pErr)
 				return pErr
 			}
+		} else if !parent.IsDir {
+			return notAFolderErr(parentPath, parent)
 		}
 
 		fauxSrc := DupFile(rem)
@@ -216,6 +305,9 @@ func (g *Commands) PushPiped() error {
 			nonStatable:     true,
 			ignoreChecksum:  g.opts.IgnoreChecksum,
 			retryCount:      g.opts.ExponentialBackoffRetryCount,
+			retries:         newRetryBudget(g.opts.RetriesPerFile),
+			createdTime:     g.opts.CreatedTime,
+			indexableText:   g.opts.IndexableText,
 		}
 
 		rem, _, rErr := g.rem.upsertByComparison(os.Stdin, args)
@@ -240,6 +332,30 @@ func (g *Commands) PushPiped() error {
 	return nil
 }
 
+// rootAtParentId makes remote paths resolve relative to the folder
+// with id opts.ParentId so that sources are pushed directly into it.
+func (g *Commands) rootAtParentId() error {
+	if g.opts.ParentId == "" {
+		return nil
+	}
+
+	if g.opts.Destination != "" {
+		return invalidArgumentsErr(fmt.Errorf("cannot use both `%s` and `%s`", CLIOptionParentId, CLIOptionPushDestination))
+	}
+
+	parent, err := g.rem.FindById(g.opts.ParentId)
+	if err != nil {
+		return remoteLookupErr(fmt.Errorf("parent %q: %v", g.opts.ParentId, err))
+	}
+
+	if !parent.IsDir {
+		return invalidArgumentsErr(fmt.Errorf("parent %q (%s) is not a folder", g.opts.ParentId, parent.Name))
+	}
+
+	g.rem.rootFolderId = parent.Id
+	return nil
+}
+
 func (g *Commands) deserializeIndex(identifier string) *config.Index {
 	index, err := g.context.DeserializeIndex(identifier)
 	if err != nil {
@@ -249,6 +365,9 @@ func (g *Commands) deserializeIndex(identifier string) *config.Index {
 }
 
 func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounter) (err error) {
+	var checkpoint *checkpointer
+	checkpoint, cl, opMap = g.resumeFromCheckpoint(PushKey, cl, opMap)
+
 	if opMap == nil {
 		result := opChangeCount(cl)
 		opMap = &result
@@ -260,6 +379,13 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 		totalSize += counter.sizeByOperation(op)
 	}
 
+	progressJSON, err := g.startProgressJSON(totalSize)
+	if err != nil {
+		return err
+	}
+	defer progressJSON.finish()
+
+	g.summary.begin()
 	g.taskStart(totalSize)
 
 	defer close(g.rem.progressChan)
@@ -279,6 +405,11 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 
 	sort.Sort(ByPrecedence(cl))
 
+	var budget *inflightBudget
+	if g.opts.MaxInflightBytes > 0 {
+		budget = newInflightBudget(g.opts.MaxInflightBytes)
+	}
+
 	jobsChan := make(chan semalim.Job)
 
 	go func() {
@@ -291,6 +422,11 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 				continue
 			}
 
+			// The changes in flight finish but no more are started
+			if g.uploadLimitReached() {
+				break
+			}
+
 			fn := remoteOpToChangerTranslator(g, c)
 
 			if fn == nil {
@@ -298,6 +434,12 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 				continue
 			}
 
+			fn = g.noteUploadLimit(fn)
+
+			if budget != nil {
+				fn = budget.bounded(fn)
+			}
+
 			cjs := changeJobSt{
 				change:   c,
 				fn:       fn,
@@ -310,16 +452,78 @@ func (g *Commands) playPushChanges(cl []*Change, opMap *map[Operation]sizeCounte
 		}
 	}()
 
+	applied := 0
 	results := semalim.Run(jobsChan, uint64(n))
 	for result := range results {
 		res, resErr := result.Value(), result.Err()
 		if resErr != nil {
 			err = reComposeError(err, fmt.Sprintf("push: %s err: %v\n", res, resErr))
+			g.continueOnError(fmt.Sprintf("%v", res), resErr)
+		} else {
+			applied += 1
+			checkpoint.done(res)
 		}
 	}
 
+	if g.uploadLimitReached() {
+		// Saved even without -checkpoint for -resume to skip what was uploaded
+		checkpoint.save()
+		err = reComposeError(g.uploadLimitErr(len(cl)-applied), fmt.Sprintf("%v", err))
+	}
+
+	checkpoint.finish(err)
 	g.taskFinish()
-	return err
+	g.reportSummary()
+	return g.failuresErr(err)
+}
+
+// inflightBudget bounds the sum of the sizes of the
+// files that are being concurrently uploaded.
+type inflightBudget struct {
+	sync.Mutex
+	cond     *sync.Cond
+	cap      int64
+	inflight int64
+}
+
+func newInflightBudget(cap int64) *inflightBudget {
+	ib := &inflightBudget{cap: cap}
+	ib.cond = sync.NewCond(ib)
+	return ib
+}
+
+func (ib *inflightBudget) acquire(n int64) {
+	ib.Lock()
+	defer ib.Unlock()
+
+	// A file larger than the cap can only be uploaded
+	// once nothing else is in flight, otherwise it would
+	// wait forever.
+	for ib.inflight > 0 && ib.inflight+n > ib.cap {
+		ib.cond.Wait()
+	}
+	ib.inflight += n
+}
+
+func (ib *inflightBudget) release(n int64) {
+	ib.Lock()
+	ib.inflight -= n
+	ib.Unlock()
+	ib.cond.Broadcast()
+}
+
+func (ib *inflightBudget) bounded(fn func(*Change) error) func(*Change) error {
+	return func(c *Change) error {
+		if c.Src == nil || c.Src.IsDir || c.Op() == OpDelete {
+			return fn(c)
+		}
+
+		size := c.Src.Size
+		ib.acquire(size)
+		defer ib.release(size)
+
+		return fn(c)
+	}
 }
 
 func (g *Commands) pathSplitter(absPath string) (dir, base string) {
@@ -383,15 +587,64 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 		ignoreChecksum:  g.opts.IgnoreChecksum,
 		debug:           g.opts.Verbose && g.opts.canPreview(),
 		retryCount:      g.opts.ExponentialBackoffRetryCount,
+		retries:         newRetryBudget(g.opts.RetriesPerFile),
+		properties:      g.opts.Properties,
+		createdTime:     g.opts.CreatedTime,
+		indexableText:   g.opts.IndexableText,
+		progress:        g.progressJSON.fileProgressFn(change.Path),
+	}
+
+	if title, ok := g.originalTitle(change.Path); ok {
+		args.title = title
+	} else if remotePath := g.remotePathOf(change.Path); remotePath != change.Path {
+		// Flattened folders keep the title of the last folder of their chain
+		args.title = gopath.Base(remotePath)
+	}
+
+	if g.opts.PreserveMode && !change.Src.IsDir {
+		if args.properties, err = fileModeProperties(absPath, args.properties); err != nil {
+			return err
+		}
+	}
+
+	asCopy := g.shouldUploadAsCopy(change)
+	if asCopy {
+		// Insert a new file rather than updating the shared one
+		src := *change.Src
+		src.Id = ""
+		args.src = &src
+		args.dest = nil
+
+		title := args.title
+		if title == "" {
+			title = urlToPath(src.Name, false)
+		}
+		args.title = copyTitle(title, time.Now())
 	}
 
 	coercedMimeKey, ok := g.coercedMimeKey()
 	if ok {
 		args.mimeKey = coercedMimeKey
-	} else if args.src != nil && !args.src.IsDir { // Infer it from the extension
-		args.mimeKey = filepath.Ext(args.src.Name)
+	} else if args.src != nil && !args.src.IsDir {
+		if mimeType, overridden := g.mimeOverride(args.src.Name); overridden {
+			args.mimeOverride = mimeType
+		} else { // Infer it from the extension
+			args.mimeKey = filepath.Ext(args.src.Name)
+
+			// Fallback to the content if the extension is absent or too generic
+			if mimeType := guessMimeType(args.mimeKey); mimeType == "" || mimeType == OctetStreamMimeType {
+				args.sniffedMimeType = sniffMimeType(absPath)
+			}
+		}
 	}
 
+	if g.opts.Compress && args.src != nil && !args.src.IsDir {
+		args.compress = compressible(args.src.Name)
+	}
+
+	// Only updates of the content make a revision to pin
+	pinRevision := g.opts.PinRevision && !asCopy && args.dest != nil && args.shouldUploadBody()
+
 	rem, err := g.rem.UpsertByComparison(args)
 	if err != nil {
 		g.log.LogErrf("%s: %v\n", change.Path, err)
@@ -400,6 +653,13 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 	if rem == nil {
 		return
 	}
+
+	if asCopy {
+		// The local file still corresponds to the shared remote file so it isn't indexed against the copy
+		g.log.Logf("%s: the remote file is shared so it was left as is, uploaded a copy %q %s\n", change.Path, rem.Name, rem.AlternateLink)
+		return
+	}
+
 	index := rem.ToIndex()
 	wErr := g.context.SerializeIndex(index)
 
@@ -407,9 +667,48 @@ func (g *Commands) remoteMod(change *Change) (err error) {
 	if wErr != nil {
 		g.log.LogErrf("serializeIndex %s: %v\n", rem.Name, wErr)
 	}
+
+	if pinRevision {
+		err = g.pinRevision(change.Path, rem.Id, args.debug, args.retries)
+	}
 	return
 }
 
+// pinRevision pins the head revision of the file that was just updated
+// at relToRootPath, failing the change if it couldn't be pinned since
+// the revision would otherwise be purged without notice.
+func (g *Commands) pinRevision(relToRootPath, fileId string, debug bool, retries *retryBudget) error {
+	retrier := retries.bound(retryableChangeOp(func() (interface{}, error) {
+		return g.rem.pinHeadRevision(fileId)
+	}, debug, g.opts.ExponentialBackoffRetryCount))
+
+	revisionId, err := expb.ExponentialBackOffSync(retrier)
+	if err != nil {
+		err = reComposeError(err, fmt.Sprintf("%s: pinning the new revision", relToRootPath))
+		g.log.LogErrln(err)
+		return err
+	}
+
+	g.log.Logf("%s: pinned revision %v\n", relToRootPath, revisionId)
+	return nil
+}
+
+// shouldUploadAsCopy reports whether the change would update a remote
+// file that is shared with others, which is to be kept as is.
+func (g *Commands) shouldUploadAsCopy(change *Change) bool {
+	if !g.opts.UploadAsCopy || change.Src == nil || change.Dest == nil {
+		return false
+	}
+	return !change.Src.IsDir && !change.Dest.IsDir && change.Dest.Shared
+}
+
+// copyTitle returns the title of a copy of the file titled title, made at t.
+func copyTitle(title string, t time.Time) string {
+	ext := gopath.Ext(title)
+	stem := strings.TrimSuffix(title, ext)
+	return fmt.Sprintf("%s (copy %s)%s", stem, t.Format("2006-01-02 15.04.05"), ext)
+}
+
 func (g *Commands) remoteAdd(change *Change) error {
 	return g.remoteMod(change)
 }
@@ -434,7 +733,7 @@ func (g *Commands) remoteUntrash(change *Change) error {
 	return nil
 }
 
-func remoteRemover(g *Commands, change *Change, fn func(string) error) error {
+func remoteRemover(g *Commands, change *Change, fn func(string) error, undoAction string) error {
 	defer func() {
 		g.taskAdd(change.Dest.Size)
 	}()
@@ -442,6 +741,7 @@ func remoteRemover(g *Commands, change *Change, fn func(string) error) error {
 	if err := fn(change.Dest.Id); err != nil {
 		return err
 	}
+	g.recordUndo(undoAction, change.Path, change.Dest)
 
 	if change.Dest.IsDir {
 		mkdirAllMu.Lock()
@@ -460,14 +760,23 @@ func remoteRemover(g *Commands, change *Change, fn func(string) error) error {
 }
 
 func (g *Commands) remoteTrash(change *Change) error {
-	return remoteRemover(g, change, g.rem.Trash)
+	return remoteRemover(g, change, g.rem.Trash, UndoActionTrash)
 }
 
 func (g *Commands) remoteDelete(change *Change) error {
-	return remoteRemover(g, change, g.rem.Delete)
+	return remoteRemover(g, change, g.rem.Delete, UndoActionDelete)
 }
 
+// notAFolderErr reports that the remote file at p is in the way
+// of a folder that files need to be pushed under.
+func notAFolderErr(p string, f *File) error {
+	return mkdirFailedErr(fmt.Errorf("%s exists remotely as a file of type %q with id %s, not a folder; move or rename it before pushing under it", p, f.MimeType, f.Id))
+}
+
+// remoteMkdirAll creates the remote folder d along with any missing parents,
+// failing if any part of the path exists but isn't a folder.
 func (g *Commands) remoteMkdirAll(d string) (*File, error) {
+	d = g.remotePathOf(d)
 	mkdirAllMu.Lock()
 
 	cachedValue, ok := g.mkdirAllCache.Get(d)
@@ -491,6 +800,9 @@ func (g *Commands) remoteMkdirAll(d string) (*File, error) {
 
 	if retrFile != nil {
 		mkdirAllMu.Unlock()
+		if !retrFile.IsDir {
+			return nil, notAFolderErr(d, retrFile)
+		}
 		return retrFile, nil
 	}
 
@@ -509,6 +821,8 @@ func (g *Commands) remoteMkdirAll(d string) (*File, error) {
 		if parentErr != nil || parent == nil {
 			return parent, parentErr
 		}
+	} else if !parent.IsDir {
+		return nil, notAFolderErr(parDirPath, parent)
 	}
 
 	mkdirAllMu.Lock()
@@ -528,6 +842,7 @@ func (g *Commands) remoteMkdirAll(d string) (*File, error) {
 		src:             remoteFile,
 		debug:           g.opts.Verbose && g.opts.canPreview(),
 		retryCount:      g.opts.ExponentialBackoffRetryCount,
+		retries:         newRetryBudget(g.opts.RetriesPerFile),
 	}
 
 	cur, curErr := g.rem.UpsertByComparison(&args)
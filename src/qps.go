@@ -0,0 +1,89 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// qpsLimiter spaces out requests evenly so that at most qps of them
+// are sent per second, whichever goroutine they are sent from.
+type qpsLimiter struct {
+	sync.Mutex
+
+	interval time.Duration
+	// next is the earliest time that the next request can be sent at.
+	next time.Time
+}
+
+// newQPSLimiter returns a limiter of qps requests per
+// second, nil if requests aren't to be limited.
+func newQPSLimiter(qps float64) *qpsLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	return &qpsLimiter{interval: time.Duration(float64(time.Second) / qps)}
+}
+
+// reserve books the next slot for a request made at now and
+// returns how long the request has to wait for its slot.
+func (ql *qpsLimiter) reserve(now time.Time) time.Duration {
+	ql.Lock()
+	defer ql.Unlock()
+
+	if ql.next.Before(now) {
+		ql.next = now
+	}
+	delay := ql.next.Sub(now)
+	ql.next = ql.next.Add(ql.interval)
+	return delay
+}
+
+func (ql *qpsLimiter) wait() {
+	if ql == nil {
+		return
+	}
+	if delay := ql.reserve(time.Now()); delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// qpsTransport holds back each request until the limiter lets it through.
+type qpsTransport struct {
+	base    http.RoundTripper
+	limiter *qpsLimiter
+}
+
+func (qt *qpsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	qt.limiter.wait()
+
+	base := qt.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return base.RoundTrip(req)
+}
+
+// limitQPS makes all the requests of r, from all of its
+// goroutines, share a limit of qps requests per second.
+func (r *Remote) limitQPS(qps float64) {
+	limiter := newQPSLimiter(qps)
+	if limiter == nil || r.client == nil {
+		return
+	}
+	r.client.Transport = &qpsTransport{base: r.client.Transport, limiter: limiter}
+}
@@ -128,7 +128,22 @@ func parseRCValues(rcMap map[string]string) (valueMappings map[string]interface{
 				CLIOptionIgnoreNameClashes, CLIOptionIgnoreChecksum, CLIOptionFixClashesKey,
 				CLIOptionDesktopLinks, CLIOptionExportsDumpToSameDirectory, CLIOptionTrashed,
 				CLIOptionStarred, CLIOptionPiped, CLIOptionExplicitlyExport,
-				CLIOptionDirectories, CLIOptionAllStarred,
+				CLIOptionDirectories, CLIOptionAllStarred, CLIOptionResume,
+				CLIOptionReverse, CLIOptionExportsStripExtension, CLIOptionExportsKeepOriginalName,
+				CLIOptionIgnoreCase, CLIOptionFollowShortcuts, CLIOptionRenameOnCollision,
+				CLIOptionAtomic, CLIOptionApplyRemoteDeletes, CLIOptionPromptAll,
+				CLIOptionMirror, CLIOptionCompress, CLIOptionDecompress, CLIOptionPullQueue,
+				CLIOptionDryRun, CLIOptionRepointShortcuts, CLIOptionContinueOnError,
+				CLIOptionParentsAsLabels, CLIOptionUploadAsCopy, CLIOptionSkipExisting,
+				CLIOptionPreserveMode, CLIOptionMetadataOnly, CLIOptionExcludeGoogleDocs,
+				CLIOptionFlattenSingleChild, CLIOptionSummaryOnly, CLIOptionByContent,
+				CLIOptionByName, CLIOptionVerifyDeep, CLIOptionWithComments,
+				CLIOptionFollow, CLIOptionWaitForLock, CLIOptionRemoteHashOnly,
+				CLIOptionExportAll, CLIOptionExportAllFormats,
+				CLIOptionListJSON, CLIOptionChunkedList, CLIOptionNoRecursive,
+				CLIOptionLinkShortcuts, CLIOptionPinRevision, CLIOptionKeepRevisions,
+				CLIOptionOwnedOnly, CLIOptionExcludeShared,
+				CLIOptionPreserveEmptyDirs, CLIOptionNoEmptyDirs, ActivityKey,
 			},
 		},
 		{
@@ -136,21 +151,38 @@ func parseRCValues(rcMap map[string]string) (valueMappings map[string]interface{
 				PageSizeKey,
 				DepthKey,
 				CLIOptionRetryCount,
+				CLIOptionCheckpointInterval,
+				CLIOptionPruneDepth,
+				CLIOptionRetryOnChecksumMismatch,
+				CLIOptionConcurrentList,
+				CLIOptionRetriesPerFile,
+			},
+		},
+		{
+			resolver: _int64fer, keys: []string{
+				CLIOptionMaxInflightBytes, CLIOptionSinceChangeId,
 			},
 		},
 		{
 			resolver: _stringfer, keys: []string{
-				CLIOptionUnified, CLIOptionDiffBaseLocal,
+				CLIOptionUnified, CLIOptionDiffBaseLocal, CLIOptionSince, CLIOptionTempDir,
+				CLIOptionMinFileSize, CLIOptionMaxFileSize, CLIOptionParentId,
+				CLIOptionDebounce, CLIOptionPollInterval, CLIOptionCreatedTime, CLIOptionRenameMap,
+				CLIOptionSlashReplacement, CLIOptionBandwidthSchedule, CLIOptionCutoff,
 				ExportsKey, ExcludeOpsKey, CLIOptionUnifiedShortKey,
 				CLIEncryptionPassword, CLIDecryptionPassword, SortKey,
 				CLIOptionNotOwner, ExportsDirKey, CLIOptionExactTitle, AddressKey,
-				CLIOptionPushDestination, CLIOptionSkipMime, CLIOptionMatchMime,
-				ExportsKey,
+				CLIOptionPushDestination, CLIOptionStripPrefix, CLIOptionIndexableText, CLIOptionSkipMime, CLIOptionMatchMime,
+				ExportsKey, CLIOptionOrderBy, CLIOptionListFormat,
+				CLIOptionLocalChecksumAlgo,
+				CLIOptionProgressJSON, CLIOptionUnicodeNormalization,
+				CLIOptionModifiedAfter, CLIOptionMime, CLIOptionQuery,
+				CLIOptionResumableStateTTL,
 			},
 		},
 		{
 			resolver: _stringArrayfer, keys: []string{
-			// Add items that might need string array parsing and conversion here
+				// Add items that might need string array parsing and conversion here
 			},
 		},
 	}
@@ -44,6 +44,9 @@ const (
 	// OAuth 2.0 full Drive scope used for authorization.
 	DriveScope = "https://www.googleapis.com/auth/drive"
 
+	// OAuth 2.0 read-only Drive Activity scope used by `stat -activity`.
+	DriveActivityScope = "https://www.googleapis.com/auth/drive.activity.readonly"
+
 	// OAuth 2.0 access type for offline/refresh access.
 	AccessType = "offline"
 
@@ -92,6 +95,42 @@ type Remote struct {
 	encrypter    func(io.Reader) (io.Reader, error)
 	decrypter    func(io.Reader) (io.ReadCloser, error)
 	progressChan chan int
+	// ignoreCase when set resolves paths by matching
+	// titles case-insensitively.
+	ignoreCase bool
+	// normalize when set is applied to titles when matching
+	// them, so that titles in other Unicode forms match too.
+	normalize func(string) string
+	// rootFolderId when set is the id of the folder
+	// that paths are resolved relative to instead of "root".
+	rootFolderId string
+	// bandwidth when set paces uploads and downloads.
+	bandwidth *bandwidthLimiter
+	// chunks adapts the chunk size of uploads to how they go.
+	chunks chunkSizer
+}
+
+func (r *Remote) normalized(title string) string {
+	if r.normalize == nil {
+		return title
+	}
+	return r.normalize(title)
+}
+
+// titlesMatch reports whether the titles are the same, ignoring
+// differences in Unicode normalization and, if set, in case.
+func (r *Remote) titlesMatch(a, b string) bool {
+	if r.ignoreCase {
+		return strings.EqualFold(r.normalized(a), r.normalized(b))
+	}
+	return r.normalized(a) == r.normalized(b)
+}
+
+func (r *Remote) rootFolder() string {
+	if r.rootFolderId != "" {
+		return r.rootFolderId
+	}
+	return "root"
 }
 
 // NewRemoteContextFromServiceAccount returns a remote initialized
@@ -101,8 +140,10 @@ type Remote struct {
 // https://developers.google.com/accounts/docs/application-default-credentials
 //
 // You'll also need to configure access to Google Drive.
-func NewRemoteContextFromServiceAccount(jwtConfig *jwt.Config) (*Remote, error) {
-	client := jwtConfig.Client(context.Background())
+//
+// The connection pool is tuned with the settings from configContext, which may be nil.
+func NewRemoteContextFromServiceAccount(jwtConfig *jwt.Config, configContext *config.Context) (*Remote, error) {
+	client := jwtConfig.Client(transportContext(configContext))
 	return remoteFromClient(client)
 }
 
@@ -180,8 +221,8 @@ func (r *Remote) change(changeId string) (*drive.Change, error) {
 	return r.service.Changes.Get(changeId).Do()
 }
 
-func RetrieveRefreshToken(ctx context.Context, context *config.Context) (string, error) {
-	config := newAuthConfig(context)
+func RetrieveRefreshToken(ctx context.Context, context *config.Context, extraScopes ...string) (string, error) {
+	config := newAuthConfig(context, extraScopes...)
 
 	randState := fmt.Sprintf("%s%v", time.Now(), rand.Uint32())
 	url := config.AuthCodeURL(randState, oauth2.AccessTypeOffline)
@@ -282,7 +323,7 @@ func retryableChangeOp(fn func() (interface{}, error), debug bool, retryCount in
 
 func (r *Remote) findByPathM(p string, trashed bool) *paginationPair {
 	if rootLike(p) {
-		return r.FindByIdM("root")
+		return r.FindByIdM(r.rootFolder())
 	}
 
 	parts := strings.Split(p, RemoteSeparator)
@@ -291,19 +332,19 @@ func (r *Remote) findByPathM(p string, trashed bool) *paginationPair {
 		finder = r.findByPathTrashedM
 	}
 
-	return finder("root", parts[1:])
+	return finder(r.rootFolder(), parts[1:])
 }
 
 func (r *Remote) findByPath(p string, trashed bool) (*File, error) {
 	if rootLike(p) {
-		return r.FindById("root")
+		return r.FindById(r.rootFolder())
 	}
 	parts := strings.Split(p, "/")
 	finder := r.findByPathRecv
 	if trashed {
 		finder = r.findByPathTrashed
 	}
-	return finder("root", parts[1:])
+	return finder(r.rootFolder(), parts[1:])
 }
 
 func (r *Remote) FindByPath(p string) (*File, error) {
@@ -569,12 +610,13 @@ func (r *Remote) Unpublish(id string) error {
 	return r.deletePermissions(id, Anyone)
 }
 
-func (r *Remote) Publish(id string) (string, error) {
+func (r *Remote) Publish(id string, role Role, withLink bool) (string, error) {
 	_, err := r.insertPermissions(&permission{
 		fileId:      id,
 		value:       "",
-		role:        Reader,
+		role:        role,
 		accountType: Anyone,
+		withLink:    withLink,
 	})
 	if err != nil {
 		return "", err
@@ -624,6 +666,28 @@ func (r *Remote) Download(id string, exportURL string) (io.ReadCloser, error) {
 	return body, err
 }
 
+func (r *Remote) revision(fileId, revisionId string) (*drive.Revision, error) {
+	return r.service.Revisions.Get(fileId, revisionId).Do()
+}
+
+// pinHeadRevision marks the head revision of the file as pinned, which keeps
+// it forever instead of letting it be purged, and returns its id.
+func (r *Remote) pinHeadRevision(fileId string) (string, error) {
+	f, err := r.service.Files.Get(fileId).Fields("headRevisionId").Do()
+	if err != nil {
+		return "", err
+	}
+	if f.HeadRevisionId == "" {
+		return "", illogicalStateErr(fmt.Errorf("%s has no head revision to pin", fileId))
+	}
+
+	rev, err := r.service.Revisions.Patch(fileId, f.HeadRevisionId, &drive.Revision{Pinned: true}).Do()
+	if err != nil {
+		return "", err
+	}
+	return rev.Id, nil
+}
+
 func (r *Remote) Touch(id string) (*File, error) {
 	f, err := r.service.Files.Touch(id).Do()
 	if err != nil {
@@ -692,9 +756,20 @@ type upsertOpt struct {
 	mask            int
 	ignoreChecksum  bool
 	mimeKey         string
+	sniffedMimeType string
+	mimeOverride    string
+	title           string
+	compress        bool
+	createdTime     time.Time
 	nonStatable     bool
 	retryCount      int
+	// retries when set bounds the retries of all the requests for the file.
+	retries         *retryBudget
 	uploadChunkSize int
+	properties      map[string]string
+	indexableText   string
+	// progress when set is also reported the bytes uploaded.
+	progress func(int)
 }
 
 func togglePropertiesInsertCall(req *drive.FilesInsertCall, mask int) *drive.FilesInsertCall {
@@ -756,10 +831,20 @@ func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, m
 		Parents: []*drive.ParentReference{&drive.ParentReference{Id: args.parentId}},
 	}
 
+	// Restore the original title of files that were pulled under other names
+	if args.title != "" {
+		uploaded.Title = args.title
+	}
+
 	if args.src.IsDir {
 		uploaded.MimeType = DriveFolderMimeType
 	}
 
+	// Compression has to precede encryption since encrypted content barely compresses
+	if args.compress && body != nil && args.shouldUploadBody() {
+		body = gzipReader(body)
+	}
+
 	if r.encrypter != nil && body != nil {
 		encR, encErr := r.encrypter(body)
 		if encErr != nil {
@@ -777,15 +862,42 @@ func (r *Remote) upsertByComparison(body io.Reader, args *upsertOpt) (f *File, m
 		uploaded.MimeType = guessMimeType(args.mimeKey)
 	}
 
+	if args.sniffedMimeType != "" {
+		uploaded.MimeType = args.sniffedMimeType
+	}
+
+	// A mimeType configured for the extension takes precedence
+	if args.mimeOverride != "" {
+		uploaded.MimeType = args.mimeOverride
+	}
+
 	// Ensure that the ModifiedDate is retrieved from local
 	uploaded.ModifiedDate = toUTCString(args.src.ModTime)
 
-	var mediaOptions []googleapi.MediaOption
-	if args.uploadChunkSize > 0 {
-		mediaOptions = append(mediaOptions, googleapi.ChunkSize(args.uploadChunkSize))
+	properties := args.properties
+	if args.compress {
+		uploaded.Title += GzipExt
+		uploaded.MimeType = GzipMimeType
+		properties = compressionProperties(args.src, properties)
+	}
+
+	if len(properties) >= 1 {
+		uploaded.Properties = toDriveProperties(properties)
 	}
 
+	// Makes the content of otherwise opaque files e.g images findable by search
+	if args.indexableText != "" && !args.src.IsDir {
+		uploaded.IndexableText = &drive.FileIndexableText{Text: args.indexableText}
+	}
+
+	mediaOptions := []googleapi.MediaOption{googleapi.ChunkSize(r.uploadChunkSize(args))}
+
 	if args.src.Id == "" {
+		// The created date can only be set on insertion
+		if !args.createdTime.IsZero() {
+			uploaded.CreatedDate = toUTCString(args.createdTime)
+		}
+
 		req := r.service.Files.Insert(uploaded)
 
 		if !args.src.IsDir && body != nil {
@@ -864,6 +976,26 @@ func (r *Remote) updateStarred(fileId string, star bool) (*File, error) {
 	return r.byFileIdUpdater(fileId, f)
 }
 
+// reparent adds the parent addParentId to the file, removes removeParentId
+// if set and renames it to newTitle if set, all in a single update.
+func (r *Remote) reparent(fileId, addParentId, removeParentId, newTitle string) (*File, error) {
+	f := &drive.File{
+		Title: newTitle,
+	}
+
+	req := r.service.Files.Patch(fileId, f).AddParents(addParentId)
+	if removeParentId != "" {
+		req = req.RemoveParents(removeParentId)
+	}
+
+	patched, err := req.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRemoteFile(patched), nil
+}
+
 func (r *Remote) removeParent(fileId, parentId string) error {
 	return r.service.Parents.Delete(fileId, parentId).Do()
 }
@@ -902,6 +1034,7 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 
 	var body io.Reader
 	var cleanUp func() error
+	var content io.Seeker
 
 	if !args.src.IsDir {
 		// In relation to issue #612, since we are not only resolving
@@ -923,15 +1056,19 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 			// See Issue https://github.com/odeke-em/drive/issues/711.
 			cleanUp = file.Close
 			body = file
+			content = file
 		}
 	}
 
-	bd := statos.NewReader(body)
+	bd := statos.NewReader(r.throttle(body))
 
 	go func() {
 		commChan := bd.ProgressChan()
 		for n := range commChan {
 			r.progressChan <- n
+			if args.progress != nil {
+				args.progress(n)
+			}
 		}
 	}()
 
@@ -942,12 +1079,10 @@ func (r *Remote) UpsertByComparison(args *upsertOpt) (f *File, err error) {
 			defer cleanUp()
 		}
 
-		emitter := func() (interface{}, error) {
-			f, mediaInserted, err := r.upsertByComparison(bd, args)
-			return &tuple{first: f, second: mediaInserted, last: err}, err
-		}
-
-		retrier := retryableChangeOp(emitter, args.debug, args.retryCount)
+		emitter := r.uploadEmitter(args, content, func() (*File, bool, error) {
+			return r.upsertByComparison(bd, args)
+		})
+		retrier := args.retries.bound(retryableChangeOp(emitter, args.debug, args.retryCount))
 
 		res, err := expb.ExponentialBackOffSync(retrier)
 		resultLoad <- &tuple{first: res, last: err}
@@ -1064,17 +1199,14 @@ func (r *Remote) findByPathRecvRawM(parentId string, p []string, trashed bool) *
 		// find the file or directory under parentId and titled with p[0]
 		req := r.service.Files.List()
 		// TODO: use field selectors
-		var expr string
 		head := urlToPath(first, false)
-		if trashed {
-			expr = fmt.Sprintf("title = %s and trashed=true", customQuote(head))
-		} else {
-			expr = fmt.Sprintf("%s in parents and title = %s and trashed=false",
-				customQuote(parentId), customQuote(head))
-		}
+		expr := r.titleMatchExpr(parentId, head, trashed)
 
 		req.Q(expr)
 		pager := _reqDoPage(req, true, false, true)
+		if r.ignoreCase {
+			pager = r.filterByTitleMatch(pager, head)
+		}
 
 		if len(rest) < 1 {
 			chanOChan <- pager
@@ -1148,16 +1280,24 @@ func (r *Remote) findByPathRecvRaw(parentId string, p []string, trashed bool) (*
 	// find the file or directory under parentId and titled with p[0]
 	req := r.service.Files.List()
 	// TODO: use field selectors
-	var expr string
 	head := urlToPath(p[0], false)
-	if trashed {
-		expr = fmt.Sprintf("title = %s and trashed=true", customQuote(head))
-	} else {
-		expr = fmt.Sprintf("%s in parents and title = %s and trashed=false",
-			customQuote(parentId), customQuote(head))
-	}
+	expr := r.titleMatchExpr(parentId, head, trashed)
 	req.Q(expr)
 
+	if r.ignoreCase {
+		first, err := r.firstTitleMatch(req, head)
+		if err != nil {
+			if err.Error() == ErrGoogleAPIInvalidQueryHardCoded.Error() {
+				err = invalidGoogleAPIQueryErr(fmt.Errorf("err: %v query: `%s`", err, expr))
+			}
+			return nil, err
+		}
+		if len(p) == 1 {
+			return NewRemoteFile(first), nil
+		}
+		return r.findByPathRecvRaw(first.Id, p[1:], trashed)
+	}
+
 	// We only need the head file since we expect only one File to be created
 	req.MaxResults(1)
 
@@ -1181,6 +1321,84 @@ func (r *Remote) findByPathRecvRaw(parentId string, p []string, trashed bool) (*
 	return r.findByPathRecvRaw(first.Id, p[1:], trashed)
 }
 
+// titleMatchExpr returns the query for the files titled head under parentId.
+func (r *Remote) titleMatchExpr(parentId, head string, trashed bool) string {
+	titleOp := "title ="
+	if r.ignoreCase {
+		// `title contains` is matched case-insensitively by the API,
+		// the results are then narrowed down to those whose titles
+		// only differ from head by case.
+		titleOp = "title contains"
+	}
+
+	heads := []string{head}
+	if r.normalize != nil {
+		heads = titleVariants(head)
+	}
+
+	var titleClauses []string
+	for _, variant := range heads {
+		titleClauses = append(titleClauses, fmt.Sprintf("%s %s", titleOp, customQuote(variant)))
+	}
+	titleExpr := titleClauses[0]
+	if len(titleClauses) > 1 {
+		titleExpr = fmt.Sprintf("(%s)", strings.Join(titleClauses, " or "))
+	}
+
+	if trashed {
+		return fmt.Sprintf("%s and trashed=true", titleExpr)
+	}
+	return fmt.Sprintf("%s in parents and %s and trashed=false", customQuote(parentId), titleExpr)
+}
+
+func (r *Remote) filterByTitleMatch(pager *paginationPair, title string) *paginationPair {
+	filesChan := make(chan *File)
+
+	go func() {
+		defer close(filesChan)
+
+		matched := false
+		for f := range pager.filesChan {
+			if f != nil && r.titlesMatch(f.Name, title) {
+				matched = true
+				filesChan <- f
+			}
+		}
+
+		if !matched {
+			// Preserve the nil on no match semantics of _reqDoPage
+			filesChan <- nil
+		}
+	}()
+
+	return &paginationPair{errsChan: pager.errsChan, filesChan: filesChan}
+}
+
+func (r *Remote) firstTitleMatch(req *drive.FilesListCall, title string) (*drive.File, error) {
+	pageToken := ""
+	for {
+		if pageToken != "" {
+			req = req.PageToken(pageToken)
+		}
+
+		files, err := req.Do()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, f := range files.Items {
+			if f != nil && r.titlesMatch(f.Title, title) {
+				return f, nil
+			}
+		}
+
+		pageToken = files.NextPageToken
+		if pageToken == "" {
+			return nil, ErrPathNotExists
+		}
+	}
+}
+
 func (r *Remote) findByPathRecv(parentId string, p []string) (*File, error) {
 	return r.findByPathRecvRaw(parentId, p, false)
 }
@@ -1197,23 +1415,21 @@ func (r *Remote) findByPathTrashed(parentId string, p []string) (*File, error) {
 	return r.findByPathRecvRaw(parentId, p, true)
 }
 
-func newAuthConfig(context *config.Context) *oauth2.Config {
+// newAuthConfig returns the OAuth2.0 config that asks for the Drive
+// scope along with any extra scopes that were requested.
+func newAuthConfig(context *config.Context, extraScopes ...string) *oauth2.Config {
 	return &oauth2.Config{
 		ClientID:     context.ClientId,
 		ClientSecret: context.ClientSecret,
 		RedirectURL:  RedirectURL,
 		Endpoint:     google.Endpoint,
-		Scopes:       []string{DriveScope},
+		Scopes:       append([]string{DriveScope}, extraScopes...),
 	}
 }
 
 func newOAuthClient(configContext *config.Context) *http.Client {
-	config := newAuthConfig(configContext)
-
-	token := oauth2.Token{
-		RefreshToken: configContext.RefreshToken,
-		Expiry:       time.Now().Add(1 * time.Hour),
+	source := newRefreshingTokenSource(transportContext(configContext), configContext)
+	return &http.Client{
+		Transport: &refreshingTransport{source: source, base: newTransport(configContext)},
 	}
-
-	return config.Client(context.Background(), &token)
 }
@@ -0,0 +1,215 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+const (
+	renameMapSeparator = "=>"
+
+	// DefaultSlashReplacement replaces the "/" in remote titles, it is
+	// the escaped "/" that pushes already turn back into "/" in titles.
+	DefaultSlashReplacement = "%2F"
+)
+
+// RenameRule replaces the matches of Pattern in
+// remote titles to get their local names on pull.
+type RenameRule struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// slashPattern matches "/" as well as its escaped form since
+// the names of remote files could have been escaped already.
+var slashPattern = regexp.MustCompile(`/|` + regexp.QuoteMeta(url.QueryEscape("/")))
+
+// DefaultRenameRules replace the characters that can't be in local names.
+// Drive allows "/" in titles yet it is the path separator everywhere, so
+// it is replaced with slashReplacement e.g "%2F" to escape it.
+func DefaultRenameRules(goos, slashReplacement string) []RenameRule {
+	if slashReplacement == "" {
+		slashReplacement = DefaultSlashReplacement
+	}
+
+	rules := []RenameRule{{Pattern: slashPattern, Replacement: slashReplacement}}
+	if goos == OSWindowsKey {
+		rules = append(rules, RenameRule{Pattern: regexp.MustCompile(`[<>:"\\|?*\x00-\x1f]`), Replacement: "_"})
+	}
+	return rules
+}
+
+// ParseRenameMap parses lines of `<regex> => <replacement>` rules. The
+// replacement can refer to submatches e.g `$1`. Blank lines and lines
+// starting with # are skipped.
+func ParseRenameMap(r io.Reader) (rules []RenameRule, err error) {
+	scanner := bufio.NewScanner(r)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		i := strings.LastIndex(line, renameMapSeparator)
+		if i < 0 {
+			return nil, invalidArgumentsErr(fmt.Errorf("rename map line %d: expecting `<regex> %s <replacement>`", lineNumber, renameMapSeparator))
+		}
+
+		pattern := strings.TrimSpace(line[:i])
+		replacement := strings.TrimSpace(line[i+len(renameMapSeparator):])
+		re, reErr := regexp.Compile(pattern)
+		if pattern == "" || reErr != nil {
+			return nil, invalidArgumentsErr(fmt.Errorf("rename map line %d: %q is not a valid regex", lineNumber, pattern))
+		}
+
+		rules = append(rules, RenameRule{Pattern: re, Replacement: replacement})
+	}
+
+	return rules, scanner.Err()
+}
+
+// localName applies rules in order to the remote title name.
+func localName(name string, rules []RenameRule) string {
+	for _, rule := range rules {
+		name = rule.Pattern.ReplaceAllString(name, rule.Replacement)
+	}
+
+	switch name {
+	case "", ".", "..":
+		name = "_" + name
+	}
+	return name
+}
+
+func (g *Commands) renameRules() []RenameRule {
+	return append(append([]RenameRule{}, g.opts.RenameRules...), DefaultRenameRules(runtime.GOOS, g.opts.SlashReplacement)...)
+}
+
+// titleSidecar holds the original titles of the remote files
+// that were pulled under other local names.
+type titleSidecar struct {
+	sync.Once
+	// titles maps local paths to the original titles.
+	titles map[string]string
+	// localPaths maps the parent paths joined by titleKey
+	// to the original titles back to the local paths.
+	localPaths map[string]string
+}
+
+func titleKey(parentPath, title string) string {
+	return parentPath + "\x00" + title
+}
+
+func (g *Commands) titleSidecar() *titleSidecar {
+	g.titles.Do(func() {
+		titles, err := g.context.ReadTitles()
+		if err != nil {
+			g.log.LogErrf("titles: reading %v\n", err)
+		}
+
+		g.titles.titles = titles
+		g.titles.localPaths = make(map[string]string)
+		for localPath, title := range titles {
+			g.titles.localPaths[titleKey(path.Dir(localPath), title)] = localPath
+		}
+	})
+	return &g.titles
+}
+
+// originalTitle returns the remote title that the local
+// file at relToRootPath was pulled from, if it was renamed.
+func (g *Commands) originalTitle(relToRootPath string) (string, bool) {
+	title, ok := g.titleSidecar().titles[relToRootPath]
+	return title, ok
+}
+
+// renamedView returns the view of the children of the remote folder at
+// remoteBase with their local names. On pull, names are derived with the
+// rename rules and the renamed ones are recorded, while on push names are
+// looked up in the titles that were recorded by previous pulls.
+func (g *Commands) renamedView(remoteBase string, pagePair *paginationPair, push bool) *paginationPair {
+	rules := g.renameRules()
+	sidecar := g.titleSidecar()
+	filesChan := make(chan *File)
+
+	go func() {
+		defer close(filesChan)
+		for f := range pagePair.filesChan {
+			if f == nil {
+				filesChan <- f
+				continue
+			}
+
+			// The names of remote files are escaped, the titles are recorded as they are
+			title := urlToPath(f.Name, false)
+
+			name := f.Name
+			if !push {
+				name = localName(title, rules)
+			} else if localPath, ok := sidecar.localPaths[titleKey(remoteBase, title)]; ok {
+				name = path.Base(localPath)
+			}
+
+			if name == f.Name {
+				filesChan <- f
+				continue
+			}
+
+			renamed := *f
+			renamed.Name = name
+			if !push {
+				g.renamedTitles.add(remotePathJoin(remoteBase, name), title)
+			}
+			filesChan <- &renamed
+		}
+	}()
+
+	return &paginationPair{errsChan: pagePair.errsChan, filesChan: filesChan}
+}
+
+// summarizeRenamedTitles reports the remote files that were pulled under
+// other names and records their original titles for pushes to restore.
+func (g *Commands) summarizeRenamedTitles() {
+	paths, titles := g.renamedTitles.sorted()
+	if len(paths) < 1 {
+		return
+	}
+
+	g.log.Logf("\n%d remote file(s) have different local names:\n", len(paths))
+
+	sidecar := g.titleSidecar()
+	merged := make(map[string]string)
+	for p, title := range sidecar.titles {
+		merged[p] = title
+	}
+	for _, p := range paths {
+		g.log.Logf("\t%s <= %q\n", p, titles[p])
+		merged[p] = titles[p]
+	}
+
+	// Record the original titles so that pushes can restore them
+	if err := g.context.WriteTitles(merged); err != nil {
+		g.log.LogErrf("titles: writing %v\n", err)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	drive "google.golang.org/api/drive/v2"
+)
+
+// Restrictions are the distribution controls to set on files,
+// those that are nil are left as they are.
+type Restrictions struct {
+	// ViewersCanCopyContent when false keeps readers and
+	// commenters from downloading, printing and copying files.
+	ViewersCanCopyContent *bool
+	// WritersCanShare when false keeps writers from sharing files.
+	WritersCanShare *bool
+}
+
+func parseOptionalBool(option, value string) (*bool, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, invalidArgumentsErr(fmt.Errorf("%s: %q is neither true nor false", option, value))
+	}
+	return &b, nil
+}
+
+// ParseRestrictions parses the true or false values of the restriction
+// flags, returning nil if neither was set.
+func ParseRestrictions(viewersCanCopyContent, writersCanShare string) (*Restrictions, error) {
+	viewersCanCopy, err := parseOptionalBool(CLIOptionViewersCanCopy, viewersCanCopyContent)
+	if err != nil {
+		return nil, err
+	}
+	writersShare, err := parseOptionalBool(CLIOptionWritersCanShare, writersCanShare)
+	if err != nil {
+		return nil, err
+	}
+
+	if viewersCanCopy == nil && writersShare == nil {
+		return nil, nil
+	}
+	return &Restrictions{ViewersCanCopyContent: viewersCanCopy, WritersCanShare: writersShare}, nil
+}
+
+// restrictionsPatch returns the file with which to patch a file to rs.
+// False values have to be sent explicitly for them not to be omitted.
+func restrictionsPatch(rs *Restrictions) *drive.File {
+	f := &drive.File{}
+	if rs.ViewersCanCopyContent != nil {
+		restricted := !*rs.ViewersCanCopyContent
+		// labels.restricted is the older name for copyRequiresWriterPermission
+		f.CopyRequiresWriterPermission = restricted
+		f.Labels = &drive.FileLabels{Restricted: restricted, ForceSendFields: []string{"Restricted"}}
+		f.ForceSendFields = append(f.ForceSendFields, "CopyRequiresWriterPermission")
+	}
+	if rs.WritersCanShare != nil {
+		f.WritersCanShare = *rs.WritersCanShare
+		f.ForceSendFields = append(f.ForceSendFields, "WritersCanShare")
+	}
+	return f
+}
+
+func (r *Remote) restrict(fileId string, rs *Restrictions) (*File, error) {
+	patched, err := r.service.Files.Patch(fileId, restrictionsPatch(rs)).Do()
+	if err != nil {
+		return nil, err
+	}
+	return NewRemoteFile(patched), nil
+}
+
+func restrictionState(f *File) string {
+	copying, sharing := "allowed", "allowed"
+	if f.CopyRequiresWriterPermission {
+		copying = "blocked"
+	}
+	if !f.WritersCanShare {
+		sharing = "blocked"
+	}
+	return fmt.Sprintf("download, print and copy by viewers %s, sharing by writers %s", copying, sharing)
+}
+
+// applyRestrictions sets the requested restrictions on the files
+// and reports the restrictions that they end up with.
+func (c *Commands) applyRestrictions(files []*File) (err error) {
+	rs := c.opts.Restrictions
+	if rs == nil {
+		return nil
+	}
+
+	for _, file := range files {
+		if file == nil {
+			continue
+		}
+
+		restricted, rErr := c.rem.restrict(file.Id, rs)
+		if rErr != nil {
+			err = reComposeError(err, fmt.Sprintf("restricting %s: %v", file.Name, rErr))
+			continue
+		}
+		c.log.Logf("%s: %s\n", file.Name, restrictionState(restricted))
+	}
+
+	return err
+}
@@ -0,0 +1,162 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"path"
+)
+
+type expiredFile struct {
+	path string
+	file *File
+}
+
+// expired reports whether f was last modified before the cutoff and is
+// at least as large as the minimum size, if one was set.
+func (g *Commands) expired(f *File) bool {
+	if f == nil || f.IsDir || !f.ModTime.Before(g.opts.OlderThan) {
+		return false
+	}
+	return g.opts.MinFileSize <= 0 || f.Size >= g.opts.MinFileSize
+}
+
+// TrashOlderThan trashes the files under the sources that were
+// last modified before the cutoff, as a retention policy.
+func (g *Commands) TrashOlderThan() (err error) {
+	if g.opts.OlderThan.IsZero() {
+		return invalidArgumentsErr(fmt.Errorf("expecting a cutoff to trash the files older than"))
+	}
+
+	var expiredFiles []*expiredFile
+	for _, relToRootPath := range g.opts.Sources {
+		f, fErr := g.rem.FindByPath(relToRootPath)
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+			continue
+		}
+
+		found, fErr := g.findExpired(relToRootPath, f)
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRootPath, fErr))
+		}
+		expiredFiles = append(expiredFiles, found...)
+	}
+
+	if len(expiredFiles) < 1 {
+		g.log.Logf("no files were modified before %v\n", g.opts.OlderThan)
+		return err
+	}
+
+	reclaimable := int64(0)
+	for _, ef := range expiredFiles {
+		g.log.Logf("%-12s %v %s\n", prettyBytes(ef.file.Size), ef.file.ModTime, ef.path)
+		reclaimable += ef.file.Size
+	}
+
+	g.log.Logf("%d file(s) modified before %v, %s to reclaim\n", len(expiredFiles), g.opts.OlderThan, prettyBytes(reclaimable))
+
+	if g.opts.DryRun {
+		return err
+	}
+
+	if g.opts.canPrompt() {
+		if status := promptForChanges(); !accepted(status) {
+			return status.Error()
+		}
+	}
+
+	reclaimed := int64(0)
+	trashedCount := 0
+	for _, ef := range expiredFiles {
+		if tErr := g.rem.Trash(ef.file.Id); tErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: trashing %v", ef.path, tErr))
+			continue
+		}
+		g.recordUndo(UndoActionTrash, ef.path, ef.file)
+		reclaimed += ef.file.Size
+		trashedCount += 1
+	}
+
+	g.log.Logf("Trashed %d file(s), reclaimed %s\n", trashedCount, prettyBytes(reclaimed))
+	return err
+}
+
+func (g *Commands) findExpired(relToRootPath string, f *File) (expiredFiles []*expiredFile, err error) {
+	if f == nil {
+		return nil, ErrPathNotExists
+	}
+
+	if anyMatch(g.opts.Ignorer, path.Base(relToRootPath), relToRootPath) {
+		return nil, nil
+	}
+
+	if !f.IsDir {
+		if g.expired(f) {
+			expiredFiles = append(expiredFiles, &expiredFile{path: relToRootPath, file: f})
+		}
+		return expiredFiles, nil
+	}
+
+	var subFolders []*File
+	pagePair := g.rem.FindByParentId(f.Id, g.opts.Hidden)
+	errsChan := pagePair.errsChan
+	childrenChan := pagePair.filesChan
+
+	var listErr error
+	working := true
+	for working {
+		select {
+		case pErr := <-errsChan:
+			if pErr != nil && listErr == nil {
+				listErr = pErr
+			}
+		case child, stillHasContent := <-childrenChan:
+			if !stillHasContent {
+				working = false
+				break
+			}
+			if child == nil {
+				continue
+			}
+
+			childPath := remotePathJoin(relToRootPath, child.Name)
+			if anyMatch(g.opts.Ignorer, child.Name, childPath) {
+				continue
+			}
+
+			if child.IsDir {
+				subFolders = append(subFolders, child)
+			} else if g.expired(child) {
+				expiredFiles = append(expiredFiles, &expiredFile{path: childPath, file: child})
+			}
+		}
+	}
+
+	if listErr != nil {
+		return expiredFiles, listErr
+	}
+
+	for _, subFolder := range subFolders {
+		subPath := remotePathJoin(relToRootPath, subFolder.Name)
+		found, fErr := g.findExpired(subPath, subFolder)
+		if fErr != nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", subPath, fErr))
+		}
+		expiredFiles = append(expiredFiles, found...)
+	}
+
+	return expiredFiles, err
+}
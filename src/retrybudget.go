@@ -0,0 +1,82 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"sync"
+
+	expb "github.com/odeke-em/exponential-backoff"
+)
+
+// retryBudget holds the retries left for all the requests made for a single
+// file, so that a file that keeps failing gives up after its own allowance
+// instead of going through -retry-count retries for each of its requests.
+// A nil budget places no limit beyond -retry-count.
+type retryBudget struct {
+	sync.Mutex
+
+	left int
+}
+
+func newRetryBudget(retriesPerFile int) *retryBudget {
+	if retriesPerFile < 1 {
+		return nil
+	}
+	return &retryBudget{left: retriesPerFile}
+}
+
+// take uses up one retry, returning false if none are left.
+func (rb *retryBudget) take() bool {
+	if rb == nil {
+		return true
+	}
+
+	rb.Lock()
+	defer rb.Unlock()
+	if rb.left < 1 {
+		return false
+	}
+	rb.left -= 1
+	return true
+}
+
+// bound makes each retry of retrier take from the budget, with the
+// last failure returned as is once the budget has been used up.
+func (rb *retryBudget) bound(retrier *expb.ExponentialBacker) *expb.ExponentialBacker {
+	if rb == nil {
+		return retrier
+	}
+
+	do, statusCheck := retrier.Do, retrier.StatusCheck
+
+	attempts := 0
+	exhausted := false
+	var lastValue interface{}
+	var lastErr error
+
+	retrier.Do = func() (interface{}, error) {
+		if attempts += 1; attempts > 1 && !rb.take() {
+			exhausted = true
+			return lastValue, lastErr
+		}
+		lastValue, lastErr = do()
+		return lastValue, lastErr
+	}
+	retrier.StatusCheck = func(v interface{}) (ok, retryable bool) {
+		ok, retryable = statusCheck(v)
+		return ok, retryable && !exhausted
+	}
+	return retrier
+}
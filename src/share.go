@@ -19,7 +19,9 @@ import (
 	"strings"
 	"sync"
 
+	expb "github.com/odeke-em/exponential-backoff"
 	"github.com/odeke-em/log"
+	"github.com/odeke-em/semalim"
 )
 
 type AccountType int
@@ -63,6 +65,7 @@ type shareChange struct {
 
 type permission struct {
 	fileId      string
+	fileName    string
 	value       string
 	message     string
 	role        Role
@@ -286,8 +289,7 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 		}
 	}
 
-	successes := 0
-
+	var perms []*permission
 	for _, file := range change.files {
 		for _, accountType := range change.accountTypes {
 			for _, email := range change.emails {
@@ -298,8 +300,9 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 				}
 
 				for _, role := range change.roles {
-					perm := permission{
+					perms = append(perms, &permission{
 						fileId:      file.Id,
+						fileName:    file.Name,
 						value:       email,
 						role:        role,
 						accountType: accountType,
@@ -307,22 +310,75 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 						notify:   change.notify,
 						message:  change.emailMessage,
 						withLink: change.withLink,
-					}
-
-					if ferr := fn(&perm); ferr != nil {
-						err = reComposeError(err, fmt.Sprintf("%s err %s: %v\n", fnName, file.Name, ferr))
-					} else {
-						successes += 1
-						if c.opts.Verbose {
-							c.log.Logf("successful %s for %s with email %q, role %q accountType %q\n",
-								fnName, file.Name, email, role.String(), accountType.String())
-						}
-					}
+					})
 				}
 			}
 		}
 	}
 
+	if len(perms) < 1 {
+		return noMatchesFoundErr(fmt.Errorf("no matches found!"))
+	}
+
+	c.taskStart(int64(len(perms)))
+
+	n := maxProcs()
+	debug := c.opts.Verbose && c.opts.canPreview()
+	jobsChan := make(chan semalim.Job)
+
+	go func() {
+		defer close(jobsChan)
+
+		for i, perm := range perms {
+			perm := perm
+			do := func() (interface{}, error) {
+				emitter := func() (interface{}, error) {
+					return perm, fn(perm)
+				}
+
+				// Permission calls on large trees easily get rate limited,
+				// so each one gets retried with exponential backoff.
+				retrier := retryableChangeOp(emitter, debug, c.opts.ExponentialBackoffRetryCount)
+				_, err := expb.ExponentialBackOffSync(retrier)
+				c.taskAdd(1)
+				return perm, err
+			}
+
+			jobsChan <- jobSt{id: uint64(i), do: do}
+		}
+	}()
+
+	successes, failures := 0, 0
+	sharedFiles := make(map[string]bool)
+
+	results := semalim.Run(jobsChan, uint64(n))
+	for result := range results {
+		perm, _ := result.Value().(*permission)
+		if perm == nil {
+			continue
+		}
+
+		if ferr := result.Err(); ferr != nil {
+			failures += 1
+			err = reComposeError(err, fmt.Sprintf("%s err %s: %v\n", fnName, perm.fileName, ferr))
+			continue
+		}
+
+		successes += 1
+		sharedFiles[perm.fileId] = true
+		if c.opts.Verbose {
+			c.log.Logf("successful %s for %s with email %q, role %q accountType %q\n",
+				fnName, perm.fileName, perm.value, perm.role.String(), perm.accountType.String())
+		}
+	}
+
+	c.taskFinish()
+
+	if c.opts.Recursive || failures >= 1 {
+		c.log.Logf("%s: %d file(s) done, %d/%d permission update(s) succeeded, %d failed\n",
+			fnName, len(sharedFiles), successes, len(perms), failures)
+	}
+
 	if err != nil {
 		return err
 	}
@@ -334,9 +390,63 @@ func (c *Commands) playShareChanges(change *shareChange) (err error) {
 	return nil
 }
 
+// descendants returns the files together with all the files nested under
+// any of them that are folders.
+func (c *Commands) descendants(files []*File) (all []*File, err error) {
+	seen := make(map[string]bool)
+	queue := append([]*File{}, files...)
+
+	for len(queue) >= 1 {
+		file := queue[0]
+		queue = queue[1:]
+
+		if file == nil || seen[file.Id] {
+			continue
+		}
+
+		seen[file.Id] = true
+		all = append(all, file)
+
+		if !file.IsDir {
+			continue
+		}
+
+		pagePair := c.rem.FindByParentId(file.Id, c.opts.Hidden)
+		errsChan := pagePair.errsChan
+		childrenChan := pagePair.filesChan
+
+		working := true
+		for working {
+			select {
+			case pErr := <-errsChan:
+				if pErr != nil {
+					err = reComposeError(err, fmt.Sprintf("%s: %v", file.Name, pErr))
+				}
+			case child, stillHasContent := <-childrenChan:
+				if !stillHasContent {
+					working = false
+					break
+				}
+				if child != nil {
+					queue = append(queue, child)
+				}
+			}
+		}
+	}
+
+	return all, err
+}
+
 func (c *Commands) share(revoke, byId bool) (err error) {
 	files := c.resolveRemotePaths(c.opts.Sources, byId)
 
+	if c.opts.Recursive {
+		files, err = c.descendants(files)
+		if err != nil {
+			return err
+		}
+	}
+
 	var emails []string
 	var emailMessage string
 
@@ -421,5 +531,12 @@ func (c *Commands) share(revoke, byId bool) (err error) {
 		}
 	}
 
-	return c.playShareChanges(&change)
+	if err := c.playShareChanges(&change); err != nil {
+		return err
+	}
+
+	if revoke {
+		return nil
+	}
+	return c.applyRestrictions(files)
 }
@@ -15,6 +15,7 @@
 package drive
 
 import (
+	"fmt"
 	"sort"
 	"strings"
 )
@@ -234,3 +235,125 @@ func (g *Commands) sort(fl []*File, attrStrValues ...string) []*File {
 
 	return fl
 }
+
+const (
+	OrderByName         = "name"
+	OrderByModifiedTime = "modifiedTime"
+	OrderBySize         = "size"
+	OrderByFolder       = "folder"
+)
+
+// orderByAPIKeys maps the keys accepted by `list -order-by` to those
+// understood by the files.list orderBy parameter. Keys missing
+// from this map have to be sorted locally.
+var orderByAPIKeys = map[string]string{
+	OrderByName:         "title",
+	OrderByModifiedTime: "modifiedDate",
+	OrderByFolder:       "folder",
+}
+
+var orderByLessCmpers = map[string]func(*File, *File) bool{
+	OrderByName:         nameCmpLess,
+	OrderByModifiedTime: modTimeCmpLess,
+	OrderBySize:         sizeCmpLess,
+	OrderByFolder:       nilCmpOrProceed(func(l, r *File) bool { return l.IsDir && !r.IsDir }),
+}
+
+type orderBySt struct {
+	keys    []string
+	reverse bool
+	// apiOrderBy when non-empty is the orderBy parameter that
+	// can be sent to the API, so no local sorting is needed.
+	apiOrderBy string
+}
+
+func parseOrderBy(keys []string, reverse bool) (*orderBySt, error) {
+	if len(keys) < 1 {
+		return nil, nil
+	}
+
+	ob := &orderBySt{reverse: reverse}
+	var apiKeys []string
+	apiCapable := true
+
+	for _, key := range keys {
+		canonical := ""
+		for knownKey := range orderByLessCmpers {
+			if strings.EqualFold(knownKey, key) {
+				canonical = knownKey
+				break
+			}
+		}
+
+		if canonical == "" {
+			return nil, invalidArgumentsErr(fmt.Errorf("order-by: unknown key %q, expecting any of %q, %q, %q, %q",
+				key, OrderByName, OrderByModifiedTime, OrderBySize, OrderByFolder))
+		}
+
+		ob.keys = append(ob.keys, canonical)
+
+		apiKey, ok := orderByAPIKeys[canonical]
+		if !ok {
+			apiCapable = false
+			continue
+		}
+		if reverse {
+			apiKey += " desc"
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if apiCapable {
+		ob.apiOrderBy = strings.Join(apiKeys, ",")
+	}
+
+	return ob, nil
+}
+
+// orderedFlist compares files by each of its keys in turn,
+// so that the first key is the primary ordering.
+type orderedFlist struct {
+	fl      fileList
+	lessFns []func(*File, *File) bool
+}
+
+func (ofl orderedFlist) Len() int {
+	return len(ofl.fl)
+}
+
+func (ofl orderedFlist) Swap(i, j int) {
+	ofl.fl[i], ofl.fl[j] = ofl.fl[j], ofl.fl[i]
+}
+
+func (ofl orderedFlist) Less(i, j int) bool {
+	l, r := ofl.fl[i], ofl.fl[j]
+	for _, lessFn := range ofl.lessFns {
+		if lessFn(l, r) {
+			return true
+		}
+		if lessFn(r, l) {
+			return false
+		}
+	}
+	return false
+}
+
+// sortLocally is the fallback for orderings that the API cannot perform.
+func (ob *orderBySt) sortLocally(fl []*File) []*File {
+	if ob == nil || ob.apiOrderBy != "" {
+		return fl
+	}
+
+	ofl := orderedFlist{fl: fl}
+	for _, key := range ob.keys {
+		ofl.lessFns = append(ofl.lessFns, orderByLessCmpers[key])
+	}
+
+	var sortInterface sort.Interface = ofl
+	if ob.reverse {
+		sortInterface = sort.Reverse(sortInterface)
+	}
+	sort.Stable(sortInterface)
+
+	return fl
+}
@@ -0,0 +1,110 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const PartialDownloadSuffix = ".part"
+
+// stagingDir is the directory in which downloads are written
+// to before being moved into their final destinations.
+func (g *Commands) stagingDir() string {
+	if g.opts != nil && g.opts.TempDir != "" {
+		return g.opts.TempDir
+	}
+	return os.TempDir()
+}
+
+// validateStagingDir ensures that the staging directory exists and is writable.
+func (g *Commands) validateStagingDir() error {
+	dir := g.stagingDir()
+	probe, err := ioutil.TempFile(dir, "drive-probe")
+	if err != nil {
+		return invalidArgumentsErr(fmt.Errorf("temp dir %q is not writable: %v", dir, err))
+	}
+
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+func (g *Commands) createStagingFile(destAbsPath string) (*os.File, error) {
+	prefix := fmt.Sprintf("%s%s", filepath.Base(destAbsPath), PartialDownloadSuffix)
+	return ioutil.TempFile(g.stagingDir(), prefix)
+}
+
+// downloadFileMode is the mode to give a download, which is that of
+// the file being replaced if any since staging files are only user accessible.
+func downloadFileMode(destAbsPath string) os.FileMode {
+	if fi, err := os.Stat(destAbsPath); err == nil {
+		return fi.Mode().Perm()
+	}
+	return 0644
+}
+
+func isCrossDeviceErr(err error) bool {
+	linkErr, ok := err.(*os.LinkError)
+	return ok && linkErr.Err == syscall.EXDEV
+}
+
+// moveFile moves the file at src to dest. Since renames cannot cross devices,
+// in that case the content is first copied next to dest and then renamed into
+// place so that dest never has partial content.
+func moveFile(src, dest string) error {
+	err := os.Rename(src, dest)
+	if err == nil || !isCrossDeviceErr(err) {
+		return err
+	}
+
+	fi, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fi.Close()
+
+	srcInfo, err := fi.Stat()
+	if err != nil {
+		return err
+	}
+
+	fo, err := ioutil.TempFile(filepath.Dir(dest), fmt.Sprintf(".%s%s", filepath.Base(dest), PartialDownloadSuffix))
+	if err != nil {
+		return err
+	}
+
+	if err = fo.Chmod(srcInfo.Mode().Perm()); err == nil {
+		_, err = io.Copy(fo, fi)
+	}
+	if cErr := fo.Close(); err == nil {
+		err = cErr
+	}
+
+	if err == nil {
+		err = os.Rename(fo.Name(), dest)
+	}
+
+	if err != nil {
+		os.Remove(fo.Name())
+		return err
+	}
+
+	return os.Remove(src)
+}
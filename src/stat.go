@@ -79,6 +79,18 @@ func prettyPermission(logf log.Loggerf, perm *drive.Permission) {
 	logf("*\n")
 }
 
+// prettyUser formats a user as their name followed by their email
+// address in angle brackets, as either of them may be missing.
+func prettyUser(name, email string) string {
+	switch {
+	case name == "":
+		return email
+	case email == "":
+		return name
+	}
+	return fmt.Sprintf("%s <%s>", name, email)
+}
+
 func prettyFileStat(logf log.Loggerf, relToRootPath string, file *File) {
 	dirType := "file"
 	if file.IsDir {
@@ -104,10 +116,22 @@ func prettyFileStat(logf log.Loggerf, relToRootPath string, file *File) {
 		&keyValue{"LastModifyingUsername", file.LastModifyingUsername},
 	}
 
+	if file.LastModifyingUserEmail != "" {
+		kvList = append(kvList, &keyValue{"LastModifyingUserEmail", file.LastModifyingUserEmail})
+	}
+
+	if sharingUser := prettyUser(file.SharingUserName, file.SharingUserEmail); sharingUser != "" {
+		kvList = append(kvList, &keyValue{"SharingUser", sharingUser})
+	}
+
 	if file.Description != "" {
 		kvList = append(kvList, &keyValue{"Description", fmt.Sprintf("%q", file.Description)})
 	}
 
+	if len(file.Properties) >= 1 {
+		kvList = append(kvList, &keyValue{"Properties", prettyProperties(file.Properties)})
+	}
+
 	if file.Name != file.OriginalFilename {
 		kvList = append(kvList, &keyValue{"OriginalFilename", file.OriginalFilename})
 	}
@@ -140,6 +164,9 @@ func (g *Commands) stat(relToRootPath string, file *File, depth int) error {
 		}
 	} else {
 		prettyFileStat(g.log.Logf, relToRootPath, file)
+		if multiParented(file) {
+			g.log.Logf("%-25s %-30v\n", "Parents", strings.Join(g.parentFolderPaths(file), " & "))
+		}
 		perms, permErr := g.rem.listPermissions(file.Id)
 		if permErr != nil {
 			return permErr
@@ -148,6 +175,12 @@ func (g *Commands) stat(relToRootPath string, file *File, depth int) error {
 		for _, perm := range perms {
 			prettyPermission(g.log.Logf, perm)
 		}
+
+		if g.opts.Activity {
+			if err := g.statActivity(file); err != nil {
+				return err
+			}
+		}
 	}
 
 	if depth == 0 {
@@ -0,0 +1,119 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// transferSummary tallies the outcomes of the changes played
+// by a push or pull for the report printed at the end.
+type transferSummary struct {
+	sync.Mutex
+	start time.Time
+
+	created int
+	updated int
+	deleted int
+	failed  int
+	bytes   int64
+}
+
+func (ts *transferSummary) begin() {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.start = time.Now()
+}
+
+// reset clears the tally for another push or pull by the same Commands.
+func (ts *transferSummary) reset() {
+	ts.Lock()
+	defer ts.Unlock()
+	ts.start = time.Time{}
+	ts.created, ts.updated, ts.deleted, ts.failed = 0, 0, 0, 0
+	ts.bytes = 0
+}
+
+func (ts *transferSummary) record(change *Change, err error) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	if err != nil {
+		ts.failed += 1
+		return
+	}
+
+	switch change.Op() {
+	case OpAdd:
+		ts.created += 1
+	case OpMod, OpModConflict:
+		ts.updated += 1
+	case OpDelete:
+		ts.deleted += 1
+		return
+	default:
+		return
+	}
+
+	if change.Src != nil && !change.Src.IsDir {
+		ts.bytes += change.Src.Size
+	}
+}
+
+// changed returns the number of files that were created, updated
+// or deleted so far, and the number of those that failed.
+func (ts *transferSummary) changed() (changed, failed int) {
+	ts.Lock()
+	defer ts.Unlock()
+	return ts.created + ts.updated + ts.deleted, ts.failed
+}
+
+// transferred returns the bytes of the files transferred so far.
+func (ts *transferSummary) transferred() int64 {
+	ts.Lock()
+	defer ts.Unlock()
+	return ts.bytes
+}
+
+// String formats the summary with skipped as the number of files that were skipped.
+func (ts *transferSummary) String(skipped int, elapsed time.Duration) string {
+	ts.Lock()
+	defer ts.Unlock()
+
+	throughput := int64(0)
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		throughput = int64(float64(ts.bytes) / seconds)
+	}
+
+	return fmt.Sprintf("%d created, %d updated, %d deleted, %d skipped, %d failed; transferred %s in %v (%s/s)",
+		ts.created, ts.updated, ts.deleted, skipped, ts.failed,
+		prettyBytes(ts.bytes), elapsed, prettyBytes(throughput))
+}
+
+// reportSummary prints the final report of a push or pull if only the summary was asked for.
+func (g *Commands) reportSummary() {
+	if !g.opts.SummaryOnly {
+		return
+	}
+
+	paths, _ := g.skippedNatives.sorted()
+	elapsed := time.Since(g.summary.start)
+	// Sub-millisecond precision is just noise in the report
+	elapsed -= elapsed % time.Millisecond
+
+	g.log.Logf("Summary: %s\n", g.summary.String(len(paths), elapsed))
+}
@@ -0,0 +1,165 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/odeke-em/drive/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+// refreshingTokenSource hands out the access token until it expires or
+// is invalidated, then refreshes it from the refresh token and saves it
+// so that later invocations can reuse it.
+type refreshingTokenSource struct {
+	sync.Mutex
+
+	ctx          context.Context
+	conf         *oauth2.Config
+	refreshToken string
+	token        *oauth2.Token
+	save         func(*oauth2.Token) error
+}
+
+func newRefreshingTokenSource(ctx context.Context, configContext *config.Context) *refreshingTokenSource {
+	ts := &refreshingTokenSource{
+		ctx:          ctx,
+		conf:         newAuthConfig(configContext),
+		refreshToken: configContext.RefreshToken,
+		save:         configContext.WriteToken,
+	}
+
+	// The saved token is of other credentials if they were set up again since
+	if saved, err := configContext.ReadToken(); err == nil && saved.RefreshToken == ts.refreshToken {
+		ts.token = saved
+	}
+	return ts
+}
+
+func (ts *refreshingTokenSource) Token() (*oauth2.Token, error) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	if ts.token.Valid() {
+		return ts.token, nil
+	}
+
+	refresher := ts.conf.TokenSource(ts.ctx, &oauth2.Token{RefreshToken: ts.refreshToken})
+	token, err := refresher.Token()
+	if err != nil {
+		return nil, refreshErr(err)
+	}
+
+	if token.RefreshToken == "" {
+		token.RefreshToken = ts.refreshToken
+	}
+
+	ts.token = token
+	if err := ts.save(token); err != nil {
+		DebugPrintf("token: saving %v", err)
+	}
+	return token, nil
+}
+
+// invalidate discards the token if it is still the current one,
+// for the next request to refresh it.
+func (ts *refreshingTokenSource) invalidate(stale *oauth2.Token) {
+	ts.Lock()
+	defer ts.Unlock()
+
+	if ts.token != nil && stale != nil && ts.token.AccessToken == stale.AccessToken {
+		ts.token = nil
+	}
+}
+
+// refreshErr only reports a revoked or expired refresh token as an
+// authentication failure, other failures to refresh could be transient.
+func refreshErr(err error) error {
+	rErr, ok := err.(*oauth2.RetrieveError)
+	if !ok || !strings.Contains(string(rErr.Body), "invalid_grant") {
+		return err
+	}
+	return makeError(fmt.Errorf("the refresh token was revoked or has expired, run `drive %s` to set up new credentials", InitKey), StatusAuthenticationFailed)
+}
+
+// refreshingTransport authorizes requests with the access token of
+// its source. A request that gets rejected with a 401, e.g because the
+// token was revoked early or the clock is off, is retried once with a
+// freshly refreshed token.
+type refreshingTransport struct {
+	source *refreshingTokenSource
+	base   http.RoundTripper
+}
+
+func (rt *refreshingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	token, err := rt.source.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := rt.base.RoundTrip(authorized(req, token))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	rt.source.invalidate(token)
+	// The body of a request was consumed, it can only be replayed if it can
+	// be gotten anew, otherwise the request is left to the retries of
+	// the operation with the new token.
+	var body io.ReadCloser
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return res, nil
+		}
+		if body, err = req.GetBody(); err != nil {
+			return res, nil
+		}
+	}
+
+	res.Body.Close()
+	if token, err = rt.source.Token(); err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return nil, err
+	}
+
+	retry := authorized(req, token)
+	if body != nil {
+		retry.Body = body
+	}
+	return rt.base.RoundTrip(retry)
+}
+
+// authorized returns a copy of req with the token set, since
+// round trippers mustn't modify the requests that they are given.
+func authorized(req *http.Request, token *oauth2.Token) *http.Request {
+	authReq := new(http.Request)
+	*authReq = *req
+
+	authReq.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		authReq.Header[k] = append([]string(nil), v...)
+	}
+
+	token.SetAuthHeader(authReq)
+	return authReq
+}
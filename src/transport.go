@@ -0,0 +1,119 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+	"golang.org/x/net/context"
+	"golang.org/x/oauth2"
+)
+
+const (
+	DefaultIdleConnTimeout = 90 * time.Second
+
+	// connStatsInterval is the number of connections
+	// after which the connection reuse stats are logged.
+	connStatsInterval = 100
+)
+
+// transportSettings returns the connection pool settings configured in
+// the context, defaulting to ones scaled to the number of concurrent
+// transfers so that their connections get reused rather than reopened.
+func transportSettings(c *config.Context) (maxIdleConns, maxIdleConnsPerHost int, idleConnTimeout time.Duration) {
+	// Each concurrent transfer could hold a connection to the API and another for the content
+	maxIdleConnsPerHost = 2 * maxProcs()
+	maxIdleConns = 2 * maxIdleConnsPerHost
+	idleConnTimeout = DefaultIdleConnTimeout
+
+	if c == nil {
+		return
+	}
+	if c.MaxIdleConns > 0 {
+		maxIdleConns = c.MaxIdleConns
+	}
+	if c.MaxIdleConnsPerHost > 0 {
+		maxIdleConnsPerHost = c.MaxIdleConnsPerHost
+	}
+	if c.IdleConnTimeoutSeconds > 0 {
+		idleConnTimeout = time.Duration(c.IdleConnTimeoutSeconds) * time.Second
+	}
+	return
+}
+
+func newTransport(c *config.Context) http.RoundTripper {
+	maxIdleConns, maxIdleConnsPerHost, idleConnTimeout := transportSettings(c)
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		// A custom dialer otherwise turns off HTTP/2
+		ForceAttemptHTTP2: true,
+
+		MaxIdleConns:        maxIdleConns,
+		MaxIdleConnsPerHost: maxIdleConnsPerHost,
+		IdleConnTimeout:     idleConnTimeout,
+	}
+
+	if !Debug() {
+		return transport
+	}
+
+	DebugPrintf("transport: maxIdleConns=%d maxIdleConnsPerHost=%d idleConnTimeout=%v",
+		maxIdleConns, maxIdleConnsPerHost, idleConnTimeout)
+	return &connStatsTransport{RoundTripper: transport}
+}
+
+// connStatsTransport keeps track of how many connections
+// were reused, to help with tuning the connection pool.
+type connStatsTransport struct {
+	http.RoundTripper
+
+	conns  uint64
+	reused uint64
+}
+
+func (cst *connStatsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				atomic.AddUint64(&cst.reused, 1)
+			}
+			if conns := atomic.AddUint64(&cst.conns, 1); conns%connStatsInterval == 0 {
+				DebugPrintf("transport: %d of %d connections were reused", atomic.LoadUint64(&cst.reused), conns)
+			}
+		},
+	}
+
+	return cst.RoundTripper.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+}
+
+// transportContext returns a context that makes oauth2 clients use
+// a transport tuned with the settings from c.
+func transportContext(c *config.Context) context.Context {
+	return context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+		Transport: newTransport(c),
+	})
+}
@@ -17,6 +17,9 @@ package drive
 import (
 	"fmt"
 	// "path/filepath"
+
+	expb "github.com/odeke-em/exponential-backoff"
+	"github.com/odeke-em/semalim"
 )
 
 type trashOpt struct {
@@ -242,10 +245,28 @@ func (g *Commands) reduceForTrash(args []string, opt *trashOpt) error {
 	return g.playTrashChangeList(cl, opt)
 }
 
-func (g *Commands) playTrashChangeList(cl []*Change, opt *trashOpt) (err error) {
-	trashSize, unTrashSize := reduceToSize(cl, SelectDest|SelectSrc)
-	g.taskStart(trashSize + unTrashSize)
+// name names the operation for its checkpoint and final tally.
+func (opt *trashOpt) name() string {
+	switch {
+	case opt.permanent:
+		return "delete"
+	case opt.toTrash:
+		return "trash"
+	default:
+		return "untrash"
+	}
+}
 
+// trashTarget returns the remote file that the change
+// operates on, keyed by id in the checkpoint.
+func trashTarget(c *Change, opt *trashOpt) *File {
+	if !opt.permanent && !opt.toTrash {
+		return c.Src
+	}
+	return c.Dest
+}
+
+func (g *Commands) playTrashChangeList(cl []*Change, opt *trashOpt) (err error) {
 	var fn func(*Change) error
 	if opt.permanent {
 		fn = g.remoteDelete
@@ -258,19 +279,85 @@ func (g *Commands) playTrashChangeList(cl []*Change, opt *trashOpt) (err error)
 		g.DebugPrintf("[playTrashChangeList/nonPermanentOp]: toTrash: %v\n", opt.toTrash)
 	}
 
+	name := opt.name()
+	cp := newCheckpointer(g, name)
+
+	var pending []*Change
+	skipped := 0
 	for i, c := range cl {
-		op := c.Op()
-		g.DebugPrintf("[playTrashChangeList] #%d op: %v change: %#v\n", i, op, c)
+		g.DebugPrintf("[playTrashChangeList] #%d op: %v change: %#v\n", i, c.Op(), c)
 		if c.Op() == OpNone {
 			continue
 		}
 
-		cErr := fn(c)
-		if cErr != nil {
-			g.log.LogErrln(cErr)
+		target := trashTarget(c, opt)
+		if target == nil {
+			continue
+		}
+		if cp.completed[target.Id] {
+			skipped += 1
+			continue
 		}
+		pending = append(pending, c)
+	}
+
+	if skipped >= 1 {
+		g.log.Logf("%s: resuming, skipping %d already completed changes\n", name, skipped)
+	}
+
+	trashSize, unTrashSize := reduceToSize(pending, SelectDest|SelectSrc)
+	g.taskStart(trashSize + unTrashSize)
+
+	debug := g.opts.Verbose && g.opts.canPreview()
+	jobsChan := make(chan semalim.Job)
+
+	go func() {
+		defer close(jobsChan)
+
+		for i, c := range pending {
+			c := c
+			do := func() (interface{}, error) {
+				emitter := func() (interface{}, error) {
+					return c, fn(c)
+				}
+
+				// Bulk trashing gets rate limited just like any other
+				// bulk operation so each change is retried with backoff.
+				retrier := retryableChangeOp(emitter, debug, g.opts.ExponentialBackoffRetryCount)
+				_, err := expb.ExponentialBackOffSync(retrier)
+				return c, err
+			}
+
+			jobsChan <- jobSt{id: uint64(i), do: do}
+		}
+	}()
+
+	successes, failures := 0, 0
+	bytes := int64(0)
+
+	results := semalim.Run(jobsChan, uint64(maxProcs()))
+	for result := range results {
+		c, _ := result.Value().(*Change)
+		if c == nil {
+			continue
+		}
+
+		target := trashTarget(c, opt)
+		if rErr := result.Err(); rErr != nil {
+			failures += 1
+			g.log.LogErrf("%s: %s %v\n", name, c.Path, rErr)
+			err = reComposeError(err, fmt.Sprintf("%s: %v", c.Path, rErr))
+			continue
+		}
+
+		successes += 1
+		bytes += target.Size
+		cp.done(target.Id)
 	}
 
 	g.taskFinish()
+	cp.finish(err)
+
+	g.log.Logf("%s: %d file(s) done (%s), %d failed, %d skipped\n", name, successes, prettyBytes(bytes), failures, skipped)
 	return err
 }
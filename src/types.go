@@ -62,7 +62,9 @@ const (
 )
 
 const (
-	DriveFolderMimeType = "application/vnd.google-apps.folder"
+	DriveFolderMimeType   = "application/vnd.google-apps.folder"
+	DriveShortcutMimeType = "application/vnd.google-apps.shortcut"
+	OctetStreamMimeType   = "application/octet-stream"
 )
 
 // Arbitrary value. TODO: Get better definition of BigFileSize.
@@ -86,7 +88,9 @@ type ParentFile struct {
 type File struct {
 	// AlternateLink opens the file in a relevant Google editor or viewer
 	AlternateLink string
-	BlobAt        string
+	// WebContentLink downloads the content of the file directly
+	WebContentLink string
+	BlobAt         string
 	// Copyable decides if the user has allowed for the file to be copied
 	Copyable           bool
 	ExportLinks        map[string]string
@@ -116,6 +120,25 @@ type File struct {
 	Description           string
 	Parents               []*ParentFile
 	QuotaBytesUsed        int64
+	// Properties are the custom key/value properties set on the file
+	Properties []*drive.Property
+	// ShortcutTargetId and ShortcutTargetMimeType are only set
+	// for shortcuts and describe the file that they point to.
+	ShortcutTargetId       string
+	ShortcutTargetMimeType string
+	// CopyRequiresWriterPermission when set keeps readers and commenters from
+	// downloading, printing and copying the file. WritersCanShare lets writers
+	// share the file with others.
+	CopyRequiresWriterPermission bool
+	WritersCanShare              bool
+	// OwnedByMe is set if the authenticated user is one of the owners.
+	OwnedByMe bool
+	// LastModifyingUserEmail is the email address of the last user to modify
+	// the file. SharingUserName and SharingUserEmail are those of the user
+	// who shared the file with the authenticated user, if anyone did.
+	LastModifyingUserEmail string
+	SharingUserName        string
+	SharingUserEmail       string
 }
 
 func newParentFile(p *drive.ParentReference) *ParentFile {
@@ -142,8 +165,24 @@ func NewRemoteFile(f *drive.File) *File {
 		return pfl
 	}(f.Parents)
 
+	var shortcutTargetId, shortcutTargetMimeType string
+	if f.ShortcutDetails != nil {
+		shortcutTargetId = f.ShortcutDetails.TargetId
+		shortcutTargetMimeType = f.ShortcutDetails.TargetMimeType
+	}
+
+	var lastModifyingUserEmail, sharingUserName, sharingUserEmail string
+	if f.LastModifyingUser != nil {
+		lastModifyingUserEmail = f.LastModifyingUser.EmailAddress
+	}
+	if f.SharingUser != nil {
+		sharingUserName = f.SharingUser.DisplayName
+		sharingUserEmail = f.SharingUser.EmailAddress
+	}
+
 	return &File{
 		AlternateLink:      f.AlternateLink,
+		WebContentLink:     f.WebContentLink,
 		BlobAt:             f.DownloadUrl,
 		Copyable:           f.Copyable,
 		Etag:               f.Etag,
@@ -168,6 +207,18 @@ func NewRemoteFile(f *drive.File) *File {
 		Description:           f.Description,
 		Parents:               parents,
 		QuotaBytesUsed:        f.QuotaBytesUsed,
+		Properties:            f.Properties,
+
+		ShortcutTargetId:       shortcutTargetId,
+		ShortcutTargetMimeType: shortcutTargetMimeType,
+
+		CopyRequiresWriterPermission: f.CopyRequiresWriterPermission,
+		WritersCanShare:              f.WritersCanShare,
+		OwnedByMe:                    ownedByMe(f.Owners),
+
+		LastModifyingUserEmail: lastModifyingUserEmail,
+		SharingUserName:        sharingUserName,
+		SharingUserEmail:       sharingUserEmail,
 	}
 }
 
@@ -197,10 +248,24 @@ func DupFile(f *File) *File {
 		LastViewedByMeTime: f.LastViewedByMeTime,
 		Labels:             f.Labels,
 		AlternateLink:      f.AlternateLink,
+		WebContentLink:     f.WebContentLink,
 		OriginalFilename:   f.OriginalFilename,
 		Description:        f.Description,
 		Parents:            f.Parents,
 		QuotaBytesUsed:     f.QuotaBytesUsed,
+		Properties:         f.Properties,
+
+		ShortcutTargetId:       f.ShortcutTargetId,
+		ShortcutTargetMimeType: f.ShortcutTargetMimeType,
+
+		CopyRequiresWriterPermission: f.CopyRequiresWriterPermission,
+		WritersCanShare:              f.WritersCanShare,
+		OwnedByMe:                    f.OwnedByMe,
+
+		LastModifyingUsername:  f.LastModifyingUsername,
+		LastModifyingUserEmail: f.LastModifyingUserEmail,
+		SharingUserName:        f.SharingUserName,
+		SharingUserEmail:       f.SharingUserEmail,
 	}
 }
 
@@ -263,6 +328,10 @@ func (f *File) localAliases(prefix string) (aliases []string) {
 	if runtime.GOOS == OSLinuxKey && hasExportLinks(f) {
 		suffixes = append(suffixes, DesktopExtension)
 	}
+	// The internet shortcuts of -link-shortcuts stand in for natives too
+	if ext := linkShortcutExtension(runtime.GOOS); googleNative(f) && !(ext == DesktopExtension && hasExportLinks(f)) {
+		suffixes = append(suffixes, ext)
+	}
 
 	for _, suffix := range suffixes {
 		join := sepJoin(".", prefix, suffix)
@@ -327,6 +396,10 @@ func (op *Operation) description() (symbol, info string) {
 	}
 }
 
+func (f *File) isShortcut() bool {
+	return f != nil && f.MimeType == DriveShortcutMimeType
+}
+
 func (f *File) largeFile() bool {
 	return f.Size > BigFileSize
 }
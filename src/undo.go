@@ -0,0 +1,148 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/odeke-em/drive/config"
+	expb "github.com/odeke-em/exponential-backoff"
+)
+
+// The actions recorded in the undo log. Trashed files can be
+// untrashed, while permanent deletions can only be reported.
+const (
+	UndoActionTrash  = "trash"
+	UndoActionDelete = "delete"
+)
+
+// undoLog appends the files affected by the destructive changes of
+// a command to the undo log, all under the same operation.
+type undoLog struct {
+	sync.Mutex
+	op string
+}
+
+// recordUndo logs that action was applied to f at p. Failing to log
+// doesn't fail the change that was already applied, it is only reported.
+func (g *Commands) recordUndo(action, p string, f *File) {
+	if f == nil {
+		return
+	}
+
+	g.undo.Lock()
+	defer g.undo.Unlock()
+
+	now := time.Now()
+	if g.undo.op == "" {
+		g.undo.op = strconv.FormatInt(now.UnixNano(), 10)
+	}
+
+	entry := &config.UndoEntry{
+		Op:     g.undo.op,
+		Time:   now.UTC().Format(time.RFC3339),
+		Action: action,
+		Id:     f.Id,
+		Path:   p,
+	}
+	if err := g.context.AppendUndoEntry(entry); err != nil {
+		g.log.LogErrf("undo log: %s %v\n", p, err)
+	}
+}
+
+// lastUndoOp splits the entries of the last operation in the undo
+// log from those of the operations before it.
+func lastUndoOp(entries []*config.UndoEntry) (last, rest []*config.UndoEntry) {
+	if len(entries) < 1 {
+		return nil, nil
+	}
+
+	op := entries[len(entries)-1].Op
+	for _, entry := range entries {
+		if entry.Op == op {
+			last = append(last, entry)
+		} else {
+			rest = append(rest, entry)
+		}
+	}
+	return last, rest
+}
+
+// Undo reverses the last operation recorded in the undo log by untrashing
+// the files that it trashed. Permanently deleted files are only reported.
+// The files that couldn't be untrashed are kept in the log to retry.
+func (g *Commands) Undo() (err error) {
+	entries, err := g.context.ReadUndoLog()
+	if err != nil {
+		return err
+	}
+
+	last, rest := lastUndoOp(entries)
+	if len(last) < 1 {
+		return noMatchesFoundErr(fmt.Errorf("nothing to undo"))
+	}
+
+	var untrashable, deleted []*config.UndoEntry
+	for _, entry := range last {
+		if entry.Action == UndoActionTrash {
+			untrashable = append(untrashable, entry)
+		} else {
+			deleted = append(deleted, entry)
+		}
+	}
+
+	for _, entry := range deleted {
+		g.log.LogErrf("%s (%s) was permanently deleted on %s and cannot be restored\n", entry.Path, entry.Id, entry.Time)
+	}
+
+	if len(untrashable) >= 1 {
+		for _, entry := range untrashable {
+			g.log.Logf("untrash %s (%s)\n", entry.Path, entry.Id)
+		}
+
+		if g.opts.canPrompt() {
+			if status := promptForChanges(); !accepted(status) {
+				return status.Error()
+			}
+		}
+	}
+
+	debug := g.opts.Verbose && g.opts.canPreview()
+	var failed []*config.UndoEntry
+	restored := 0
+	for _, entry := range untrashable {
+		id := entry.Id
+		retrier := retryableChangeOp(func() (interface{}, error) {
+			return id, g.rem.Untrash(id)
+		}, debug, g.opts.ExponentialBackoffRetryCount)
+
+		if _, uErr := expb.ExponentialBackOffSync(retrier); uErr != nil {
+			failed = append(failed, entry)
+			err = reComposeError(err, fmt.Sprintf("%s (%s): %v", entry.Path, entry.Id, uErr))
+			continue
+		}
+		restored += 1
+	}
+
+	if wErr := g.context.WriteUndoLog(append(rest, failed...)); wErr != nil {
+		err = reComposeError(err, fmt.Sprintf("undo log: %v", wErr))
+	}
+
+	g.log.Logf("undo: %d file(s) untrashed, %d failed, %d permanently deleted\n", restored, len(failed), len(deleted))
+	return err
+}
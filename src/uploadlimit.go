@@ -0,0 +1,83 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"google.golang.org/api/googleapi"
+)
+
+// The reasons of the 403s that Drive returns once the quota of the day has
+// been used up, e.g. the 750GB that a user can upload each day. Retrying
+// these is pointless until the quota is reset.
+var dailyLimitReasons = map[string]bool{
+	"dailyLimitExceeded":   true,
+	"uploadLimitExceeded":  true,
+	"quotaExceeded":        true,
+	"storageQuotaExceeded": true,
+}
+
+// msgDailyUserRateLimitExceeded is the message that distinguishes the daily
+// upload limit from the transient userRateLimitExceeded of too many requests
+// per second, which shares its reason but is worth backing off and retrying.
+const msgDailyUserRateLimitExceeded = "User rate limit exceeded."
+
+// isDailyUploadLimitErr reports whether err is Drive refusing any more
+// uploads for the day, as opposed to a rate limit to back off from.
+func isDailyUploadLimitErr(err error) bool {
+	switch e := err.(type) {
+	case *Error:
+		return e != nil && isDailyUploadLimitErr(e.err)
+	case Error:
+		return isDailyUploadLimitErr(e.err)
+	case *googleapi.Error:
+		if e == nil || e.Code != 403 {
+			return false
+		}
+		for _, item := range e.Errors {
+			if dailyLimitReasons[item.Reason] {
+				return true
+			}
+			if item.Reason == "userRateLimitExceeded" && item.Message == msgDailyUserRateLimitExceeded {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// uploadLimitReached reports whether an upload of this run hit the daily limit.
+func (g *Commands) uploadLimitReached() bool {
+	return atomic.LoadInt32(&g.uploadLimitHit) != 0
+}
+
+// noteUploadLimit wraps fn to record the first upload that hits the
+// daily limit, after which no more changes are to be started.
+func (g *Commands) noteUploadLimit(fn func(*Change) error) func(*Change) error {
+	return func(c *Change) error {
+		err := fn(c)
+		if isDailyUploadLimitErr(err) {
+			atomic.StoreInt32(&g.uploadLimitHit, 1)
+		}
+		return err
+	}
+}
+
+func (g *Commands) uploadLimitErr(remaining int) error {
+	return uploadLimitReachedErr(fmt.Errorf("daily upload limit reached after uploading %s this run, %d change(s) left; resume with -resume later",
+		prettyBytes(g.summary.transferred()), remaining))
+}
@@ -0,0 +1,240 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+)
+
+type verifyStatus string
+
+const (
+	VerifyOK            verifyStatus = "OK"
+	VerifyMismatch      verifyStatus = "MISMATCH"
+	VerifyMissingLocal  verifyStatus = "MISSING-LOCAL"
+	VerifyMissingRemote verifyStatus = "MISSING-REMOTE"
+	VerifySkipped       verifyStatus = "SKIPPED"
+	VerifyCorrupt       verifyStatus = "CORRUPT"
+)
+
+type verifyTally struct {
+	counts map[verifyStatus]int
+}
+
+func (vt *verifyTally) failed() int {
+	return vt.counts[VerifyMismatch] + vt.counts[VerifyMissingLocal] + vt.counts[VerifyMissingRemote] + vt.counts[VerifyCorrupt]
+}
+
+// Verify compares the md5 checksums of local files against those of their
+// remote counterparts without transferring any content.
+func (g *Commands) Verify() (err error) {
+	tally := &verifyTally{counts: make(map[verifyStatus]int)}
+
+	for _, relToRoot := range g.opts.Sources {
+		var local, remote *File
+
+		absPath := g.context.AbsPathOf(relToRoot)
+		if fi, lErr := os.Stat(absPath); lErr == nil {
+			local = NewLocalFile(absPath, fi)
+		} else if !os.IsNotExist(lErr) {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRoot, lErr))
+			continue
+		}
+
+		remote, rErr := g.rem.FindByPath(relToRoot)
+		if rErr != nil && rErr != ErrPathNotExists {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRoot, rErr))
+			continue
+		}
+
+		if local == nil && remote == nil {
+			err = reComposeError(err, fmt.Sprintf("%s: %v", relToRoot, ErrPathNotExists))
+			continue
+		}
+
+		if vErr := g.verify(relToRoot, local, remote, tally); vErr != nil {
+			err = reComposeError(err, vErr.Error())
+		}
+	}
+
+	g.log.Logf("%d ok, %d mismatched, %d missing locally, %d missing remotely, %d skipped\n",
+		tally.counts[VerifyOK], tally.counts[VerifyMismatch], tally.counts[VerifyMissingLocal],
+		tally.counts[VerifyMissingRemote], tally.counts[VerifySkipped])
+	if g.opts.VerifyDeep {
+		g.log.Logf("%d corrupt on the remote\n", tally.counts[VerifyCorrupt])
+	}
+
+	if failed := tally.failed(); failed >= 1 {
+		err = reComposeError(err, fmt.Sprintf("%d file(s) failed verification", failed))
+		return verificationFailedErr(err)
+	}
+
+	return err
+}
+
+func (g *Commands) reportVerification(status verifyStatus, relPath string, tally *verifyTally) {
+	tally.counts[status] += 1
+	if status == VerifyOK && !g.opts.Verbose {
+		return
+	}
+	g.log.Logf("%-14s %s\n", status, relPath)
+}
+
+func (g *Commands) verify(relPath string, local, remote *File, tally *verifyTally) error {
+	if local == nil && remote == nil {
+		return nil
+	}
+
+	localIsDir, remoteIsDir := local != nil && local.IsDir, remote != nil && remote.IsDir
+	if localIsDir || remoteIsDir {
+		if local != nil && remote != nil && localIsDir != remoteIsDir {
+			g.reportVerification(VerifyMismatch, relPath, tally)
+			return nil
+		}
+		return g.verifyChildren(relPath, local, remote, tally)
+	}
+
+	if g.opts.VerifyDeep && remote != nil && remote.Md5Checksum != "" {
+		downloaded, err := g.downloadedChecksum(remote)
+		if err != nil {
+			return fmt.Errorf("%s: %v", relPath, err)
+		}
+		if downloaded != remote.Md5Checksum {
+			g.reportVerification(VerifyCorrupt, relPath, tally)
+			g.log.LogErrf("  stored md5 %s, downloaded content has md5 %s\n", remote.Md5Checksum, downloaded)
+			return nil
+		}
+		// The remote content is intact, which is all that can be
+		// verified of files that have no local copy.
+		if local == nil {
+			g.reportVerification(VerifyOK, relPath, tally)
+			return nil
+		}
+	}
+
+	switch {
+	case local == nil:
+		g.reportVerification(VerifyMissingLocal, relPath, tally)
+	case remote == nil:
+		g.reportVerification(VerifyMissingRemote, relPath, tally)
+	case remote.Md5Checksum == "":
+		// Google Docs and the like have no md5Checksum to compare against
+		g.reportVerification(VerifySkipped, relPath, tally)
+	case md5Checksum(local) != remote.Md5Checksum:
+		g.reportVerification(VerifyMismatch, relPath, tally)
+	default:
+		g.reportVerification(VerifyOK, relPath, tally)
+	}
+
+	return nil
+}
+
+// downloadedChecksum streams the content of f to compute its md5
+// checksum, without keeping any of it.
+func (g *Commands) downloadedChecksum(f *File) (string, error) {
+	body, err := g.rem.Download(f.Id, "")
+	if err != nil {
+		return "", err
+	}
+	defer body.Close()
+
+	h := md5.New()
+	if _, err := io.Copy(h, body); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)), nil
+}
+
+func (g *Commands) verifyChildren(relPath string, local, remote *File, tally *verifyTally) (err error) {
+	locals := make(map[string]*File)
+	remotes := make(map[string]*File)
+
+	if local != nil && local.IsDir {
+		fslArg := fsListingArg{
+			parent:  relPath,
+			context: g.context,
+			hidden:  g.opts.Hidden,
+			depth:   -1,
+			ignore:  g.opts.Ignorer,
+		}
+
+		localChildren, lErr := list(&fslArg)
+		if lErr != nil && !os.IsNotExist(lErr) {
+			return lErr
+		}
+		for child := range localChildren {
+			if child != nil {
+				locals[child.Name] = child
+			}
+		}
+	}
+
+	if remote != nil && remote.IsDir {
+		pagePair := g.rem.FindByParentId(remote.Id, g.opts.Hidden)
+		errsChan := pagePair.errsChan
+		childrenChan := pagePair.filesChan
+
+		var listErr error
+		working := true
+		for working {
+			select {
+			case pErr := <-errsChan:
+				if pErr != nil && listErr == nil {
+					listErr = pErr
+				}
+			case child, stillHasContent := <-childrenChan:
+				if !stillHasContent {
+					working = false
+					break
+				}
+				if child != nil && !anyMatch(g.opts.Ignorer, child.Name) {
+					remotes[child.Name] = child
+				}
+			}
+		}
+
+		// Verifying against part of the remote children would report
+		// the local ones whose remotes didn't come through as missing
+		if listErr != nil {
+			return fmt.Errorf("%s: %v", relPath, listErr)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var names []string
+	for _, group := range []map[string]*File{locals, remotes} {
+		for name := range group {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		if vErr := g.verify(path.Join(relPath, name), locals[name], remotes[name], tally); vErr != nil {
+			err = reComposeError(err, vErr.Error())
+		}
+	}
+
+	return err
+}
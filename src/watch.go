@@ -0,0 +1,246 @@
+// Copyright 2016 Google Inc. All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package drive
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/odeke-em/drive/config"
+)
+
+const DefaultWatchDebounce = 2 * time.Second
+
+// Watch monitors the sources for local changes and pushes the affected
+// paths once no further changes have been seen for opts.Debounce.
+func (g *Commands) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, relToRootPath := range g.opts.Sources {
+		if err := g.watchTree(watcher, g.context.AbsPathOf(relToRootPath)); err != nil {
+			return err
+		}
+	}
+
+	debounce := g.opts.Debounce
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	g.log.Logf("Watching %v for changes\n", g.opts.Sources)
+
+	pending := make(map[string]bool)
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			relToRootPath, watched := g.watchedPath(event.Name)
+			if !watched {
+				continue
+			}
+
+			// Folders created after watching began, including those moved
+			// in, also need to be watched.
+			if event.Op&fsnotify.Create != 0 {
+				if fi, sErr := os.Stat(event.Name); sErr == nil && fi.IsDir() {
+					if wErr := g.watchTree(watcher, event.Name); wErr != nil {
+						g.log.LogErrf("watch: %s %v\n", relToRootPath, wErr)
+					}
+				}
+			}
+
+			pending[relToRootPath] = true
+			timer.Reset(debounce)
+
+		case wErr, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			g.log.LogErrf("watch: %v\n", wErr)
+
+		case <-timer.C:
+			sources := g.watchedPushSources(pending)
+			pending = make(map[string]bool)
+			if len(sources) < 1 {
+				continue
+			}
+
+			g.log.Logf("[%s] syncing %v\n", time.Now().Format(time.RFC3339), sources)
+			if pErr := g.watchPush(sources); pErr != nil {
+				g.log.LogErrf("watch: push %v\n", pErr)
+			} else {
+				g.log.Logf("[%s] synced %d path(s)\n", time.Now().Format(time.RFC3339), len(sources))
+			}
+		}
+	}
+}
+
+// PollPull periodically queries the changes feed and pulls the
+// sources whenever there were remote changes since the last poll.
+func (g *Commands) PollPull() error {
+	about, err := g.rem.About()
+	if err != nil {
+		return err
+	}
+
+	nextChangeId := about.LargestChangeId + 1
+	sources := g.opts.Sources
+
+	ticker := time.NewTicker(g.opts.PollInterval)
+	defer ticker.Stop()
+
+	g.log.Logf("Polling for remote changes to %v every %v\n", sources, g.opts.PollInterval)
+
+	for range ticker.C {
+		changes, cErr := g.rem.changes(nextChangeId)
+		if cErr != nil {
+			g.log.LogErrf("poll: %v\n", cErr)
+			continue
+		}
+
+		changedIds := make(map[string]bool)
+		for change := range changes {
+			if change == nil {
+				continue
+			}
+			if change.Id >= nextChangeId {
+				nextChangeId = change.Id + 1
+			}
+			changedIds[change.FileId] = true
+		}
+
+		if len(changedIds) < 1 {
+			g.log.Logf("[%s] 0 file(s) updated\n", time.Now().Format(time.RFC3339))
+			continue
+		}
+
+		// Each pull closes the progress channel once it is done with it,
+		// and only reports the files and failures of its own poll
+		g.rem.progressChan = make(chan int)
+		g.resetRun()
+		g.opts.Sources = sources
+		if pErr := g.Pull(); pErr != nil {
+			g.log.LogErrf("poll: pull %v\n", pErr)
+		}
+
+		updated, failed := g.summary.changed()
+		g.log.Logf("[%s] %d file(s) updated, %d failed\n", time.Now().Format(time.RFC3339), updated, failed)
+	}
+
+	return nil
+}
+
+func (g *Commands) watchTree(watcher *fsnotify.Watcher, absPath string) error {
+	return filepath.Walk(absPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			// Paths can disappear between being listed and walked
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if !fi.IsDir() {
+			return nil
+		}
+
+		if p != absPath {
+			if _, watched := g.watchedPath(p); !watched {
+				return filepath.SkipDir
+			}
+		}
+
+		return watcher.Add(p)
+	})
+}
+
+// watchedPath returns the path relative to the root of the context of absPath
+// and whether or not changes to it should be pushed.
+func (g *Commands) watchedPath(absPath string) (string, bool) {
+	rootAbsPath := g.context.AbsPathOf("")
+	relPath, err := filepath.Rel(rootAbsPath, absPath)
+	if err != nil || strings.HasPrefix(relPath, "..") {
+		return "", false
+	}
+
+	for _, segment := range strings.Split(relPath, string(filepath.Separator)) {
+		if segment == config.GDDirSuffix || isHidden(segment, g.opts.Hidden) {
+			return "", false
+		}
+	}
+
+	relToRootPath := localPathJoin(relPath)
+	if anyMatch(g.opts.Ignorer, filepath.Base(absPath), relToRootPath) {
+		return "", false
+	}
+
+	return relToRootPath, true
+}
+
+// watchedPushSources reduces the changed paths to those to push. Paths that no
+// longer exist, for example after deletions or the renames of atomic saves by
+// editors, are pushed by their parents so that the removals get propagated.
+// Descendants of any path to push are dropped since the push covers them.
+func (g *Commands) watchedPushSources(changed map[string]bool) []string {
+	candidates := make(map[string]bool)
+	for relToRootPath := range changed {
+		for !rootLike(relToRootPath) {
+			if _, err := os.Lstat(g.context.AbsPathOf(relToRootPath)); err == nil {
+				break
+			}
+			relToRootPath = filepath.Dir(relToRootPath)
+		}
+		candidates[relToRootPath] = true
+	}
+
+	var sources []string
+	for relToRootPath := range candidates {
+		covered := false
+		for other := range candidates {
+			if other != relToRootPath && (rootLike(other) || strings.HasPrefix(relToRootPath, other+"/")) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			sources = append(sources, relToRootPath)
+		}
+	}
+
+	sort.Strings(sources)
+	return sources
+}
+
+func (g *Commands) watchPush(sources []string) error {
+	// Each push closes the progress channel once it is done with it
+	g.rem.progressChan = make(chan int)
+
+	g.opts.Sources = sources
+	return g.Push()
+}